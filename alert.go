@@ -0,0 +1,183 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//Severity grades an alert.
+type Severity int
+
+//Severities from least to most urgent
+const (
+	SeverityDebug Severity = iota
+	SeverityInfo
+	SeverityWarn
+	SeverityError
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityDebug:
+		return "debug"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarn:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+//Priority is the queue priority alerts of the severity are sent at: debug and info alerts are low priority and shed
+//during quiet hours, warnings are normal priority and held during quiet hours, and errors and criticals are high
+//priority and bypass them.
+func (s Severity) Priority() Priority {
+	switch {
+	case s >= SeverityError:
+		return PriorityHigh
+	case s == SeverityWarn:
+		return PriorityNormal
+	}
+	return PriorityLow
+}
+
+//TitlePrefix is prepended to the titles of alerts of the severity.
+func (s Severity) TitlePrefix() string {
+	switch s {
+	case SeverityDebug:
+		return "[DEBUG] "
+	case SeverityInfo:
+		return "[INFO] "
+	case SeverityWarn:
+		return "[WARN] "
+	case SeverityError:
+		return "[ERROR] "
+	case SeverityCritical:
+		return "[CRIT] "
+	}
+	return ""
+}
+
+//Alerter is the single entry point for monitoring scripts: alerts are formatted by severity, routed to targets by
+//the Router, and sent through the Queue at the severity's priority so that quiet hours apply.
+//
+//	a := pushbullet.NewAlerter(client)
+//	a.Router = &pushbullet.Router{Rules: rules, Default: []pushbullet.Target{pushbullet.DeviceTarget(phone)}}
+//	a.Alert(pushbullet.SeverityCritical, "db down", err.Error())
+type Alerter struct {
+	Client *Client
+	// Router chooses the targets of each alert, as an Event with the severity's name and Source. Its Notifier is
+	// not used. Alerts go to the OnCall targets, or all of the user's devices, when it is nil.
+	Router *Router
+	// OnCall is consulted at send time for the targets OnCallTarget stands for in Router's rules.
+	OnCall TargetSelector
+	// Queue, when set, holds alerts for sending at their severity's priority; they are sent immediately otherwise.
+	Queue       *Queue
+	Source      string
+	MinSeverity Severity // alerts below it are dropped
+}
+
+//NewAlerter returns an Alerter sending through c.
+func NewAlerter(c *Client) *Alerter {
+	return &Alerter{Client: c}
+}
+
+//Alert sends an alert, returning a NotifyErrors listing the deliveries which failed. Queued alerts are only
+//reported through the Queue's OnError.
+func (a *Alerter) Alert(severity Severity, title, body string) error {
+	return a.AlertContext(context.Background(), severity, title, body)
+}
+
+//AlertContext is Alert bound to a context which may cancel its requests
+func (a *Alerter) AlertContext(ctx context.Context, severity Severity, title, body string) error {
+	if severity < a.MinSeverity {
+		return nil
+	}
+	_, err := a.send(ctx, severity, severity.TitlePrefix()+title, body)
+	return err
+}
+
+//send routes the titled alert and sends or queues it, returning the idens of the pushes sent
+func (a *Alerter) send(ctx context.Context, severity Severity, title, body string) ([]string, error) {
+	pushes := a.route(severity, title, body)
+	if a.Queue != nil {
+		for _, p := range pushes {
+			a.Queue.Enqueue(p, severity.Priority())
+		}
+		return nil, nil
+	}
+	if a.Client == nil {
+		return nil, errors.New("Alerter has no client")
+	}
+	var idens []string
+	var errs NotifyErrors
+	for _, p := range pushes {
+		sent, err := a.Client.sendPush(ctx, p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(sent.ID) > 0 {
+			idens = append(idens, sent.ID)
+		}
+	}
+	if len(errs) > 0 {
+		return idens, errs
+	}
+	return idens, nil
+}
+
+//route returns the pushes the alert is sent as
+func (a *Alerter) route(severity Severity, title, body string) []PushMessage {
+	r := a.Router
+	if r == nil {
+		if a.OnCall == nil {
+			return []PushMessage{NotifyPush(title, body)}
+		}
+		r = &Router{Default: []Target{OnCallTarget()}}
+	}
+	if a.OnCall != nil {
+		r = withOnCall(r, a.OnCall.Select(time.Now()))
+	}
+	return r.Route(Event{Title: title, Body: body, Severity: severity.String(), Source: a.Source})
+}
+
+//withOnCall returns a copy of the router with OnCallTarget replaced by the on-call targets
+func withOnCall(r *Router, oncall []Target) *Router {
+	expand := func(targets []Target) []Target {
+		var l []Target
+		for _, t := range targets {
+			if t == OnCallTarget() {
+				l = append(l, oncall...)
+			} else {
+				l = append(l, t)
+			}
+		}
+		return l
+	}
+	c := *r
+	c.Default = expand(r.Default)
+	c.Rules = make([]Rule, len(r.Rules))
+	for i, rule := range r.Rules {
+		rule.Targets = expand(rule.Targets)
+		c.Rules[i] = rule
+	}
+	return &c
+}
+
+//Alert sends an alert formatted by severity to all of the user's devices. Use an Alerter for routing and quiet
+//hours.
+func (c *Client) Alert(severity Severity, title, body string) error {
+	return c.AlertContext(context.Background(), severity, title, body)
+}
+
+//AlertContext is Alert bound to a context which may cancel its requests
+func (c *Client) AlertContext(ctx context.Context, severity Severity, title, body string) error {
+	return NewAlerter(c).AlertContext(ctx, severity, title, body)
+}
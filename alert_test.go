@@ -0,0 +1,65 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAlertRoutesBySeverity(t *testing.T) {
+	var sent []PushMessage
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		sent = append(sent, p)
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	a := NewAlerter(c)
+	a.MinSeverity = SeverityInfo
+	a.Router = &Router{
+		Rules:   []Rule{{Severity: []string{"critical"}, Targets: []Target{DeviceTarget("phone"), ChannelTarget("oncall")}}},
+		Default: []Target{EmailTarget("ops@example.com")},
+	}
+	if err := a.Alert(SeverityDebug, "noise", ""); err != nil || len(sent) != 0 {
+		t.Fatal("Expected alerts below the minimum to be dropped:", err, sent)
+	}
+	if err := a.Alert(SeverityCritical, "db down", "connection refused"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Alert(SeverityWarn, "disk 80%", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 3 || sent[0].Title != "[CRIT] db down" || sent[0].DeviceID != "phone" || sent[1].ChannelTag != "oncall" {
+		t.Fatalf("Unexpected critical alert: %+v", sent)
+	}
+	if sent[2].Title != "[WARN] disk 80%" || sent[2].Email != "ops@example.com" {
+		t.Errorf("Unexpected warning: %+v", sent[2])
+	}
+
+	if err := c.Alert(SeverityInfo, "deployed", "v1.2"); err != nil || sent[3].Title != "[INFO] deployed" || len(sent[3].DeviceID) > 0 {
+		t.Errorf("Unexpected client alert: %+v, %v", sent[3], err)
+	}
+}
+
+func TestAlertQuietHoursBypass(t *testing.T) {
+	q := NewQueue(&Client{})
+	q.QuietHours = &QuietHours{Start: 0, End: 24 * time.Hour}
+	a := &Alerter{Queue: q}
+	a.Alert(SeverityInfo, "deployed", "")
+	a.Alert(SeverityWarn, "disk 80%", "")
+	a.Alert(SeverityCritical, "db down", "")
+
+	p, priority, ok := q.pop()
+	if !ok || p.Title != "[CRIT] db down" || priority != PriorityHigh {
+		t.Errorf("Expected the critical alert to bypass quiet hours: %+v", p)
+	}
+	if _, _, ok := q.pop(); ok {
+		t.Error("Expected the warning to be held during quiet hours")
+	}
+	if s := q.Stats(); s.Shed[PriorityLow] != 1 || s.Pending != 1 {
+		t.Errorf("Expected the info alert shed and the warning held: %+v", s)
+	}
+}
@@ -0,0 +1,98 @@
+package pushbullet
+
+import (
+	"context"
+	"net/url"
+)
+
+//ListTotals reports what a walk over every page of a list found.
+type ListTotals struct {
+	Pages   int // pages requested
+	Records int // records returned
+	Deleted int // tombstones left out
+}
+
+//AllDevices walks every page of the device list. Deleted devices are left out unless IncludeInactive is among the
+//options, and counted in the totals.
+func (c *Client) AllDevices(ctx context.Context, opts ...ListOption) ([]Device, ListTotals, error) {
+	var devices []Device
+	totals, err := c.walkPages(ctx, routeDevices, opts, func(p *syncPage, keepDeleted bool) (deleted int) {
+		for _, d := range p.Devices {
+			if !d.Active && !keepDeleted {
+				deleted++
+				continue
+			}
+			devices = append(devices, d)
+		}
+		return deleted
+	})
+	totals.Records = len(devices)
+	return devices, totals, err
+}
+
+//AllChats walks every page of the chat list. Deleted chats are left out unless IncludeInactive is among the options,
+//and counted in the totals.
+func (c *Client) AllChats(ctx context.Context, opts ...ListOption) ([]Chat, ListTotals, error) {
+	var chats []Chat
+	totals, err := c.walkPages(ctx, routeChats, opts, func(p *syncPage, keepDeleted bool) (deleted int) {
+		for _, chat := range p.Chats {
+			if !chat.Active && !keepDeleted {
+				deleted++
+				continue
+			}
+			chats = append(chats, chat)
+		}
+		return deleted
+	})
+	totals.Records = len(chats)
+	return chats, totals, err
+}
+
+//AllContacts walks every page of the deprecated contact list. Deleted contacts are left out unless IncludeInactive
+//is among the options, and counted in the totals.
+func (c *Client) AllContacts(ctx context.Context, opts ...ListOption) ([]Contact, ListTotals, error) {
+	if err := c.deprecatedContacts(); err != nil {
+		return nil, ListTotals{}, err
+	}
+	return c.allContacts(ctx, opts)
+}
+
+//allContacts is AllContacts without the deprecation check, for migrating off the contacts
+func (c *Client) allContacts(ctx context.Context, opts []ListOption) ([]Contact, ListTotals, error) {
+	var contacts []Contact
+	totals, err := c.walkPages(ctx, routeContacts, opts, func(p *syncPage, keepDeleted bool) (deleted int) {
+		for _, contact := range p.Contacts {
+			if !contact.Active && !keepDeleted {
+				deleted++
+				continue
+			}
+			contacts = append(contacts, contact)
+		}
+		return deleted
+	})
+	totals.Records = len(contacts)
+	return contacts, totals, err
+}
+
+//walkPages requests every page of a list, passing each to collect which returns the number of tombstones it left out
+func (c *Client) walkPages(ctx context.Context, route string, opts []ListOption, collect func(p *syncPage, keepDeleted bool) int) (ListTotals, error) {
+	var totals ListTotals
+	keepDeleted := len(listQuery(nil, opts).Get("active")) == 0
+	cursor := ""
+	for {
+		q := url.Values{}
+		if len(cursor) > 0 {
+			q.Set("cursor", cursor)
+		}
+		var p syncPage
+		if err := c.getContext(ctx, listCall(route, q, opts), &p); err != nil {
+			return totals, err
+		}
+		totals.Pages++
+		totals.Deleted += collect(&p, keepDeleted)
+		if len(p.Cursor) == 0 {
+			return totals, nil
+		}
+		cursor = p.Cursor
+	}
+}
@@ -0,0 +1,46 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAllDevicesChatsAndContacts(t *testing.T) {
+	var queries []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		key := r.URL.Path[1:]
+		record := map[string]string{"devices": `"nickname": "Phone"`, "chats": `"with": {"email": "ann@example.com"}`,
+			"contacts": `"email": "ann@example.com"`}[key]
+		if r.URL.Query().Get("cursor") == "" {
+			fmt.Fprintf(w, `{"%s": [{"iden": "a", "active": true, %s}, {"iden": "b", "active": false}], "cursor": "next"}`, key, record)
+			return
+		}
+		fmt.Fprintf(w, `{"%s": [{"iden": "c", "active": true, %s}]}`, key, record)
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	devices, totals, err := c.AllDevices(ctx)
+	if err != nil || len(devices) != 2 || devices[1].ID != "c" {
+		t.Fatal("Unexpected devices:", devices, err)
+	}
+	if totals != (ListTotals{Pages: 2, Records: 2, Deleted: 1}) {
+		t.Error("Unexpected totals:", totals)
+	}
+	if queries[1] != "active=true&cursor=next" {
+		t.Error("Options not applied to every page:", queries)
+	}
+
+	chats, totals, err := c.AllChats(ctx, IncludeInactive())
+	if err != nil || len(chats) != 3 || totals != (ListTotals{Pages: 2, Records: 3}) {
+		t.Error("Expected tombstones kept with IncludeInactive:", chats, totals, err)
+	}
+
+	contacts, totals, err := c.AllContacts(ctx)
+	if err != nil || len(contacts) != 2 || totals != (ListTotals{Pages: 2, Records: 2, Deleted: 1}) {
+		t.Error("Unexpected contacts:", contacts, totals, err)
+	}
+}
@@ -0,0 +1,94 @@
+package pushbullet
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+//Annotations are metadata about the application request a call is made for, such as its request ID or tenant,
+//carried in the call's context. They are never sent to Pushbullet; they tag the client's debug log, the events
+//passed to the OnRequest hook, and are readable by transports wrapping HTTPClient through AnnotationsFrom.
+type Annotations map[string]string
+
+//Well known annotation keys
+const (
+	AnnotationRequestID = "request_id"
+	AnnotationTenant    = "tenant"
+)
+
+type annotationsKey struct{}
+
+//Annotate returns a copy of ctx annotated with key set to value, keeping any annotations already present.
+func Annotate(ctx context.Context, key, value string) context.Context {
+	prev, _ := ctx.Value(annotationsKey{}).(Annotations)
+	a := make(Annotations, len(prev)+1)
+	for k, v := range prev {
+		a[k] = v
+	}
+	a[key] = value
+	return context.WithValue(ctx, annotationsKey{}, a)
+}
+
+//ContextWithRequestID annotates ctx with the ID of the application request it serves.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return Annotate(ctx, AnnotationRequestID, id)
+}
+
+//ContextWithTenant annotates ctx with the tenant it serves.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return Annotate(ctx, AnnotationTenant, tenant)
+}
+
+//AnnotationsFrom returns the annotations of ctx, or nil when there are none. The map must not be modified.
+func AnnotationsFrom(ctx context.Context) Annotations {
+	a, _ := ctx.Value(annotationsKey{}).(Annotations)
+	return a
+}
+
+//String renders the annotations as space separated key=value pairs, ordered by key.
+func (a Annotations) String() string {
+	pairs := make([]string, 0, len(a))
+	for k, v := range a {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
+
+//RequestEvent describes a completed API request, for audit logs and metrics.
+type RequestEvent struct {
+	Method      string
+	Endpoint    string // the method and first path segment, as in Stats
+	StatusCode  int    // zero when no response was received
+	Duration    time.Duration
+	Err         error
+	Annotations Annotations // from the request's context
+}
+
+//WithRequestHook passes an event for every API request made, including each retry, to hook. The hook is called
+//synchronously and must be safe for concurrent use.
+func WithRequestHook(hook func(RequestEvent)) Option {
+	return func(c *Client) {
+		c.OnRequest = hook
+	}
+}
+
+//observe reports a completed request to the OnRequest hook
+func (c *Client) observe(req *http.Request, call string, status int, d time.Duration, err error) {
+	if c.OnRequest == nil {
+		return
+	}
+	c.OnRequest(RequestEvent{Method: req.Method, Endpoint: endpointName(req.Method, call), StatusCode: status,
+		Duration: d, Err: c.redactError(err), Annotations: AnnotationsFrom(req.Context())})
+}
+
+//annotationSuffix renders the annotations of ctx for appending to a log line
+func annotationSuffix(ctx context.Context) string {
+	if a := AnnotationsFrom(ctx); len(a) > 0 {
+		return " [" + a.String() + "]"
+	}
+	return ""
+}
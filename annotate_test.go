@@ -0,0 +1,61 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	base := ContextWithRequestID(context.Background(), "req-1")
+	ctx := ContextWithTenant(base, "acme")
+	if got := AnnotationsFrom(ctx).String(); got != "request_id=req-1 tenant=acme" {
+		t.Errorf("annotations = %q", got)
+	}
+	if _, ok := AnnotationsFrom(base)[AnnotationTenant]; ok {
+		t.Error("Annotating a derived context changed its parent")
+	}
+	if AnnotationsFrom(context.Background()) != nil {
+		t.Error("Expected no annotations on a bare context")
+	}
+}
+
+func TestAnnotationsTagRequests(t *testing.T) {
+	var seen Annotations
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Request-Id") != "" || strings.Contains(r.URL.RawQuery, "acme") {
+			t.Error("Annotations leaked to the API:", r.Header, r.URL)
+		}
+		w.WriteHeader(404)
+	}))
+	defer server.Close()
+	var logged bytes.Buffer
+	var events []RequestEvent
+	c := ClientWithKey("apikey", WithAPI(server.URL, ""), WithLogLevel(LogDebug), WithLogger(log.New(&logged, "", 0)),
+		WithRequestHook(func(e RequestEvent) { events = append(events, e) }))
+	c.HTTPClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		seen = AnnotationsFrom(r.Context())
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	ctx := ContextWithTenant(ContextWithRequestID(context.Background(), "req-7"), "acme")
+	c.DeletePushContext(ctx, "p1")
+
+	if len(events) != 1 {
+		t.Fatalf("Expected one request event, got: %+v", events)
+	}
+	e := events[0]
+	if e.Endpoint != "DELETE pushes" || e.StatusCode != 404 || e.Err == nil || e.Annotations[AnnotationTenant] != "acme" {
+		t.Errorf("Unexpected event: %+v", e)
+	}
+	if seen[AnnotationRequestID] != "req-7" {
+		t.Error("Annotations not visible to the transport:", seen)
+	}
+	if n := strings.Count(logged.String(), "[request_id=req-7 tenant=acme]"); n != 2 {
+		t.Errorf("Expected the request and response dumps tagged, got:\n%s", logged.String())
+	}
+}
@@ -0,0 +1,153 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+//URLSender is a Notifier configured from a single URL by ParseURL, for tools which configure their notifiers as
+//URLs.
+type URLSender struct {
+	Client    *Client
+	Targets   []Target
+	Nicknames []string // devices resolved by nickname when sending
+	Priority  Priority
+	// Queue, when set, receives the notifications at Priority instead of them being sent directly.
+	Queue *Queue
+}
+
+//ParseURL configures a URLSender from an Apprise style URL. Both the Apprise Pushbullet form and a form naming the
+//kind of target are accepted:
+//
+//	pbul://TOKEN                        all devices
+//	pbul://TOKEN/DEVICE_IDEN            a device, by iden
+//	pbul://TOKEN/user@example.com       a user, by email
+//	pbul://TOKEN/#channel               the subscribers of a channel
+//	pball://TOKEN@device/Nickname       a device, by nickname
+//	pball://TOKEN@iden/DEVICE_IDEN      a device, by iden
+//	pball://TOKEN@email/user@example.com
+//	pball://TOKEN@channel/tag
+//	pball://TOKEN@client/CLIENT_IDEN
+//
+//Several targets may be given as further path elements, such as pbul://TOKEN/DEVICE_IDEN/#ops/#alerts/user@example.com.
+//The priority query parameter, low, normal or high, sets the priority used with a Queue. A channel tag which is
+//not valid is an error.
+func ParseURL(raw string, opts ...Option) (*URLSender, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	s := &URLSender{Priority: PriorityNormal}
+	var token, kind string
+	switch u.Scheme {
+	case "pbul":
+		token = u.Host
+	case "pball":
+		if u.User == nil {
+			token = u.Host
+		} else {
+			token, kind = u.User.Username(), strings.ToLower(u.Host)
+		}
+	default:
+		return nil, errors.New("Unsupported URL scheme " + u.Scheme)
+	}
+	if len(token) == 0 {
+		return nil, errors.New("Missing access token")
+	}
+
+	// a '#' starts the URL fragment, so the elements from the first /#channel on, and any query after them, arrive
+	// there
+	elements, query := u.Path, u.RawQuery
+	if len(u.Fragment) > 0 {
+		fragment := u.Fragment
+		if i := strings.Index(fragment, "?"); i >= 0 && len(query) == 0 {
+			fragment, query = fragment[:i], fragment[i+1:]
+		}
+		elements += "/#" + fragment
+	}
+	for _, element := range strings.Split(strings.Trim(elements, "/"), "/") {
+		if len(element) == 0 {
+			continue
+		}
+		switch kind {
+		case "device":
+			s.Nicknames = append(s.Nicknames, element)
+		case "iden":
+			s.Targets = append(s.Targets, DeviceTarget(element))
+		case "email":
+			s.Targets = append(s.Targets, EmailTarget(element))
+		case "channel":
+			if err := ValidateChannelTag(strings.TrimPrefix(element, "#")); err != nil {
+				return nil, err
+			}
+			s.Targets = append(s.Targets, ChannelTarget(strings.TrimPrefix(element, "#")))
+		case "client":
+			s.Targets = append(s.Targets, ClientTarget(element))
+		case "":
+			if strings.Contains(element, "@") {
+				s.Targets = append(s.Targets, EmailTarget(element))
+			} else if strings.HasPrefix(element, "#") {
+				if err := ValidateChannelTag(strings.TrimPrefix(element, "#")); err != nil {
+					return nil, err
+				}
+				s.Targets = append(s.Targets, ChannelTarget(strings.TrimPrefix(element, "#")))
+			} else {
+				s.Targets = append(s.Targets, DeviceTarget(element))
+			}
+		default:
+			return nil, errors.New("Unknown target kind " + kind)
+		}
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if p := values.Get("priority"); len(p) > 0 {
+		switch strings.ToLower(p) {
+		case "low":
+			s.Priority = PriorityLow
+		case "normal":
+			s.Priority = PriorityNormal
+		case "high":
+			s.Priority = PriorityHigh
+		default:
+			return nil, errors.New("Unknown priority " + p)
+		}
+	}
+	s.Client = ClientWithKey(token, opts...)
+	return s, nil
+}
+
+//Notify sends the notification to each target, or to all devices when there are none, returning a NotifyErrors
+//listing the deliveries which failed.
+func (s *URLSender) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	targets := append([]Target(nil), s.Targets...)
+	var errs NotifyErrors
+	for _, nickname := range s.Nicknames {
+		d, err := s.Client.GetDeviceByNicknameContext(ctx, nickname)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		targets = append(targets, DeviceTarget(d.ID))
+	}
+	if len(targets) == 0 && len(errs) == 0 {
+		targets = []Target{{}}
+	}
+	for _, t := range targets {
+		p := NotifyPush(title, body, append([]NotifyOption{t.NotifyOption()}, opts...)...)
+		if s.Queue != nil {
+			s.Queue.Enqueue(p, s.Priority)
+			continue
+		}
+		if _, err := s.Client.sendPush(ctx, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	for _, tc := range []struct {
+		url       string
+		targets   []Target
+		nicknames int
+		priority  Priority
+	}{
+		{"pbul://o.token", nil, 0, PriorityNormal},
+		{"pbul://o.token/udeviden/bob@example.com/#ops", []Target{DeviceTarget("udeviden"), EmailTarget("bob@example.com"), ChannelTarget("ops")}, 0, PriorityNormal},
+		{"pball://o.token@device/Phone/Laptop?priority=high", nil, 2, PriorityHigh},
+		{"pball://o.token@channel/ops?priority=low", []Target{ChannelTarget("ops")}, 0, PriorityLow},
+		{"pbul://o.token/#ops/#alerts/udeviden/bob@example.com?priority=high",
+			[]Target{ChannelTarget("ops"), ChannelTarget("alerts"), DeviceTarget("udeviden"), EmailTarget("bob@example.com")}, 0, PriorityHigh},
+		{"pbul://o.token/%23ops/udeviden#alerts", []Target{ChannelTarget("ops"), DeviceTarget("udeviden"), ChannelTarget("alerts")}, 0, PriorityNormal},
+		{"pball://o.token@channel/#ops/#alerts", []Target{ChannelTarget("ops"), ChannelTarget("alerts")}, 0, PriorityNormal},
+	} {
+		s, err := ParseURL(tc.url)
+		if err != nil {
+			t.Fatal(tc.url, err)
+		}
+		if s.Client.APIKey != "o.token" || len(s.Targets) != len(tc.targets) || len(s.Nicknames) != tc.nicknames || s.Priority != tc.priority {
+			t.Errorf("Unexpected sender for %s: %+v", tc.url, s)
+			continue
+		}
+		for i := range tc.targets {
+			if s.Targets[i] != tc.targets[i] {
+				t.Errorf("Unexpected target %d for %s: %+v", i, tc.url, s.Targets[i])
+			}
+		}
+	}
+	for _, bad := range []string{"http://o.token", "pbul://", "pball://o.token@planet/x", "pbul://o.token?priority=urgent",
+		"pbul://o.token/#ops/#", "pbul://o.token/#my channel", "pball://o.token@channel/o.ps"} {
+		if _, err := ParseURL(bad); err == nil {
+			t.Error("Expected an error for", bad)
+		}
+	}
+}
+
+func TestURLSenderNickname(t *testing.T) {
+	var sent []PushMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/devices" {
+			w.Write([]byte(`{"devices": [{"iden": "d1", "nickname": "Phone", "active": true}]}`))
+			return
+		}
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		sent = append(sent, p)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	s, _ := ParseURL("pball://o.token@device/phone")
+	s.Client.BaseURL = server.URL + "/"
+
+	if err := s.Notify(context.Background(), "Hi", "there"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].DeviceID != "d1" || sent[0].Title != "Hi" {
+		t.Errorf("Unexpected pushes: %+v", sent)
+	}
+}
@@ -0,0 +1,107 @@
+package pushbullet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand"
+	"net/http"
+	"time"
+)
+
+//Backoff decides whether and when to retry a request which failed with a network error, a 5xx status or a rate
+//limit. Interactive tools will want a couple of quick retries, batch jobs many patient ones.
+type Backoff interface {
+	//Delay returns the wait before the given retry, counted from 1, and false to give up. prev is the wait before
+	//the previous retry, zero for the first.
+	Delay(retry int, prev time.Duration) (time.Duration, bool)
+}
+
+//ConstantBackoff waits the same interval before each retry.
+type ConstantBackoff struct {
+	Interval time.Duration
+	Retries  int // retries after the first attempt
+}
+
+//Delay implements Backoff.
+func (b ConstantBackoff) Delay(retry int, prev time.Duration) (time.Duration, bool) {
+	return b.Interval, retry <= b.Retries
+}
+
+//ExponentialBackoff doubles the wait before each retry, starting from Base and capped at Max when Max is set.
+type ExponentialBackoff struct {
+	Base    time.Duration
+	Max     time.Duration
+	Retries int // retries after the first attempt
+}
+
+//Delay implements Backoff.
+func (b ExponentialBackoff) Delay(retry int, prev time.Duration) (time.Duration, bool) {
+	d := b.Base
+	for i := 1; i < retry && (b.Max <= 0 || d < b.Max); i++ {
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d, retry <= b.Retries
+}
+
+//DecorrelatedJitter waits a random interval between Base and three times the previous wait, capped at Max when Max
+//is set, spreading out the retries of many clients failing at once.
+type DecorrelatedJitter struct {
+	Base    time.Duration
+	Max     time.Duration
+	Retries int // retries after the first attempt
+}
+
+//Delay implements Backoff.
+func (b DecorrelatedJitter) Delay(retry int, prev time.Duration) (time.Duration, bool) {
+	if prev < b.Base {
+		prev = b.Base
+	}
+	d := b.Base + time.Duration(mrand.Int63n(int64(3*prev-b.Base)+1))
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d, retry <= b.Retries
+}
+
+//WithBackoff retries requests which failed temporarily, waiting as the policy decides. Only GET and DELETE
+//requests and pushes are retried; pushes are given a guid so the API can drop duplicates of a retried send.
+func WithBackoff(b Backoff) Option {
+	return func(c *Client) {
+		c.Backoff = b
+	}
+}
+
+//temporary reports whether a request which received the status might succeed when retried; status is zero when
+//no response was received
+func temporary(ctx context.Context, status int) bool {
+	return (status == 0 && ctx.Err() == nil) || status == http.StatusTooManyRequests || status >= 500
+}
+
+//hasGUID reports whether the request payload is a push which the API will deduplicate
+func hasGUID(data interface{}) bool {
+	r, ok := data.(PushRequest)
+	return ok && len(r.GUID) > 0
+}
+
+//newGUID returns a random identifier for deduplicating pushes
+func newGUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//sleepContext waits for the duration, returning the context's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,105 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoffPolicies(t *testing.T) {
+	exp := ExponentialBackoff{Base: time.Second, Max: 5 * time.Second, Retries: 4}
+	var got []time.Duration
+	for retry := 1; ; retry++ {
+		d, ok := exp.Delay(retry, 0)
+		if !ok {
+			break
+		}
+		got = append(got, d)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	if len(got) != len(want) {
+		t.Fatal("Unexpected exponential delays:", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Error("Unexpected exponential delays:", got)
+		}
+	}
+
+	if d, ok := (ConstantBackoff{Interval: time.Second, Retries: 1}).Delay(2, 0); ok || d != time.Second {
+		t.Error("Constant backoff did not stop after its retries")
+	}
+
+	jitter := DecorrelatedJitter{Base: 100 * time.Millisecond, Max: time.Second, Retries: 10}
+	prev := time.Duration(0)
+	for retry := 1; retry <= 10; retry++ {
+		d, _ := jitter.Delay(retry, prev)
+		if d < jitter.Base || d > jitter.Max || (prev > 0 && d > 3*prev) {
+			t.Errorf("Jitter delay %v out of range after %v", d, prev)
+		}
+		prev = d
+	}
+}
+
+func TestRetriesWithBackoff(t *testing.T) {
+	var guids []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]interface{}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &p)
+		guid, _ := p["guid"].(string)
+		guids = append(guids, guid)
+		if len(guids) < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+	c.Backoff = ConstantBackoff{Retries: 2}
+
+	if err := c.SendNote("Build", "retried"); err != nil {
+		t.Fatal(err)
+	}
+	if len(guids) != 3 || len(guids[0]) == 0 || guids[0] != guids[2] {
+		t.Error("Expected three attempts with the same guid:", guids)
+	}
+
+	guids = nil
+	c.Backoff = ConstantBackoff{Retries: 1}
+	if err := c.SendNote("Build", "gives up"); err == nil || len(guids) != 2 {
+		t.Error("Expected one retry before giving up:", err, len(guids))
+	}
+}
+
+func TestNoRetryForClientErrors(t *testing.T) {
+	requests := 0
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(400)
+	})
+	defer server.Close()
+	c.Backoff = ConstantBackoff{Retries: 3}
+
+	if _, err := c.GetDevices(); err == nil || requests != 1 {
+		t.Error("Expected a 400 not to be retried:", requests)
+	}
+	// a POST without a guid could be duplicated by a retry
+	requests = 0
+	c.HTTPClient = &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		requests++
+		return nil, context.DeadlineExceeded
+	})}
+	if err := c.CreateContact("Bob", "bob@example.com"); err == nil || requests != 1 {
+		t.Error("Expected the contact creation not to be retried:", requests)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
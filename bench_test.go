@@ -0,0 +1,101 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// cannedTransport answers every request in process, so benchmarks measure the client rather than the network
+type cannedTransport func(r *http.Request) []byte
+
+func (t cannedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Body != nil {
+		io.Copy(ioutil.Discard, r.Body)
+		r.Body.Close()
+	}
+	return &http.Response{StatusCode: 200, Status: "200 OK", Header: http.Header{},
+		Body: ioutil.NopCloser(bytes.NewReader(t(r))), Request: r}, nil
+}
+
+func cannedClient(t cannedTransport) *Client {
+	return &Client{APIKey: "apikey", BaseURL: "https://api.example.com/v2/", HTTPClient: &http.Client{Transport: t}}
+}
+
+// pagedHistory serves pages of 100 pushes, the last without a cursor
+func pagedHistory(pages int) cannedTransport {
+	page := historyPage(100)
+	last := append([]byte(nil), page...)
+	withCursor := append(page[:len(page)-1:len(page)-1], []byte(`,"cursor":"next"}`)...)
+	return func(r *http.Request) []byte {
+		n, _ := strconv.Atoi(r.URL.Query().Get("cursor")) // zero for the first page
+		if n+1 >= pages {
+			return last
+		}
+		return bytes.Replace(withCursor, []byte(`"next"`), []byte(`"`+strconv.Itoa(n+1)+`"`), 1)
+	}
+}
+
+var sentPush = []byte(`{"iden":"ujpah72o0sjAoRtnM0jc","type":"note","title":"Space Travel Ideas","body":"Space Elevator",` +
+	`"active":true,"created":1412047948.579029,"modified":1412047948.579031}`)
+
+func benchmarkSendPush(b *testing.B) {
+	c := cannedClient(func(*http.Request) []byte { return sentPush })
+	p := PushRequest{Type: "note", Title: "Space Travel Ideas", Body: "Space Elevator"}
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.SendPushContext(ctx, p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkIteratePushes(b *testing.B) {
+	c := cannedClient(pagedHistory(5))
+	for i := 0; i < b.N; i++ {
+		it := c.IteratePushes(context.Background(), 0)
+		n := 0
+		for it.Next() {
+			n++
+		}
+		it.Close()
+		if it.Err() != nil || n != 500 {
+			b.Fatal(n, it.Err())
+		}
+	}
+}
+
+func benchmarkUpload(b *testing.B) {
+	c := cannedClient(func(*http.Request) []byte { return nil })
+	auth := Authorization{UploadURL: "https://upload.example.com/", FileName: "report.bin"}
+	auth.Data.Key, auth.Data.Signature, auth.Data.Policy = "key", "signature", "policy"
+	data := make([]byte, 1<<20)
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		if err := c.uploadFile(context.Background(), auth, "report.bin", bytes.NewReader(data), defaultMaxUploadSize); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSendPush measures encoding a push, making the call and decoding the created push.
+func BenchmarkSendPush(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkSendPush(b)
+}
+
+// BenchmarkIteratePushes measures walking five 100 push pages of history.
+func BenchmarkIteratePushes(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkIteratePushes(b)
+}
+
+// BenchmarkUpload measures sending a 1MB file to the upload URL.
+func BenchmarkUpload(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkUpload(b)
+}
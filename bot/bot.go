@@ -0,0 +1,122 @@
+//Package bot turns pushes into a remote control channel: pushes whose text starts with a registered command run
+//its handler, and the result is pushed back to the sender.
+//
+//	b := bot.New(client, "me@example.com")
+//	b.Handle("status", "report service status", func(ctx context.Context, args []string, p pushbullet.PushMessage) (string, error) {
+//		return "all good", nil
+//	})
+//	s := pushbullet.NewSync(client, pushbullet.SinkHandler(ctx, nil, b))
+//	s.Run(ctx, time.Minute)
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//replyPrefix marks the guids of replies so the bot does not treat its own pushes as commands
+const replyPrefix = "bot-reply:"
+
+//Handler runs a command with the words following its name, returning the text of the reply.
+type Handler func(ctx context.Context, args []string, p pushbullet.PushMessage) (string, error)
+
+type command struct {
+	help    string
+	handler Handler
+}
+
+//Bot matches received pushes against its commands. It implements pushbullet.PushSink, so it is fed by a Sync
+//through pushbullet.SinkHandler.
+type Bot struct {
+	Client *pushbullet.Client
+	// Allowed lists the sender emails which may run commands. Pushes from anyone else, and every push when
+	// Allowed is empty, are ignored.
+	Allowed []string
+	// Prefix, when set, must precede the command name, e.g. "/" for "/status".
+	Prefix string
+
+	commands map[string]command
+}
+
+//New returns a Bot replying through c and accepting commands from the allowed sender emails. A "help" command
+//listing the registered commands is built in.
+func New(c *pushbullet.Client, allowed ...string) *Bot {
+	b := &Bot{Client: c, Allowed: allowed, commands: make(map[string]command)}
+	b.Handle("help", "list the commands", b.help)
+	return b
+}
+
+//Handle registers a command, replacing any command of the same name. Names are matched case insensitively.
+func (b *Bot) Handle(name, help string, h Handler) {
+	b.commands[strings.ToLower(name)] = command{help: help, handler: h}
+}
+
+//HandlePush runs the command in the push, if it holds one from an allowed sender, and pushes the result back to
+//the device the command was sent from. Unauthorized and unrecognized pushes are ignored.
+func (b *Bot) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if p.Direction == "outgoing" || strings.HasPrefix(p.GUID, replyPrefix) || !b.allowed(p) {
+		return nil
+	}
+	text := strings.TrimSpace(p.Body)
+	if len(text) == 0 {
+		text = strings.TrimSpace(p.Title)
+	}
+	if len(b.Prefix) > 0 {
+		if !strings.HasPrefix(text, b.Prefix) {
+			return nil
+		}
+		text = strings.TrimPrefix(text, b.Prefix)
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil
+	}
+	name := strings.ToLower(words[0])
+	cmd, ok := b.commands[name]
+	if !ok {
+		return nil
+	}
+
+	title := name + ": ok"
+	reply, err := cmd.handler(ctx, words[1:], p)
+	if err != nil {
+		title, reply = name+": failed", err.Error()
+	}
+	opts := []pushbullet.NotifyOption{func(r *pushbullet.PushMessage) {
+		r.GUID = replyPrefix + p.ID
+	}}
+	// another user's device cannot be pushed to directly, so their replies go to their email
+	switch {
+	case p.Direction == "self" && len(p.SourceDeviceID) > 0:
+		opts = append(opts, pushbullet.ToDevice(p.SourceDeviceID))
+	case p.Direction != "self" && len(p.SenderEmail) > 0:
+		opts = append(opts, pushbullet.ToEmail(p.SenderEmail))
+	}
+	return b.Client.Notify(ctx, title, reply, opts...)
+}
+
+func (b *Bot) allowed(p pushbullet.PushMessage) bool {
+	for _, email := range b.Allowed {
+		if strings.EqualFold(email, p.SenderEmail) || strings.EqualFold(email, p.SenderEmailNormalized) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bot) help(ctx context.Context, args []string, p pushbullet.PushMessage) (string, error) {
+	names := make([]string, 0, len(b.commands))
+	for name := range b.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s%s - %s", b.Prefix, name, b.commands[name].help)
+	}
+	return strings.Join(lines, "\n"), nil
+}
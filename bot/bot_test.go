@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func TestBotCommands(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+	b := New(c, "me@example.com")
+	var restarted []string
+	b.Handle("restart", "restart a service", func(ctx context.Context, args []string, p pushbullet.PushMessage) (string, error) {
+		if len(args) == 0 {
+			return "", errors.New("Which service?")
+		}
+		restarted = append(restarted, args[0])
+		return "restarted " + args[0], nil
+	})
+	ctx := context.Background()
+
+	b.HandlePush(ctx, pushbullet.PushMessage{ID: "p1", Body: "Restart nginx", SenderEmail: "Me@example.com", SourceDeviceID: "phone", Direction: "self"})
+	b.HandlePush(ctx, pushbullet.PushMessage{ID: "p2", Body: "restart", SenderEmail: "me@example.com", Direction: "self"})
+	sent := server.Pushes()
+	if len(restarted) != 1 || restarted[0] != "nginx" || len(sent) != 2 {
+		t.Fatalf("Unexpected handling: %v, %d replies", restarted, len(sent))
+	}
+	first, second := sent[0], sent[1]
+	if first.Title != "restart: ok" || first.Body != "restarted nginx" || first.DeviceID != "phone" || first.GUID != "bot-reply:p1" {
+		t.Errorf("Unexpected reply: %+v", first)
+	}
+	if second.Title != "restart: failed" || second.Body != "Which service?" {
+		t.Errorf("Unexpected failure reply: %+v", second)
+	}
+
+	// replies, strangers and unknown commands are ignored
+	b.HandlePush(ctx, first)
+	b.HandlePush(ctx, pushbullet.PushMessage{Body: "restart db", SenderEmail: "eve@example.com", Direction: "incoming"})
+	b.HandlePush(ctx, pushbullet.PushMessage{Body: "shutdown", SenderEmail: "me@example.com"})
+	if sent := server.Pushes(); len(sent) != 2 || len(restarted) != 1 {
+		t.Error("Bot acted on a push it should have ignored:", sent)
+	}
+}
+
+func TestBotRepliesToOtherUserByEmail(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+	b := New(c, "me@example.com", "ops@example.com")
+	b.Handle("ping", "check the bot is alive", func(ctx context.Context, args []string, p pushbullet.PushMessage) (string, error) {
+		return "pong", nil
+	})
+
+	b.HandlePush(context.Background(), pushbullet.PushMessage{ID: "p1", Body: "ping", SenderEmail: "ops@example.com",
+		SourceDeviceID: "their-phone", Direction: "incoming"})
+	sent := server.Pushes()
+	if len(sent) != 1 {
+		t.Fatalf("Expected a reply, got %d", len(sent))
+	}
+	if r := sent[0]; r.Email != "ops@example.com" || len(r.DeviceID) > 0 {
+		t.Errorf("Expected the reply addressed to the sender's email, got: %+v", r)
+	}
+}
+
+func TestBotHelpAndPrefix(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+	b := New(c, "me@example.com")
+	b.Prefix = "/"
+	b.Handle("status", "report status", func(context.Context, []string, pushbullet.PushMessage) (string, error) { return "up", nil })
+
+	b.HandlePush(context.Background(), pushbullet.PushMessage{Body: "help", SenderEmail: "me@example.com"})
+	b.HandlePush(context.Background(), pushbullet.PushMessage{Title: "/help", SenderEmail: "me@example.com"})
+	if sent := server.Pushes(); len(sent) != 1 || !strings.Contains(sent[0].Body, "/status - report status") {
+		t.Errorf("Unexpected help: %+v", sent)
+	}
+}
+
+func TestBotWithoutAllowedSenders(t *testing.T) {
+	b := New(&pushbullet.Client{})
+	if err := b.HandlePush(context.Background(), pushbullet.PushMessage{Body: "help", SenderEmail: "me@example.com"}); err != nil {
+		t.Error(err)
+	}
+}
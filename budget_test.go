@@ -0,0 +1,31 @@
+//go:build budget
+
+package pushbullet
+
+import "testing"
+
+// allocationBudgets are the allocation ceilings per operation of the hot paths. They hold with headroom on current
+// toolchains; a change crossing one has made its path markedly more expensive. Lower a budget when pooling or
+// streaming work brings a path well under it.
+var allocationBudgets = map[string]struct {
+	bench  func(*testing.B)
+	allocs int64
+}{
+	"SendPush":      {benchmarkSendPush, 130},
+	"IteratePushes": {benchmarkIteratePushes, 55000},
+	"Upload":        {benchmarkUpload, 140},
+}
+
+// TestAllocationBudgets runs the benchmarks, so it is only built with the budget tag:
+//
+//	go test -tags budget -run AllocationBudgets
+func TestAllocationBudgets(t *testing.T) {
+	for name, budget := range allocationBudgets {
+		r := testing.Benchmark(budget.bench)
+		if allocs := r.AllocsPerOp(); allocs > budget.allocs {
+			t.Errorf("%s: %d allocations per operation, over the budget of %d", name, allocs, budget.allocs)
+		} else {
+			t.Logf("%s: %d allocations per operation, budget %d", name, allocs, budget.allocs)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+//Capabilities reports what a token can do, for setup wizards to guide users before the first real use.
+type Capabilities struct {
+	TokenValid bool
+	User       User // the account, when the token is valid
+	// CanSend is inferred from a valid, active account: sending cannot be probed without creating a push.
+	CanSend        bool
+	CanReadHistory bool
+	Devices        []Device // active devices
+	Pushable       []Device // devices which can receive pushes
+	SMS            []Device // devices able to send text messages
+	// E2E lists the devices with end-to-end encryption configured. Encrypted pushes and ephemerals to and from
+	// them are opaque to clients without the account's encryption password.
+	E2E []Device
+	// Problems describes, for users, anything which will stop or limit use of the token.
+	Problems []string
+}
+
+//probeDenied reports whether a probe failed because the token lacks access, rather than because the probe could
+//not be made
+func probeDenied(err error) bool {
+	var status *StatusError
+	switch KindOf(err) {
+	case KindBadToken, KindUpgradeRequired:
+		return true
+	}
+	return errors.As(err, &status) && status.StatusCode == 403
+}
+
+//Capabilities probes what the token can do with read-only requests. A token which is refused is reported, not
+//returned as an error; errors are returned when a probe could not be made at all, such as network failures.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	var caps Capabilities
+	if err := c.getContext(ctx, routeUser, &caps.User); err != nil {
+		if !probeDenied(err) {
+			return caps, err
+		}
+		caps.Problems = append(caps.Problems, c.Describe(err))
+		return caps, nil
+	}
+	caps.TokenValid = true
+	caps.CanSend = caps.User.Active
+	if !caps.User.Active {
+		caps.Problems = append(caps.Problems, "The Pushbullet account is not active.")
+	}
+
+	var history PushList
+	switch err := c.getContext(ctx, query(routePushes, url.Values{"limit": {"1"}}), &history); {
+	case err == nil:
+		caps.CanReadHistory = true
+	case probeDenied(err):
+		caps.Problems = append(caps.Problems, "The token cannot read the push history.")
+	default:
+		return caps, err
+	}
+
+	devices, _, err := c.AllDevices(ctx)
+	if err != nil && !probeDenied(err) {
+		return caps, err
+	}
+	caps.Devices = devices
+	for _, d := range devices {
+		if d.Pushable {
+			caps.Pushable = append(caps.Pushable, d)
+		}
+		if d.HasSMS {
+			caps.SMS = append(caps.SMS, d)
+		}
+		if len(d.KeyFingerprint) > 0 {
+			caps.E2E = append(caps.E2E, d)
+		}
+	}
+	switch {
+	case err != nil:
+		caps.Problems = append(caps.Problems, "The token cannot list devices.")
+	case len(caps.Pushable) == 0:
+		caps.Problems = append(caps.Problems, "No devices can receive pushes yet. Install Pushbullet on a phone or computer.")
+	}
+	return caps, nil
+}
@@ -0,0 +1,61 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	historyStatus := 200
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _, _ := r.BasicAuth(); user != "apikey" {
+			w.WriteHeader(401)
+			fmt.Fprint(w, `{"error": {"type": "invalid_request", "message": "Access token is missing or invalid."}}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/users/me":
+			fmt.Fprint(w, `{"iden": "u1", "email": "elon@teslamotors.com", "active": true}`)
+		case "/pushes":
+			if r.URL.Query().Get("limit") != "1" {
+				t.Error("Expected a single push to be requested:", r.URL)
+			}
+			w.WriteHeader(historyStatus)
+			fmt.Fprint(w, `{"pushes": []}`)
+		case "/devices":
+			fmt.Fprint(w, `{"devices": [
+				{"iden": "d1", "nickname": "Phone", "active": true, "pushable": true, "has_sms": true, "key_fingerprint": "5ae6ec7e"},
+				{"iden": "d2", "nickname": "Laptop", "active": true, "pushable": true}]}`)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	caps, err := ClientWithKey("apikey", WithAPI(server.URL, "")).Capabilities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !caps.TokenValid || !caps.CanSend || !caps.CanReadHistory || caps.User.Email != "elon@teslamotors.com" ||
+		len(caps.Devices) != 2 || len(caps.Pushable) != 2 || len(caps.SMS) != 1 || len(caps.E2E) != 1 || len(caps.Problems) != 0 {
+		t.Errorf("Unexpected capabilities: %+v", caps)
+	}
+
+	historyStatus = 403
+	caps, err = ClientWithKey("apikey", WithAPI(server.URL, "")).Capabilities(ctx)
+	if err != nil || caps.CanReadHistory || len(caps.Problems) != 1 {
+		t.Errorf("Expected the refused history to be reported: %+v, %v", caps, err)
+	}
+
+	caps, err = ClientWithKey("revoked", WithAPI(server.URL, "")).Capabilities(ctx)
+	if err != nil || caps.TokenValid || caps.CanSend || len(caps.Problems) != 1 || caps.Problems[0] != ErrorCatalog[KindBadToken].Message {
+		t.Errorf("Expected the invalid token to be reported: %+v, %v", caps, err)
+	}
+
+	server.Close()
+	if _, err = ClientWithKey("apikey", WithAPI(server.URL, "")).Capabilities(ctx); err == nil {
+		t.Error("Expected an error when the API cannot be reached")
+	}
+}
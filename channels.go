@@ -0,0 +1,43 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+//ValidateChannelTag checks the syntax of a channel tag, returning a *ValidationError for an empty tag or one with
+//characters other than ASCII letters, digits, '-' and '_'. Pushbullet documents no length limit, so none is checked.
+func ValidateChannelTag(tag string) error {
+	invalid := func(message string) error {
+		return &ValidationError{Field: "channel_tag", Message: message}
+	}
+	if len(tag) == 0 {
+		return invalid("empty tag")
+	}
+	for _, r := range tag {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return invalid("tag may only contain letters, digits, '-' and '_': " + tag)
+		}
+	}
+	return nil
+}
+
+//IsChannelTagAvailable reports whether no channel uses the tag yet, so that it can be claimed for a new channel. The
+//tag is validated first, and is available when its channel info is not found.
+func (c *Client) IsChannelTagAvailable(tag string) (bool, error) {
+	return c.IsChannelTagAvailableContext(context.Background(), tag)
+}
+
+//IsChannelTagAvailableContext is IsChannelTagAvailable bound to a context which may cancel its requests
+func (c *Client) IsChannelTagAvailableContext(ctx context.Context, tag string) (bool, error) {
+	_, err := c.ChannelInfoContext(ctx, tag)
+	var status *StatusError
+	switch {
+	case err == nil:
+		return false, nil
+	case errors.As(err, &status) && status.StatusCode == http.StatusNotFound:
+		return true, nil
+	}
+	return false, err
+}
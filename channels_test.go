@@ -0,0 +1,76 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateChannelTag(t *testing.T) {
+	for tag, valid := range map[string]bool{
+		"deploys":               true,
+		"build_status-2":        true,
+		"":                      false,
+		"my channel":            false,
+		"café":                  false,
+		strings.Repeat("x", 64): true,
+		"tag?with=query":        false,
+	} {
+		if err := ValidateChannelTag(tag); (err == nil) != valid {
+			t.Errorf("ValidateChannelTag(%q) = %v", tag, err)
+		}
+	}
+}
+
+func TestIsChannelTagAvailable(t *testing.T) {
+	requests := 0
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("tag") {
+		case "taken":
+			fmt.Fprintln(w, `{"iden": "c1", "tag": "taken", "name": "Taken"}`)
+		case "free":
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "Object not found"}}`)
+		default:
+			w.WriteHeader(500)
+		}
+	})
+	defer server.Close()
+
+	if ok, err := c.IsChannelTagAvailable("taken"); ok || err != nil {
+		t.Error("Expected a used tag to be unavailable:", ok, err)
+	}
+	if ok, err := c.IsChannelTagAvailable("free"); !ok || err != nil {
+		t.Error("Expected an unknown tag to be available:", ok, err)
+	}
+	if _, err := c.IsChannelTagAvailable("down"); err == nil {
+		t.Error("Expected a server error to be returned")
+	}
+	if _, err := c.IsChannelTagAvailable("not valid"); err == nil || requests != 3 {
+		t.Error("Expected an invalid tag to fail without a request:", err, requests)
+	}
+}
+
+func TestSubscribeChannelSendsTag(t *testing.T) {
+	var bodies []map[string]string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		fmt.Fprintln(w, `{"iden": "s1", "active": true}`)
+	})
+	defer server.Close()
+
+	if err := c.SubscribeChannel("deploys"); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 || bodies[0]["channel_tag"] != "deploys" {
+		t.Errorf("Unexpected request bodies: %v", bodies)
+	}
+	if err := c.SubscribeChannel("not valid"); err == nil || len(bodies) != 1 {
+		t.Error("Expected an invalid tag to fail without a request:", err, len(bodies))
+	}
+}
@@ -0,0 +1,121 @@
+//Package checkpoint persists the position reached in each Pushbullet resource (the newest modified timestamp
+//seen and any page cursor) so consumers such as pushbullet.Sync resume where they left off after a restart.
+package checkpoint
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//Checkpoint is the position reached in a resource.
+type Checkpoint struct {
+	Modified float64 `json:"modified"`
+	Cursor   string  `json:"cursor,omitempty"`
+}
+
+//Store saves and loads checkpoints by key, typically the resource name.
+type Store interface {
+	//Load returns the checkpoint for key, reporting false when none has been saved.
+	Load(key string) (Checkpoint, bool, error)
+	Save(key string, cp Checkpoint) error
+}
+
+//Memory is a Store held in memory, useful for tests and short-lived processes.
+type Memory struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+//NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{checkpoints: make(map[string]Checkpoint)}
+}
+
+//Load returns the checkpoint saved for key.
+func (m *Memory) Load(key string) (Checkpoint, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp, ok := m.checkpoints[key]
+	return cp, ok, nil
+}
+
+//Save records the checkpoint for key.
+func (m *Memory) Save(key string, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[key] = cp
+	return nil
+}
+
+//File is a Store persisted as a JSON document. Every save rewrites the file atomically, so a crash never
+//leaves a partially written checkpoint behind.
+type File struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+//NewFile returns a store persisted at path. The file is created on the first save.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+//Load returns the checkpoint saved for key.
+func (f *File) Load(key string) (Checkpoint, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.read()
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+	cp, ok := all[key]
+	return cp, ok, nil
+}
+
+//Save records the checkpoint for key.
+func (f *File) Save(key string, cp Checkpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+	all[key] = cp
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+func (f *File) read() (map[string]Checkpoint, error) {
+	all := make(map[string]Checkpoint)
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
@@ -0,0 +1,48 @@
+package checkpoint
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testStore(t *testing.T, s Store) {
+	if _, ok, err := s.Load("pushes"); ok || err != nil {
+		t.Fatal("Expected no checkpoint:", ok, err)
+	}
+	if err := s.Save("pushes", Checkpoint{Modified: 1412047948.579031, Cursor: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save("devices", Checkpoint{Modified: 1412047900}); err != nil {
+		t.Fatal(err)
+	}
+	cp, ok, err := s.Load("pushes")
+	if !ok || err != nil || cp.Modified != 1412047948.579031 || cp.Cursor != "abc" {
+		t.Error("Unexpected checkpoint:", cp, ok, err)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, NewMemory())
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoints.json")
+	testStore(t, NewFile(path))
+
+	// a new store over the same file sees the saved checkpoints
+	cp, ok, err := NewFile(path).Load("devices")
+	if !ok || err != nil || cp.Modified != 1412047900 {
+		t.Error("Checkpoint not persisted:", cp, ok, err)
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Error("Temporary files left behind:", len(files))
+	}
+}
@@ -0,0 +1,105 @@
+//Package ci formats build results into consistent pushes, so every pipeline reports "build failed" the same way.
+//
+//Titles take the form "<emoji> <project> <status> on <branch>", bodies list the commit and duration followed by
+//an optional message, and both are truncated to sizes which display well in device notifications. Results with
+//a LogURL become link pushes pointing at the log.
+package ci
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/internal/text"
+)
+
+//Status is the outcome of a build.
+type Status string
+
+//Build outcomes
+const (
+	Started  Status = "started"
+	Passed   Status = "passed"
+	Fixed    Status = "fixed"
+	Failed   Status = "failed"
+	Errored  Status = "errored"
+	Canceled Status = "canceled"
+)
+
+//Limits applied when formatting, in characters
+const (
+	MaxTitle = 80
+	MaxBody  = 1000
+)
+
+var emoji = map[Status]string{
+	Started:  "\U0001F504",   // counterclockwise arrows
+	Passed:   "\u2705",       // white heavy check mark
+	Fixed:    "\U0001F389",   // party popper
+	Failed:   "\u274C",       // cross mark
+	Errored:  "\u26A0\uFE0F", // warning sign
+	Canceled: "\U0001F6AB",   // no entry sign
+}
+
+//Result describes a finished (or starting) build.
+type Result struct {
+	Project  string
+	Status   Status
+	Branch   string
+	Commit   string
+	Duration time.Duration
+	LogURL   string
+	Message  string // e.g. the failing step or commit message
+}
+
+//Emoji returns the status prefix used in titles.
+func (s Status) Emoji() string {
+	if e, ok := emoji[s]; ok {
+		return e
+	}
+	return "\u2754" // white question mark ornament
+}
+
+//Format composes the push for a result.
+func Format(r Result) pushbullet.PushMessage {
+	title := r.Status.Emoji() + " " + r.Project + " " + string(r.Status)
+	if len(r.Branch) > 0 {
+		title += " on " + r.Branch
+	}
+
+	var details []string
+	if len(r.Commit) > 0 {
+		commit := r.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		details = append(details, "Commit "+commit)
+	}
+	if r.Duration > 0 {
+		details = append(details, r.Duration.Round(time.Second).String())
+	}
+	body := strings.Join(details, " · ")
+	if msg := strings.TrimSpace(r.Message); len(msg) > 0 {
+		if len(body) > 0 {
+			body += "\n"
+		}
+		body += msg
+	}
+
+	p := pushbullet.PushMessage{Type: "note", Title: text.Truncate(title, MaxTitle), Body: text.Truncate(body, MaxBody)}
+	if len(r.LogURL) > 0 {
+		p.Type = "link"
+		p.URL = r.LogURL
+	}
+	return p
+}
+
+//Send formats the result and delivers it through n.
+func Send(ctx context.Context, n pushbullet.Notifier, r Result, opts ...pushbullet.NotifyOption) error {
+	p := Format(r)
+	if p.Type == "link" {
+		opts = append([]pushbullet.NotifyOption{pushbullet.LinkURL(p.URL)}, opts...)
+	}
+	return n.Notify(ctx, p.Title, p.Body, opts...)
+}
@@ -0,0 +1,52 @@
+package ci
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestFormat(t *testing.T) {
+	p := Format(Result{
+		Project:  "gopushbullet",
+		Status:   Failed,
+		Branch:   "master",
+		Commit:   "928a1f25cd2cc5a3c0bf846983a029676fa81a04",
+		Duration: 192*time.Second + 400*time.Millisecond,
+		Message:  "go test ./... exited 1",
+	})
+	if p.Type != "note" || p.Title != "❌ gopushbullet failed on master" {
+		t.Error("Unexpected title:", p.Title)
+	}
+	if p.Body != "Commit 928a1f2 · 3m12s\ngo test ./... exited 1" {
+		t.Error("Unexpected body:", p.Body)
+	}
+}
+
+func TestFormatLinkAndTruncation(t *testing.T) {
+	p := Format(Result{Project: strings.Repeat("x", 200), Status: Passed, LogURL: "https://ci.example.com/1", Message: strings.Repeat("log ", 500)})
+	if p.Type != "link" || p.URL != "https://ci.example.com/1" {
+		t.Error("Expected link push to log:", p)
+	}
+	if utf8.RuneCountInString(p.Title) != MaxTitle || !strings.HasSuffix(p.Title, "…") {
+		t.Error("Title not truncated:", p.Title)
+	}
+	if utf8.RuneCountInString(p.Body) > MaxBody {
+		t.Error("Body not truncated")
+	}
+}
+
+func TestSend(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	err := Send(context.Background(), rec, Result{Project: "api", Status: Fixed, LogURL: "https://ci.example.com/2"}, pushbullet.ToChannel("builds"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := rec.Pushes()[0]; p.Type != "link" || p.ChannelTag != "builds" || p.Title != "\U0001F389 api fixed" {
+		t.Errorf("Unexpected push: %+v", p)
+	}
+}
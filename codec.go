@@ -0,0 +1,55 @@
+package pushbullet
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+//Codec encodes request payloads and decodes response bodies. Implementations wrapping faster JSON libraries
+//(jsoniter, segmentio/encoding) can be installed with WithCodec for high-throughput deployments; they must honor
+//the standard library's struct tags.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+//StrictCodec is implemented by codecs able to reject unknown fields. When strict decoding is enabled and the
+//configured codec does not implement it, the standard library is used for decoding instead.
+type StrictCodec interface {
+	Codec
+	UnmarshalStrict(data []byte, v interface{}) error
+}
+
+//StdCodec is the encoding/json codec used by default.
+type StdCodec struct{}
+
+//Marshal encodes v with encoding/json.
+func (StdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+//Unmarshal decodes data with encoding/json.
+func (StdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+//UnmarshalStrict decodes data with encoding/json, rejecting unknown fields.
+func (StdCodec) UnmarshalStrict(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(v)
+}
+
+//WithCodec replaces the JSON codec used for requests and responses.
+func WithCodec(codec Codec) Option {
+	return func(c *Client) {
+		c.Codec = codec
+	}
+}
+
+func (c *Client) codec() Codec {
+	if c.Codec == nil {
+		return StdCodec{}
+	}
+	return c.Codec
+}
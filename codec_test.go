@@ -0,0 +1,74 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+type countingCodec struct {
+	StdCodec
+	marshals, unmarshals int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return c.StdCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return c.StdCodec.Unmarshal(data, v)
+}
+
+func TestCustomCodec(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"iden": "p1", "type": "note"}`)
+	defer mockServer.Close()
+	codec := &countingCodec{}
+	WithCodec(codec)(c)
+
+	created, err := c.SendPush(PushRequest{Type: "note", Title: "Build Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "p1" || codec.marshals != 1 || codec.unmarshals != 1 {
+		t.Error("Codec not used for request and response:", codec.marshals, codec.unmarshals)
+	}
+}
+
+// historyPage builds a push history response of n pushes
+func historyPage(n int) []byte {
+	var l PushList
+	for i := 0; i < n; i++ {
+		l.Pushes = append(l.Pushes, PushMessage{
+			ID: fmt.Sprintf("ujpah72o0sjAoRtnM%04d", i), Type: "note", Active: true,
+			Title: "Space Travel Ideas", Body: "Space Elevator, Mars Hyperloop, Space Model S (Model Space?)",
+			Created: 1412047948.579029, Modified: 1412047948.579031,
+			SenderEmail: "elon@teslamotors.com", ReceiverEmail: "elon@teslamotors.com",
+		})
+	}
+	b, _ := json.Marshal(l)
+	return b
+}
+
+// BenchmarkDecodeHistory compares codecs decoding a full 500 push history page.
+// Add third-party codecs to the table to measure them against the standard library.
+func BenchmarkDecodeHistory(b *testing.B) {
+	codecs := map[string]Codec{
+		"std": StdCodec{},
+	}
+	page := historyPage(500)
+	for name, codec := range codecs {
+		b.Run(name, func(b *testing.B) {
+			c := &Client{Codec: codec}
+			b.SetBytes(int64(len(page)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var l PushList
+				if err := c.decode(page, &l); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
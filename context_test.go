@@ -0,0 +1,51 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContextVariantsCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	c := mockClient(server.URL)
+
+	calls := map[string]func(ctx context.Context) error{
+		"SendNoteContext": func(ctx context.Context) error { return c.SendNoteContext(ctx, "title", "body") },
+		"GetDevicesContext": func(ctx context.Context) error {
+			_, err := c.GetDevicesContext(ctx)
+			return err
+		},
+		"GetPushHistoryContext": func(ctx context.Context) error {
+			_, err := c.GetPushHistoryContext(ctx, 0)
+			return err
+		},
+		"ResolveTargetContext": func(ctx context.Context) error {
+			_, err := c.ResolveTargetContext(ctx, "phone")
+			return err
+		},
+	}
+	for name, call := range calls {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		start := time.Now()
+		err := call(ctx)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("%s: err = %v, want the deadline exceeded", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s took %v to give up", name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package pushbullet
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+//correlationPrefix marks guids carrying a correlation ID, in the form corr:<id>:<unique suffix>
+const correlationPrefix = "corr:"
+
+//Correlate tags the push with a correlation ID, carried in its guid, so related pushes such as an incident's
+//alert, updates and resolution can be found and grouped later.
+func Correlate(id string) NotifyOption {
+	return func(p *PushMessage) {
+		p.GUID = correlationPrefix + id + ":" + newGUID()
+	}
+}
+
+//CorrelationID returns the correlation ID the push was tagged with by Correlate, or an empty string.
+func (p PushMessage) CorrelationID() string {
+	if !strings.HasPrefix(p.GUID, correlationPrefix) {
+		return ""
+	}
+	id := strings.TrimPrefix(p.GUID, correlationPrefix)
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+//GroupByCorrelation groups the correlated pushes by correlation ID, each chain oldest first. Uncorrelated pushes
+//are left out.
+func GroupByCorrelation(pushes []PushMessage) map[string][]PushMessage {
+	chains := make(map[string][]PushMessage)
+	for _, p := range pushes {
+		if id := p.CorrelationID(); len(id) > 0 {
+			chains[id] = append(chains[id], p)
+		}
+	}
+	for _, chain := range chains {
+		sort.SliceStable(chain, func(i, j int) bool { return chain[i].Created < chain[j].Created })
+	}
+	return chains
+}
+
+//CorrelatedPushes returns the pushes in the correlation chain modified after the provided timestamp, oldest first.
+func (c *Client) CorrelatedPushes(ctx context.Context, id string, modifiedAfter float64) ([]PushMessage, error) {
+	it := c.IteratePushes(ctx, modifiedAfter)
+	defer it.Close()
+	var pushes []PushMessage
+	for it.Next() {
+		if it.Push().CorrelationID() == id {
+			pushes = append(pushes, it.Push())
+		}
+	}
+	return GroupByCorrelation(pushes)[id], it.Err()
+}
+
+//Correlated returns the synced pushes in the correlation chain, oldest first.
+func (s *Sync) Correlated(id string) []PushMessage {
+	return GroupByCorrelation(s.Pushes())[id]
+}
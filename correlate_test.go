@@ -0,0 +1,39 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCorrelate(t *testing.T) {
+	alert := NotifyPush("DB down", "", Correlate("incident-7"))
+	resolve := NotifyPush("DB up", "", Correlate("incident-7"))
+	if alert.CorrelationID() != "incident-7" || alert.GUID == resolve.GUID {
+		t.Errorf("Unexpected correlation guids: %q %q", alert.GUID, resolve.GUID)
+	}
+	if (PushMessage{GUID: "plain"}).CorrelationID() != "" {
+		t.Error("Uncorrelated guid parsed as a correlation")
+	}
+}
+
+func TestCorrelatedPushes(t *testing.T) {
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"pushes": [
+			{"iden": "p3", "guid": "corr:incident-7:c", "created": 3},
+			{"iden": "p2", "guid": "corr:incident-8:b", "created": 2},
+			{"iden": "p1", "guid": "corr:incident-7:a", "created": 1},
+			{"iden": "p0", "created": 0}
+		]}`)
+	})
+	defer server.Close()
+
+	chain, err := c.CorrelatedPushes(context.Background(), "incident-7", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 2 || chain[0].ID != "p1" || chain[1].ID != "p3" {
+		t.Errorf("Unexpected chain: %+v", chain)
+	}
+}
@@ -0,0 +1,153 @@
+//Package crashreport pushes a report when a program panics, so a crash on an unattended machine reaches a phone
+//instead of a log nobody reads.
+//
+//	func main() {
+//		defer crashreport.Recover(client, pushbullet.DeviceTarget(phone))
+//		...
+//	}
+//
+//On a panic Recover sends a note with the panic value and the head of the stack, and uploads the full dump of
+//every goroutine as a file push. The dump names the program but leaves out its arguments, which often hold
+//secrets, unless IncludeArgs is given. It then re-panics with the original value, or exits when asked to with Exit.
+//Recover only sees panics on the goroutine it was deferred on.
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/internal/text"
+)
+
+//MaxNoteStack limits the stack included in the note, in characters
+const MaxNoteStack = 1500
+
+//Option configures Recover.
+type Option func(*options)
+
+type options struct {
+	exit     bool
+	exitCode int
+	timeout  time.Duration
+	title    string
+	args     bool
+}
+
+//Exit makes Recover exit the process with code after reporting instead of re-panicking.
+func Exit(code int) Option {
+	return func(o *options) {
+		o.exit, o.exitCode = true, code
+	}
+}
+
+//Timeout bounds the time spent sending the report, one minute by default.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+//Title sets the title of the note, "<program> crashed on <host>" by default.
+func Title(title string) Option {
+	return func(o *options) {
+		o.title = title
+	}
+}
+
+//IncludeArgs adds the program's command line to the dump, with the client's API key redacted. Any other secrets
+//passed as arguments are uploaded with it.
+func IncludeArgs() Option {
+	return func(o *options) {
+		o.args = true
+	}
+}
+
+//exit is replaced in tests
+var exit = os.Exit
+
+//Recover reports a panic in progress to target and then re-panics or exits. It must be deferred directly:
+//
+//	defer crashreport.Recover(client, target)
+//
+//When there is no panic it does nothing. Failures to send the report are written to standard error.
+func Recover(client *pushbullet.Client, target pushbullet.Target, opts ...Option) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	o := options{timeout: time.Minute}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := report(client, target, v, debug.Stack(), o); err != nil {
+		fmt.Fprintf(os.Stderr, "crashreport: sending the crash report failed: %v\n", err)
+	}
+	if o.exit {
+		exit(o.exitCode)
+		return
+	}
+	panic(v)
+}
+
+//report sends the note and the dump, returning the first error after attempting both
+func report(client *pushbullet.Client, target pushbullet.Target, v interface{}, stack []byte, o options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	title := o.title
+	if len(title) == 0 {
+		host, _ := os.Hostname()
+		title = filepath.Base(os.Args[0]) + " crashed on " + host
+	}
+	body := fmt.Sprintf("panic: %v\n\n%s", v, text.Truncate(string(stack), MaxNoteStack))
+	noteErr := client.Notify(ctx, title, body, target.NotifyOption())
+
+	name := fmt.Sprintf("crash-%s.txt", time.Now().UTC().Format("20060102-150405"))
+	auth, err := client.Upload(ctx, bytes.NewReader(dump(client, v, stack, o)), name, "text/plain")
+	if err == nil {
+		p := pushbullet.PushMessage{Type: "file", FileName: auth.FileName, FileType: auth.FileType, FileURL: auth.FileURL,
+			Body: fmt.Sprintf("panic: %v", v)}
+		target.NotifyOption()(&p)
+		_, err = client.SendPushContext(ctx, p.Request())
+	}
+	if noteErr != nil {
+		return noteErr
+	}
+	return err
+}
+
+//dump renders the panic value, the panicking goroutine's stack and the stacks of all goroutines
+func dump(client *pushbullet.Client, v interface{}, stack []byte, o options) []byte {
+	var b bytes.Buffer
+	host, _ := os.Hostname()
+	program := filepath.Base(os.Args[0])
+	if o.args {
+		program = client.Redact(strings.Join(os.Args, " "))
+	}
+	fmt.Fprintf(&b, "panic: %v\n\nprogram: %s\nhost: %s\ntime: %s\ngo: %s %s/%s\n\n", v, program, host,
+		time.Now().UTC().Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	b.Write(stack)
+	b.WriteString("\nAll goroutines:\n\n")
+	b.Write(allStacks())
+	return b.Bytes()
+}
+
+//allStacks returns the stacks of every goroutine, growing the buffer until they fit
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= 64<<20 {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,91 @@
+package crashreport
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func crash(c *pushbullet.Client, opts ...Option) {
+	defer Recover(c, pushbullet.DeviceTarget("phone"), opts...)
+	panic("disk on fire")
+}
+
+func TestRecoverRepanics(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+
+	func() {
+		defer func() {
+			if v := recover(); v != "disk on fire" {
+				t.Errorf("re-panicked with %v", v)
+			}
+		}()
+		crash(server.Client(), Title("worker crashed"))
+	}()
+
+	pushes := server.Pushes()
+	if len(pushes) != 2 {
+		t.Fatalf("sent %d pushes, want a note and a file", len(pushes))
+	}
+	note, file := pushes[0], pushes[1]
+	if note.Type != "note" || note.Title != "worker crashed" || note.DeviceID != "phone" {
+		t.Errorf("note = %+v", note)
+	}
+	if !strings.HasPrefix(note.Body, "panic: disk on fire\n") || !strings.Contains(note.Body, "crashreport.crash") {
+		t.Errorf("note body lacks the panic or its stack:\n%s", note.Body)
+	}
+	if file.Type != "file" || file.FileURL != server.URL+"/files/"+file.FileName || file.DeviceID != "phone" {
+		t.Errorf("file push = %+v", file)
+	}
+	dump, _ := server.File(file.FileName)
+	if !strings.Contains(string(dump), "All goroutines:") || !strings.Contains(string(dump), "crashreport.TestRecoverRepanics") {
+		t.Errorf("dump lacks the goroutine stacks:\n%s", dump)
+	}
+}
+
+func TestRecoverExit(t *testing.T) {
+	defer func(f func(int)) { exit = f }(exit)
+	code := -1
+	exit = func(c int) { code = c }
+	server := pushbullettest.NewServer()
+	defer server.Close()
+
+	crash(server.Client(), Exit(3))
+	if code != 3 {
+		t.Errorf("exit code = %d, want 3", code)
+	}
+	if n := len(server.Pushes()); n != 2 {
+		t.Errorf("sent %d pushes, want 2", n)
+	}
+}
+
+func TestDumpArgs(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"/usr/bin/worker", "-token", "apikey", "-password", "hunter2"}
+	c := &pushbullet.Client{APIKey: "apikey"}
+
+	d := string(dump(c, "boom", nil, options{}))
+	if !strings.Contains(d, "program: worker\n") || strings.Contains(d, "hunter2") {
+		t.Errorf("Arguments included by default:\n%s", d)
+	}
+	d = string(dump(c, "boom", nil, options{args: true}))
+	if !strings.Contains(d, "program: /usr/bin/worker -token [REDACTED] -password hunter2\n") {
+		t.Errorf("Unexpected command line:\n%s", d)
+	}
+}
+
+func TestRecoverWithoutPanic(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+
+	func() {
+		defer Recover(server.Client(), pushbullet.DeviceTarget("phone"))
+	}()
+	if n := len(server.Pushes()); n != 0 {
+		t.Errorf("sent %d pushes without a panic", n)
+	}
+}
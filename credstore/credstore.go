@@ -0,0 +1,76 @@
+//Package credstore keeps Pushbullet API keys in the operating system's credential store (macOS Keychain,
+//Windows Credential Manager, or a Secret Service provider such as GNOME Keyring on Linux) so that
+//command line and desktop applications never need to write the key to disk in plaintext.
+//
+//Every backend implements pushbullet.TokenSource and may be handed directly to pushbullet.ClientWithTokenSource.
+package credstore
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//ErrNotFound is returned when no key has been stored for the requested service and account.
+var ErrNotFound = errors.New("credstore: key not found")
+
+//ErrUnsupported is returned by backends which are not available on the current platform.
+var ErrUnsupported = errors.New("credstore: backend not supported on this platform")
+
+//Backend is a credential store capable of holding a Pushbullet API key.
+type Backend interface {
+	pushbullet.TokenSource
+	SetToken(token string) error
+	DeleteToken() error
+}
+
+//Default returns the preferred backend for the current platform.
+func Default(service, account string) Backend {
+	return defaultBackend(service, account)
+}
+
+//commandError reports a helper which exited unsuccessfully, keeping its diagnostic output
+type commandError struct {
+	name   string
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	return "credstore: " + e.name + ": " + e.stderr
+}
+
+//stderrOf returns the diagnostic output of a failed helper
+func stderrOf(err error) string {
+	if e, ok := err.(*commandError); ok {
+		return e.stderr
+	}
+	return ""
+}
+
+//redactToken removes the key from the diagnostic output of a failed helper, which may echo its input
+func redactToken(err error, token string) error {
+	if e, ok := err.(*commandError); ok && len(token) > 0 && strings.Contains(e.stderr, token) {
+		return &commandError{name: e.name, stderr: strings.ReplaceAll(e.stderr, token, "[REDACTED]")}
+	}
+	return err
+}
+
+//runCommand executes an external helper, feeding it stdin and returning its trimmed stdout
+var runCommand = func(stdin string, name string, args ...string) (string, int, error) {
+	var out, errOut bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return "", exitErr.ExitCode(), &commandError{name: name, stderr: strings.TrimSpace(errOut.String())}
+	}
+	if err != nil {
+		return "", -1, err
+	}
+	return strings.TrimRight(out.String(), "\r\n"), 0, nil
+}
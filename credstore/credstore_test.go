@@ -0,0 +1,109 @@
+package credstore
+
+import (
+	"strings"
+	"testing"
+)
+
+// stubRunner replaces runCommand for the duration of the test
+func stubRunner(t *testing.T, fn func(string, string, ...string) (string, int, error)) {
+	orig := runCommand
+	t.Cleanup(func() { runCommand = orig })
+	runCommand = fn
+}
+
+type call struct {
+	stdin string
+	args  []string
+}
+
+func fakeRunner(out string, code int, err error, calls *[]call) func(string, string, ...string) (string, int, error) {
+	return func(stdin string, name string, args ...string) (string, int, error) {
+		*calls = append(*calls, call{stdin, append([]string{name}, args...)})
+		return out, code, err
+	}
+}
+
+func TestSecretServiceToken(t *testing.T) {
+	var calls []call
+	stubRunner(t, fakeRunner("o.abc123", 0, nil, &calls))
+	k, err := SecretService{Service: "pushbullet", Account: "me"}.Token()
+	if err != nil || k != "o.abc123" {
+		t.Error("Unexpected token result:", k, err)
+	}
+	if strings.Join(calls[0].args, " ") != "secret-tool lookup service pushbullet account me" {
+		t.Error("Unexpected command:", calls[0].args)
+	}
+
+	stubRunner(t, fakeRunner("", 1, nil, &calls))
+	if _, err = (SecretService{Service: "pushbullet", Account: "me"}).Token(); err != ErrNotFound {
+		t.Error("Expected ErrNotFound, got:", err)
+	}
+
+	// a keyring failure also exits 1 but explains itself on stderr
+	stubRunner(t, fakeRunner("", 1, &commandError{name: "secret-tool", stderr: "Cannot autolaunch D-Bus"}, &calls))
+	if _, err = (SecretService{Service: "pushbullet", Account: "me"}).Token(); err == nil || err == ErrNotFound {
+		t.Error("Expected keyring failure, got:", err)
+	}
+}
+
+func TestSecretServiceSetTokenUsesStdin(t *testing.T) {
+	var calls []call
+	stubRunner(t, fakeRunner("", 0, nil, &calls))
+	if err := (SecretService{Service: "pushbullet", Account: "me"}).SetToken("o.secret"); err != nil {
+		t.Fatal(err)
+	}
+	if calls[0].stdin != "o.secret" {
+		t.Error("Token was not passed over stdin")
+	}
+	for _, a := range calls[0].args {
+		if strings.Contains(a, "o.secret") {
+			t.Error("Token leaked into command arguments:", calls[0].args)
+		}
+	}
+}
+
+func TestKeychainSetTokenUsesStdin(t *testing.T) {
+	var calls []call
+	stubRunner(t, fakeRunner("", 0, nil, &calls))
+	if err := (Keychain{Service: "pushbullet", Account: "me"}).SetToken("o.secret"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(calls[0].args, " ") != "security -i" || !strings.Contains(calls[0].stdin, `-w "o.secret"`) {
+		t.Error("Unexpected keychain invocation:", calls[0])
+	}
+
+	stubRunner(t, fakeRunner("", keychainNotFound, nil, &calls))
+	if _, err := (Keychain{Service: "pushbullet", Account: "me"}).Token(); err != ErrNotFound {
+		t.Error("Expected ErrNotFound, got:", err)
+	}
+}
+
+func TestKeychainQuote(t *testing.T) {
+	for arg, want := range map[string]string{
+		"o.secret":      `"o.secret"`,
+		`my "work" key`: `"my \"work\" key"`,
+		`back\slash`:    `"back\\slash"`,
+		"café\tbar":     "\"café\tbar\"",
+	} {
+		if got, err := keychainQuote(arg); err != nil || got != want {
+			t.Errorf("Quoted %q as %s, %v, want %s", arg, got, err, want)
+		}
+	}
+	var calls []call
+	stubRunner(t, fakeRunner("", 0, nil, &calls))
+	if err := (Keychain{Service: "pushbullet", Account: "me"}).SetToken("o.secret\n-w other"); err == nil || len(calls) > 0 {
+		t.Error("Expected a key with a line break refused:", err, calls)
+	}
+}
+
+func TestSetTokenErrorsRedactKey(t *testing.T) {
+	var calls []call
+	stubRunner(t, fakeRunner("", 1, &commandError{name: "security", stderr: `add-generic-password -w "o.secret": failed`}, &calls))
+	for _, b := range []Backend{Keychain{Service: "pushbullet", Account: "me"}, SecretService{Service: "pushbullet", Account: "me"}} {
+		err := b.SetToken("o.secret")
+		if err == nil || strings.Contains(err.Error(), "o.secret") {
+			t.Errorf("Key not redacted from %T error: %v", b, err)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+package credstore
+
+func defaultBackend(service, account string) Backend {
+	return Keychain{Service: service, Account: account}
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !windows
+//+build !darwin,!windows
+
+package credstore
+
+func defaultBackend(service, account string) Backend {
+	return SecretService{Service: service, Account: account}
+}
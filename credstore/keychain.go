@@ -0,0 +1,57 @@
+package credstore
+
+import (
+	"errors"
+	"strings"
+)
+
+// exit status used by security(1) when an item could not be found
+const keychainNotFound = 44
+
+//Keychain stores the key as a generic password in the macOS login keychain using security(1).
+type Keychain struct {
+	Service string
+	Account string
+}
+
+//Token reads the key from the keychain.
+func (k Keychain) Token() (string, error) {
+	out, code, err := runCommand("", "security", "find-generic-password", "-s", k.Service, "-a", k.Account, "-w")
+	if code == keychainNotFound {
+		return "", ErrNotFound
+	}
+	return out, err
+}
+
+//SetToken adds or replaces the key in the keychain. The key is passed over stdin so it never appears in the process list.
+func (k Keychain) SetToken(token string) error {
+	var args []string
+	for _, arg := range []string{k.Service, k.Account, token} {
+		quoted, err := keychainQuote(arg)
+		if err != nil {
+			return err
+		}
+		args = append(args, quoted)
+	}
+	cmd := "add-generic-password -U -s " + args[0] + " -a " + args[1] + " -w " + args[2] + "\n"
+	_, _, err := runCommand(cmd, "security", "-i")
+	return redactToken(err, token)
+}
+
+//keychainQuote quotes an argument for a line read by security -i, which splits on unquoted whitespace and takes a
+//backslash to escape the character after it. A line break would end the command, so it cannot be passed.
+func keychainQuote(arg string) (string, error) {
+	if strings.ContainsAny(arg, "\r\n\x00") {
+		return "", errors.New("credstore: security -i cannot take a line break or NUL in an argument")
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`, nil
+}
+
+//DeleteToken removes the key from the keychain.
+func (k Keychain) DeleteToken() error {
+	_, code, err := runCommand("", "security", "delete-generic-password", "-s", k.Service, "-a", k.Account)
+	if code == keychainNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package credstore
+
+//SecretService stores the key with a freedesktop.org Secret Service provider (GNOME Keyring, KWallet) using secret-tool(1).
+type SecretService struct {
+	Service string
+	Account string
+	Label   string // shown by keyring managers; defaults to "Pushbullet API key"
+}
+
+//Token looks the key up by its service and account attributes. secret-tool exits with status 1 both for a missing
+//key and for D-Bus or keyring failures; only a silent failure is reported as ErrNotFound.
+func (s SecretService) Token() (string, error) {
+	out, code, err := runCommand("", "secret-tool", "lookup", "service", s.Service, "account", s.Account)
+	if (code == 1 && len(out) == 0 && len(stderrOf(err)) == 0) || (err == nil && len(out) == 0) {
+		return "", ErrNotFound
+	}
+	return out, err
+}
+
+//SetToken stores the key, replacing any existing value. The key is passed over stdin.
+func (s SecretService) SetToken(token string) error {
+	label := s.Label
+	if len(label) == 0 {
+		label = "Pushbullet API key"
+	}
+	_, _, err := runCommand(token, "secret-tool", "store", "--label="+label, "service", s.Service, "account", s.Account)
+	return redactToken(err, token)
+}
+
+//DeleteToken removes the key from the keyring.
+func (s SecretService) DeleteToken() error {
+	_, _, err := runCommand("", "secret-tool", "clear", "service", s.Service, "account", s.Account)
+	return err
+}
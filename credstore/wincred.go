@@ -0,0 +1,22 @@
+package credstore
+
+//CredentialManager stores the key as a generic credential in the Windows Credential Manager.
+type CredentialManager struct {
+	Target   string // credential name, e.g. "pushbullet:default"
+	UserName string
+}
+
+//Token reads the key from the Credential Manager.
+func (m CredentialManager) Token() (string, error) {
+	return credRead(m.Target)
+}
+
+//SetToken writes the key to the Credential Manager, persisted for the local machine.
+func (m CredentialManager) SetToken(token string) error {
+	return credWrite(m.Target, m.UserName, token)
+}
+
+//DeleteToken removes the key from the Credential Manager.
+func (m CredentialManager) DeleteToken() error {
+	return credDelete(m.Target)
+}
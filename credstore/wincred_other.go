@@ -0,0 +1,16 @@
+//go:build !windows
+//+build !windows
+
+package credstore
+
+func credRead(target string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func credWrite(target, user, token string) error {
+	return ErrUnsupported
+}
+
+func credDelete(target string) error {
+	return ErrUnsupported
+}
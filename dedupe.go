@@ -0,0 +1,170 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//AlertGroup is the state of a firing alert group.
+type AlertGroup struct {
+	Key       string
+	Severity  Severity
+	Title     string
+	Body      string
+	Count     int // times the alert fired
+	FirstSeen time.Time
+	LastSeen  time.Time
+	PushIDs   []string // the pushes sent when the group started firing
+}
+
+//GroupKey builds a group key from the named labels, in the manner of Alertmanager's group_by. All labels are used
+//when none are named.
+func GroupKey(labels map[string]string, by ...string) string {
+	if len(by) == 0 {
+		for k := range labels {
+			by = append(by, k)
+		}
+	}
+	sort.Strings(by)
+	parts := make([]string, len(by))
+	for i, k := range by {
+		parts[i] = k + "=" + labels[k]
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+//Deduplicator sends one push per alert group: repeated alerts with the same group key update the group instead of
+//sending new pushes, and a resolved push is sent when the condition clears.
+//
+//	d := pushbullet.NewDeduplicator(alerter)
+//	d.Fire(pushbullet.GroupKey(labels, "service"), pushbullet.SeverityError, "db down", err.Error())
+//	...
+//	d.Resolve(pushbullet.GroupKey(labels, "service"))
+type Deduplicator struct {
+	Alerter *Alerter
+	// DeleteOnResolve deletes the group's original pushes once the resolved push is sent. Pushes sent through the
+	// Alerter's Queue are not known and so not deleted.
+	DeleteOnResolve bool
+
+	mu     sync.Mutex
+	groups map[string]*AlertGroup
+	now    func() time.Time
+}
+
+//NewDeduplicator returns a Deduplicator sending through a.
+func NewDeduplicator(a *Alerter) *Deduplicator {
+	return &Deduplicator{Alerter: a, groups: make(map[string]*AlertGroup)}
+}
+
+//Fire raises an alert for the group key. Only the first alert of a group is sent; later ones update its state.
+//Alerts below the Alerter's MinSeverity are dropped. When sending the first alert fails the group is forgotten, so
+//the next Fire for the key sends it again.
+func (d *Deduplicator) Fire(key string, severity Severity, title, body string) error {
+	return d.FireContext(context.Background(), key, severity, title, body)
+}
+
+//FireContext is Fire bound to a context which may cancel its requests
+func (d *Deduplicator) FireContext(ctx context.Context, key string, severity Severity, title, body string) error {
+	if len(key) == 0 {
+		return errors.New("Alert has no group key")
+	}
+	if severity < d.Alerter.MinSeverity {
+		return nil
+	}
+	d.mu.Lock()
+	now := d.clock()
+	if g, ok := d.groups[key]; ok {
+		g.Count++
+		g.LastSeen = now
+		if severity > g.Severity {
+			g.Severity = severity
+		}
+		g.Body = body
+		d.mu.Unlock()
+		return nil
+	}
+	g := &AlertGroup{Key: key, Severity: severity, Title: title, Body: body, Count: 1, FirstSeen: now, LastSeen: now}
+	d.groups[key] = g
+	d.mu.Unlock()
+
+	idens, err := d.Alerter.send(ctx, severity, severity.TitlePrefix()+title, body)
+	d.mu.Lock()
+	g.PushIDs = idens
+	if err != nil && d.groups[key] == g {
+		delete(d.groups, key)
+	}
+	d.mu.Unlock()
+	return err
+}
+
+//Resolve sends a resolved push for the group key, to the targets of its alert, and forgets the group. Keys which
+//are not firing are ignored. A group whose resolved push fails keeps firing, so the resolve can be retried.
+func (d *Deduplicator) Resolve(key string) error {
+	return d.ResolveContext(context.Background(), key)
+}
+
+//ResolveContext is Resolve bound to a context which may cancel its requests
+func (d *Deduplicator) ResolveContext(ctx context.Context, key string) error {
+	d.mu.Lock()
+	g, ok := d.groups[key]
+	delete(d.groups, key)
+	now := d.clock()
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	body := fmt.Sprintf("Fired %d times from %s to %s", g.Count,
+		g.FirstSeen.Format("2006-01-02 15:04:05"), g.LastSeen.Format("2006-01-02 15:04:05"))
+	if g.Count == 1 {
+		body = fmt.Sprintf("Firing from %s to %s", g.FirstSeen.Format("2006-01-02 15:04:05"), now.Format("2006-01-02 15:04:05"))
+	}
+	if _, err := d.Alerter.send(ctx, g.Severity, "[RESOLVED] "+g.Title, body); err != nil {
+		// the group was removed up front so that concurrent resolves send once; unless it fired anew, restore it
+		d.mu.Lock()
+		if _, ok := d.groups[key]; !ok {
+			d.groups[key] = g
+		}
+		d.mu.Unlock()
+		return err
+	}
+	if !d.DeleteOnResolve {
+		return nil
+	}
+	var errs NotifyErrors
+	for _, id := range g.PushIDs {
+		if err := d.Alerter.Client.DeletePushContext(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//Groups returns the firing alert groups, ordered by key.
+func (d *Deduplicator) Groups() []AlertGroup {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	groups := make([]AlertGroup, 0, len(d.groups))
+	for _, g := range d.groups {
+		c := *g
+		c.PushIDs = append([]string(nil), g.PushIDs...)
+		groups = append(groups, c)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Key < groups[j].Key })
+	return groups
+}
+
+func (d *Deduplicator) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,124 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroupKey(t *testing.T) {
+	labels := map[string]string{"service": "db", "host": "db1", "alertname": "Down"}
+	if k := GroupKey(labels, "service", "alertname"); k != "{alertname=Down,service=db}" {
+		t.Error("Unexpected key:", k)
+	}
+	if k := GroupKey(labels); k != "{alertname=Down,host=db1,service=db}" {
+		t.Error("Unexpected key:", k)
+	}
+}
+
+func TestDeduplicator(t *testing.T) {
+	var mu sync.Mutex
+	var sent []PushMessage
+	var deleted []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "DELETE" {
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/pushes/"))
+			w.Write([]byte("{}"))
+			return
+		}
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		p.ID = "push" + string(rune('0'+len(sent)))
+		sent = append(sent, p)
+		json.NewEncoder(w).Encode(p)
+	})
+	defer server.Close()
+
+	d := NewDeduplicator(NewAlerter(c))
+	d.DeleteOnResolve = true
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d.now = func() time.Time {
+		at = at.Add(time.Minute)
+		return at
+	}
+	key := GroupKey(map[string]string{"service": "db"})
+	for i := 0; i < 3; i++ {
+		if err := d.Fire(key, SeverityError, "db down", "connection refused"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.Fire(key, SeverityCritical, "db down", "still refused")
+	if len(sent) != 1 || sent[0].Title != "[ERROR] db down" {
+		t.Fatalf("Expected a single push for the group: %+v", sent)
+	}
+	g := d.Groups()
+	if len(g) != 1 || g[0].Count != 4 || g[0].Severity != SeverityCritical || g[0].Body != "still refused" || g[0].PushIDs[0] != "push0" {
+		t.Fatalf("Unexpected group state: %+v", g)
+	}
+
+	if err := d.Resolve(key); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[1].Title != "[RESOLVED] db down" || sent[1].Body != "Fired 4 times from 2024-05-01 12:01:00 to 2024-05-01 12:04:00" {
+		t.Errorf("Unexpected resolved push: %+v", sent[1:])
+	}
+	if len(deleted) != 1 || deleted[0] != "push0" {
+		t.Error("Expected the original push to be deleted:", deleted)
+	}
+	if len(d.Groups()) != 0 || d.Resolve(key) != nil || len(sent) != 2 {
+		t.Error("Expected the group to be forgotten once resolved")
+	}
+}
+
+func TestDeduplicatorRetriesFailedFire(t *testing.T) {
+	status := 500
+	var titles []string
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	d := NewDeduplicator(NewAlerter(c))
+
+	if err := d.Fire("db", SeverityError, "db down", "refused"); err == nil {
+		t.Fatal("Expected the failed send to be reported")
+	}
+	if len(d.Groups()) != 0 {
+		t.Error("Undelivered alert kept as a firing group:", d.Groups())
+	}
+	status = 200
+	if err := d.Fire("db", SeverityError, "db down", "refused"); err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 1 || titles[0] != "[ERROR] db down" {
+		t.Error("Expected the alert sent on the next Fire:", titles)
+	}
+}
+
+func TestDeduplicatorRetriesFailedResolve(t *testing.T) {
+	status := 200
+	var titles []string
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	d := NewDeduplicator(NewAlerter(c))
+
+	if err := d.Fire("db", SeverityError, "db down", "refused"); err != nil {
+		t.Fatal(err)
+	}
+	status = 500
+	if err := d.Resolve("db"); err == nil {
+		t.Fatal("Expected the failed resolve to be reported")
+	}
+	if groups := d.Groups(); len(groups) != 1 || groups[0].Key != "db" {
+		t.Error("Expected the group kept firing after a failed resolve:", groups)
+	}
+	status = 200
+	if err := d.Resolve("db"); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Groups()) != 0 || len(titles) != 2 || titles[1] != "[RESOLVED] db down" {
+		t.Error("Expected the resolve retried:", titles, d.Groups())
+	}
+}
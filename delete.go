@@ -0,0 +1,99 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//PushFilter selects pushes for DeletePushes. Each set condition must hold; the zero PushFilter matches every push.
+type PushFilter struct {
+	OlderThan time.Time // created before this time
+	ChannelID string    // sent to the channel with this iden
+	Type      string    // of this push type
+	Match     func(PushMessage) bool
+}
+
+//Matches reports whether the push meets all of the filter's conditions.
+func (f PushFilter) Matches(p PushMessage) bool {
+	if !f.OlderThan.IsZero() && p.Created >= timestamp(f.OlderThan) {
+		return false
+	}
+	if len(f.ChannelID) > 0 && p.ChannelID != f.ChannelID {
+		return false
+	}
+	if len(f.Type) > 0 && p.Type != f.Type {
+		return false
+	}
+	return f.Match == nil || f.Match(p)
+}
+
+//DeleteOption configures DeletePushes.
+type DeleteOption func(*deleteConfig)
+
+type deleteConfig struct {
+	interval time.Duration
+	progress func(deleted, matched int)
+}
+
+//DeleteInterval spaces out the deletions, 100ms apart by default.
+func DeleteInterval(d time.Duration) DeleteOption {
+	return func(c *deleteConfig) { c.interval = d }
+}
+
+//DeleteProgress is called after each deletion with the number deleted so far and the number to delete.
+func DeleteProgress(f func(deleted, matched int)) DeleteOption {
+	return func(c *deleteConfig) { c.progress = f }
+}
+
+//DeletePushes deletes the active pushes matching the filter, returning how many were deleted. The history is read
+//in full before deleting, so deletions do not disturb paging. Deletions are throttled, and a rate limit pauses
+//them until the budget resets rather than failing.
+func (c *Client) DeletePushes(ctx context.Context, filter PushFilter, opts ...DeleteOption) (int, error) {
+	cfg := deleteConfig{interval: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var matched []string
+	it := c.IteratePushes(ctx, 0)
+	defer it.Close()
+	for it.Next() {
+		if filter.Matches(it.Push()) {
+			matched = append(matched, it.Push().ID)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for i := 0; i < len(matched); {
+		if deleted > 0 && cfg.interval > 0 {
+			if err := sleepContext(ctx, cfg.interval); err != nil {
+				return deleted, err
+			}
+		}
+		err := c.DeletePushContext(ctx, matched[i])
+		var rl *RateLimitError
+		if errors.As(err, &rl) {
+			wait := rl.RetryAfter()
+			if wait < time.Second {
+				wait = time.Second
+			}
+			if err := sleepContext(ctx, wait); err != nil {
+				return deleted, err
+			}
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted++
+		i++
+		if cfg.progress != nil {
+			cfg.progress(deleted, len(matched))
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,56 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeletePushes(t *testing.T) {
+	var deleted []string
+	limited := false
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			if !limited {
+				// one rate limit, reset already passed
+				limited = true
+				w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10))
+				w.WriteHeader(429)
+				return
+			}
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/pushes/"))
+			w.Write([]byte("{}"))
+			return
+		}
+		fmt.Fprint(w, `{"pushes": [
+			{"iden": "new", "type": "note", "created": 2000000000},
+			{"iden": "old-note", "type": "note", "created": 1000000000},
+			{"iden": "old-link", "type": "link", "created": 1000000000},
+			{"iden": "old-channel", "type": "note", "created": 1000000000, "channel_iden": "c1"}
+		]}`)
+	})
+	defer server.Close()
+
+	var progress []string
+	filter := PushFilter{OlderThan: time.Unix(1500000000, 0), Type: "note"}
+	n, err := c.DeletePushes(context.Background(), filter, DeleteInterval(0), DeleteProgress(func(deleted, matched int) {
+		progress = append(progress, fmt.Sprintf("%d/%d", deleted, matched))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || fmt.Sprint(deleted) != "[old-note old-channel]" || fmt.Sprint(progress) != "[1/2 2/2]" {
+		t.Error("Unexpected deletions:", n, deleted, progress)
+	}
+}
+
+func TestPushFilterChannel(t *testing.T) {
+	f := PushFilter{ChannelID: "c1"}
+	if f.Matches(PushMessage{}) || !f.Matches(PushMessage{ChannelID: "c1"}) {
+		t.Error("Unexpected channel filtering")
+	}
+}
@@ -0,0 +1,66 @@
+package pushbullet
+
+import "net/url"
+
+//DeprecationWarning reports the use of a push type or endpoint which Pushbullet has removed or deprecated, such as
+//address and checklist pushes (now silently created as notes) or contacts (replaced by chats).
+//
+//Warnings are passed to the client's OnDeprecation handler, or logged at LogWarn when none is set. In strict mode
+//they are returned as the error of the call instead, which is then not made.
+type DeprecationWarning struct {
+	Feature     string // the deprecated push type or endpoint
+	Replacement string // what to use instead
+}
+
+func (w *DeprecationWarning) Error() string {
+	return "Deprecated: " + w.Feature + " is no longer supported by Pushbullet, use " + w.Replacement + " instead"
+}
+
+//WithStrictDeprecations makes calls using deprecated push types or endpoints fail with a *DeprecationWarning.
+func WithStrictDeprecations() Option {
+	return func(c *Client) {
+		c.StrictDeprecations = true
+	}
+}
+
+//WithDeprecationHandler passes every deprecation warning to handler rather than logging it.
+func WithDeprecationHandler(handler func(*DeprecationWarning)) Option {
+	return func(c *Client) {
+		c.OnDeprecation = handler
+	}
+}
+
+//deprecated signals the use of a deprecated feature, returning the warning as an error in strict mode
+func (c *Client) deprecated(feature, replacement string) error {
+	w := &DeprecationWarning{Feature: feature, Replacement: replacement}
+	if c.StrictDeprecations {
+		return w
+	}
+	if c.OnDeprecation != nil {
+		c.OnDeprecation(w)
+	} else {
+		c.warn(w.Error())
+	}
+	return nil
+}
+
+//deprecatedPushType signals pushes of types Pushbullet now coerces to notes
+func (c *Client) deprecatedPushType(p PushMessage) error {
+	switch p.Type {
+	case "address":
+		return c.deprecated("the address push type", "SendAddress, which sends a maps link")
+	case "checklist":
+		return c.deprecated("the checklist push type", "a note")
+	}
+	return nil
+}
+
+//mapsURL links to a map search for the address
+func mapsURL(address string) string {
+	return "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(address)
+}
+
+//deprecatedContacts signals use of the contacts endpoints
+func (c *Client) deprecatedContacts() error {
+	return c.deprecated("the contacts endpoint", "chats (see MigrateContactsToChats)")
+}
@@ -0,0 +1,71 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeprecationWarnings(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	var warnings []*DeprecationWarning
+	c := ClientWithKey("apikey", WithDeprecationHandler(func(w *DeprecationWarning) { warnings = append(warnings, w) }))
+	c.BaseURL = server.URL + "/"
+	if err := c.SendChecklist("Build Test", []string{"item1"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := c.GetContacts(); err != nil {
+		t.Error(err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0].Feature, "checklist") || !strings.Contains(warnings[1].Feature, "contacts") {
+		t.Error("Unexpected warnings:", warnings)
+	}
+	if requests != 2 {
+		t.Error("Deprecated calls should still be made outside strict mode:", requests)
+	}
+
+	requests = 0
+	c.StrictDeprecations = true
+	err := c.SendChecklist("Build Test", []string{"item1"})
+	if _, ok := err.(*DeprecationWarning); !ok {
+		t.Errorf("Expected *DeprecationWarning in strict mode, got %T: %v", err, err)
+	}
+	if err = c.CreateContact("Carmack", "carmack@idsoftware.com"); err == nil {
+		t.Error("Expected contacts to fail in strict mode")
+	}
+	if err = c.DeleteContact("c1"); err == nil {
+		t.Error("Expected contact deletion to fail in strict mode")
+	}
+	if requests != 0 {
+		t.Error("Strict mode still made deprecated calls:", requests)
+	}
+}
+
+func TestSendAddressAsMapsLink(t *testing.T) {
+	var sent PushMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	c := ClientWithKey("apikey", WithStrictDeprecations())
+	c.BaseURL = server.URL + "/"
+
+	if err := c.SendAddress("Build Test", "Place", "123 Main st., Newtown, CT"); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Type != "link" || sent.Body != "Place\n123 Main st., Newtown, CT" ||
+		sent.URL != "https://www.google.com/maps/search/?api=1&query=123+Main+st.%2C+Newtown%2C+CT" {
+		t.Errorf("Address not sent as a maps link: %+v", sent)
+	}
+}
@@ -0,0 +1,148 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+//deviceCache holds the active devices between lookups until a device change invalidates it
+type deviceCache struct {
+	mu      sync.Mutex
+	valid   bool
+	devices []Device
+}
+
+//GetDeviceByNickname returns the active device with the given nickname, ignoring case. The device list is cached
+//between lookups; a Sync wired to the client invalidates the cache whenever a device changes, and
+//InvalidateDevices may be called directly after changes made elsewhere.
+func (c *Client) GetDeviceByNickname(nickname string) (Device, error) {
+	return c.GetDeviceByNicknameContext(context.Background(), nickname)
+}
+
+//GetDeviceByNicknameContext is GetDeviceByNickname bound to a context which may cancel its requests
+func (c *Client) GetDeviceByNicknameContext(ctx context.Context, nickname string) (Device, error) {
+	devices, err := c.cachedDevices(ctx)
+	if err != nil {
+		return Device{}, err
+	}
+	for _, d := range devices {
+		if strings.EqualFold(d.Nickname, nickname) {
+			return d, nil
+		}
+	}
+	return Device{}, errors.New("No device with nickname " + nickname)
+}
+
+//AmbiguousTargetError is returned by ResolveTarget when a spec matches more than one device.
+type AmbiguousTargetError struct {
+	Spec       string
+	Candidates []Device
+}
+
+func (e *AmbiguousTargetError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, d := range e.Candidates {
+		names[i] = d.Nickname + " (" + d.ID + ")"
+	}
+	return "Ambiguous device " + e.Spec + ", matches: " + strings.Join(names, ", ")
+}
+
+//ResolveTarget resolves a device spec from a command line or configuration file to one of the cached active devices.
+//The spec may be an iden, a nickname or a model, ignoring case, or a prefix of any of them. An exact match is
+//preferred over a prefix match, and when several devices match equally well an *AmbiguousTargetError listing them
+//is returned.
+func (c *Client) ResolveTarget(spec string) (Device, error) {
+	return c.ResolveTargetContext(context.Background(), spec)
+}
+
+//ResolveTargetContext is ResolveTarget bound to a context which may cancel its requests
+func (c *Client) ResolveTargetContext(ctx context.Context, spec string) (Device, error) {
+	devices, err := c.cachedDevices(ctx)
+	if err != nil {
+		return Device{}, err
+	}
+	if len(spec) == 0 {
+		return Device{}, errors.New("Empty device spec")
+	}
+	matchers := []func(d Device) bool{
+		func(d Device) bool { return d.ID == spec },
+		func(d Device) bool { return strings.EqualFold(d.Nickname, spec) },
+		func(d Device) bool { return strings.EqualFold(d.Model, spec) },
+		func(d Device) bool {
+			for _, field := range []string{d.ID, d.Nickname, d.Model} {
+				if len(field) >= len(spec) && strings.EqualFold(field[:len(spec)], spec) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	for _, matches := range matchers {
+		var found []Device
+		for _, d := range devices {
+			if matches(d) {
+				found = append(found, d)
+			}
+		}
+		switch {
+		case len(found) == 1:
+			return found[0], nil
+		case len(found) > 1:
+			return Device{}, &AmbiguousTargetError{Spec: spec, Candidates: found}
+		}
+	}
+	return Device{}, errors.New("No device matching " + spec)
+}
+
+//InvalidateDevices discards the cached device list so that the next lookup fetches it again.
+func (c *Client) InvalidateDevices() {
+	c.devices.mu.Lock()
+	defer c.devices.mu.Unlock()
+	c.devices.valid, c.devices.devices = false, nil
+}
+
+//cachedDevices returns the cached device list, fetching it when it is missing or invalidated
+func (c *Client) cachedDevices(ctx context.Context) ([]Device, error) {
+	c.devices.mu.Lock()
+	defer c.devices.mu.Unlock()
+	if c.devices.valid {
+		return c.devices.devices, nil
+	}
+	l, err := c.GetDevicesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.devices.valid, c.devices.devices = true, l.Devices
+	return l.Devices, nil
+}
+
+//CreateDevice registers a device, such as the computer a program runs on, so that pushes can target it. The
+//nickname is required; the model, manufacturer, icon and SMS capability are sent when set. The created device is
+//returned.
+func (c *Client) CreateDevice(ctx context.Context, d Device) (Device, error) {
+	if len(d.Nickname) == 0 {
+		return Device{}, &ValidationError{Field: "nickname", Message: "A device nickname is required"}
+	}
+	fields := map[string]interface{}{"nickname": d.Nickname}
+	for name, value := range map[string]string{"model": d.Model, "manufacturer": d.Manufacturer, "icon": d.Icon} {
+		if len(value) > 0 {
+			fields[name] = value
+		}
+	}
+	if d.HasSMS {
+		fields["has_sms"] = true
+	}
+	res, apiError, err := c.makeCallContext(ctx, "POST", routeDevices, fields)
+	if err != nil {
+		c.warn("Failed to create device:", err, apiError.String())
+		return Device{}, err
+	}
+	var created Device
+	if err = c.decode(res, &created); err != nil {
+		return created, err
+	}
+	c.InvalidateDevices()
+	return created, nil
+}
@@ -0,0 +1,96 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetDeviceByNickname(t *testing.T) {
+	requests := 0
+	devices := `{"devices": [{"iden": "d1", "nickname": "Phone", "active": true, "modified": 1}]}`
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("modified_after") == "" {
+			requests++
+		}
+		fmt.Fprintln(w, devices)
+	})
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if d, err := c.GetDeviceByNickname("phone"); err != nil || d.ID != "d1" {
+			t.Error("Unexpected lookup:", d, err)
+		}
+	}
+	if requests != 1 {
+		t.Error("Device list not cached, requests:", requests)
+	}
+	if _, err := c.GetDeviceByNickname("Tablet"); err == nil {
+		t.Error("Expected unknown nickname to fail")
+	}
+
+	// a sync seeing the device renamed invalidates the cache
+	devices = `{"devices": [{"iden": "d1", "nickname": "Old Phone", "active": true, "modified": 2}]}`
+	if err := NewSync(c, nil).Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetDeviceByNickname("Phone"); err == nil {
+		t.Error("Lookup resolved to a renamed device")
+	}
+	if d, err := c.GetDeviceByNickname("Old Phone"); err != nil || d.ID != "d1" {
+		t.Error("Renamed device not found:", d, err)
+	}
+}
+
+func TestResolveTarget(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"devices": [
+		{"iden": "ujpah72o0", "nickname": "Pixel", "model": "Pixel 7", "active": true},
+		{"iden": "ujpah72o1", "nickname": "Work Laptop", "model": "Chrome", "active": true},
+		{"iden": "ujxyz", "nickname": "Home Laptop", "model": "Firefox", "active": true}]}`)
+	defer mockServer.Close()
+
+	for spec, want := range map[string]string{
+		"ujxyz":     "ujxyz",
+		"pixel":     "ujpah72o0",
+		"chrome":    "ujpah72o1",
+		"work":      "ujpah72o1",
+		"ujpah72o1": "ujpah72o1",
+		"fire":      "ujxyz",
+	} {
+		if d, err := c.ResolveTarget(spec); err != nil || d.ID != want {
+			t.Errorf("Resolving %q: got %s, %v", spec, d.ID, err)
+		}
+	}
+	_, err := c.ResolveTarget("ujpah")
+	if amb, ok := err.(*AmbiguousTargetError); !ok || len(amb.Candidates) != 2 {
+		t.Error("Expected an ambiguity error, got:", err)
+	}
+	if _, err := c.ResolveTarget("tablet"); err == nil {
+		t.Error("Expected an unknown spec to fail")
+	}
+}
+
+func TestCreateDevice(t *testing.T) {
+	var body map[string]interface{}
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/devices" {
+			t.Error("Unexpected request:", r.Method, r.URL)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"iden": "d9", "nickname": "nas", "icon": "desktop", "active": true, "pushable": true}`)
+	})
+	defer server.Close()
+
+	d, err := c.CreateDevice(context.Background(), Device{Nickname: "nas", Icon: "desktop"})
+	if err != nil || d.ID != "d9" {
+		t.Fatal("Unexpected device:", d, err)
+	}
+	if fmt.Sprint(body) != "map[icon:desktop nickname:nas]" {
+		t.Error("Unexpected request body:", body)
+	}
+	if _, err := c.CreateDevice(context.Background(), Device{}); err == nil {
+		t.Error("Expected a nickname to be required")
+	}
+}
@@ -0,0 +1,151 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Digester is a Notifier which holds notifications back and sends one summary of them per interval, cutting the
+//noise from chatty services down to a single push.
+//
+//	d := pushbullet.NewDigester(client)
+//	go d.Run(ctx, time.Hour)
+//	var n pushbullet.Notifier = d
+type Digester struct {
+	Notifier Notifier       // receives the summaries
+	Title    string         // prefixes the summary title, "Digest" when empty
+	Options  []NotifyOption // applied to each summary, e.g. a target
+
+	mu      sync.Mutex
+	pending []digestEntry
+	now     func() time.Time
+}
+
+type digestEntry struct {
+	title string
+	url   string // the link or file of a held push
+	at    time.Time
+}
+
+//NewDigester returns a Digester summarizing to n.
+func NewDigester(n Notifier) *Digester {
+	return &Digester{Notifier: n}
+}
+
+//Notify holds the notification for the next summary. Targets and other options of individual notifications are
+//not kept; the summary is sent with the Digester's Options.
+func (d *Digester) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(title) == 0 {
+		title = body
+	}
+	d.pending = append(d.pending, digestEntry{title: title, at: d.clock()})
+	return nil
+}
+
+//AddPush holds the push for the next summary, keeping the link or file it points to. Like Notify, its target is
+//not kept.
+func (d *Digester) AddPush(p PushMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	title := p.Title
+	if len(title) == 0 {
+		title = p.Body
+	}
+	if len(title) == 0 {
+		title = p.FileName
+	}
+	url := p.URL
+	if p.Type == "file" {
+		url = p.FileURL
+	}
+	d.pending = append(d.pending, digestEntry{title: title, url: url, at: d.clock()})
+}
+
+//Flush sends a summary of the held notifications, if there are any. Notifications are kept for the next summary
+//when sending fails.
+func (d *Digester) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = nil
+	d.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	title, body := d.summarize(pending)
+	if err := d.Notifier.Notify(ctx, title, body, d.Options...); err != nil {
+		d.mu.Lock()
+		d.pending = append(pending, d.pending...)
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+//Run flushes a summary every interval until the context is done, then flushes what remains.
+func (d *Digester) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid digest interval")
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// the final summary gets its own context as ctx is already done
+			d.Flush(context.Background())
+			return ctx.Err()
+		case <-t.C:
+			d.Flush(ctx)
+		}
+	}
+}
+
+//summarize counts the notifications by title and link, in order of first appearance, and notes the time span they
+//cover
+func (d *Digester) summarize(pending []digestEntry) (title, body string) {
+	prefix := d.Title
+	if len(prefix) == 0 {
+		prefix = "Digest"
+	}
+	title = fmt.Sprintf("%s: %d notifications", prefix, len(pending))
+	if len(pending) == 1 {
+		title = prefix + ": 1 notification"
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, e := range pending {
+		line := e.title
+		if len(e.url) > 0 {
+			line += " " + e.url
+		}
+		if counts[line] == 0 {
+			order = append(order, line)
+		}
+		counts[line]++
+	}
+	lines := make([]string, 0, len(order)+2)
+	for _, t := range order {
+		if counts[t] > 1 {
+			t = fmt.Sprintf("%s (x%d)", t, counts[t])
+		}
+		lines = append(lines, t)
+	}
+	const layout = "2006-01-02 15:04:05"
+	first, last := pending[0].at, pending[len(pending)-1].at
+	lines = append(lines, "", fmt.Sprintf("From %s to %s", first.Format(layout), last.Format(layout)))
+	return title, strings.Join(lines, "\n")
+}
+
+func (d *Digester) clock() time.Time {
+	if d.now != nil {
+		return d.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,84 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigesterSummary(t *testing.T) {
+	rec := &RecordingNotifier{}
+	d := NewDigester(rec)
+	d.Options = []NotifyOption{ToChannel("ops")}
+	at := time.Date(2015, 4, 25, 10, 0, 0, 0, time.UTC)
+	d.now = func() time.Time {
+		at = at.Add(time.Minute)
+		return at
+	}
+	ctx := context.Background()
+	for _, title := range []string{"Disk full", "Build failed", "Disk full", "Disk full"} {
+		d.Notify(ctx, title, "details")
+	}
+	if len(rec.Pushes()) != 0 {
+		t.Fatal("Notification sent before the flush")
+	}
+	if err := d.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pushes := rec.Pushes()
+	want := "Disk full (x3)\nBuild failed\n\nFrom 2015-04-25 10:01:00 to 2015-04-25 10:04:00"
+	if len(pushes) != 1 || pushes[0].Title != "Digest: 4 notifications" || pushes[0].Body != want || pushes[0].ChannelTag != "ops" {
+		t.Fatalf("Unexpected summary: %+v", pushes)
+	}
+	d.Flush(ctx)
+	if len(rec.Pushes()) != 1 {
+		t.Error("Empty digest was sent")
+	}
+}
+
+func TestDigesterKeepsPendingOnFailure(t *testing.T) {
+	fail := true
+	var bodies []string
+	d := NewDigester(NotifierFunc(func(ctx context.Context, title, body string, opts ...NotifyOption) error {
+		if fail {
+			return errors.New("offline")
+		}
+		bodies = append(bodies, title)
+		return nil
+	}))
+	ctx := context.Background()
+	d.Notify(ctx, "One", "")
+	if d.Flush(ctx) == nil {
+		t.Fatal("Expected the failed summary to return an error")
+	}
+	d.Notify(ctx, "Two", "")
+	fail = false
+	d.Flush(ctx)
+	if len(bodies) != 1 || bodies[0] != "Digest: 2 notifications" {
+		t.Error("Held notifications lost after a failed flush:", bodies)
+	}
+}
+
+func TestDigesterRunInvalidInterval(t *testing.T) {
+	if err := NewDigester(&RecordingNotifier{}).Run(context.Background(), 0); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
+
+func TestDigesterAddPush(t *testing.T) {
+	rec := &RecordingNotifier{}
+	d := NewDigester(rec)
+	d.AddPush(PushMessage{Type: "file", FileName: "report.pdf", FileURL: "https://dl.example.com/report.pdf"})
+	d.AddPush(PushMessage{Type: "link", Title: "Release", URL: "https://example.com/v2"})
+	d.AddPush(PushMessage{Type: "link", Title: "Release", URL: "https://example.com/v2"})
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	pushes := rec.Pushes()
+	want := "report.pdf https://dl.example.com/report.pdf\nRelease https://example.com/v2 (x2)\n"
+	if len(pushes) != 1 || !strings.HasPrefix(pushes[0].Body, want) {
+		t.Fatalf("Unexpected summary: %+v", pushes)
+	}
+}
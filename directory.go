@@ -0,0 +1,119 @@
+package pushbullet
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+//DirectoryOption selects the files SendDirectory archives.
+type DirectoryOption func(*directoryConfig)
+
+type directoryConfig struct {
+	include, exclude []string
+}
+
+//IncludeFiles archives only the files matching one of the patterns. Patterns use path.Match syntax and are matched
+//against both the slash separated path within the directory and the file name, so "*.log" matches at any depth.
+func IncludeFiles(patterns ...string) DirectoryOption {
+	return func(c *directoryConfig) { c.include = append(c.include, patterns...) }
+}
+
+//ExcludeFiles leaves out the files and directories matching one of the patterns, such as ".git" or "*.tmp".
+func ExcludeFiles(patterns ...string) DirectoryOption {
+	return func(c *directoryConfig) { c.exclude = append(c.exclude, patterns...) }
+}
+
+//SendDirectory zips the directory and sends the archive to the target as a single file push, named after the
+//directory. The archive is streamed to the upload as it is built, which fails with ErrUploadTooLarge as soon as it
+//exceeds the account's upload limit.
+func (c *Client) SendDirectory(dir string, target Target, opts ...DirectoryOption) (PushMessage, error) {
+	return c.SendDirectoryContext(context.Background(), dir, target, opts...)
+}
+
+//SendDirectoryContext is SendDirectory bound to a context which may cancel its requests
+func (c *Client) SendDirectoryContext(ctx context.Context, dir string, target Target, opts ...DirectoryOption) (PushMessage, error) {
+	var cfg directoryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return PushMessage{}, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(zipDirectory(pw, dir, cfg))
+	}()
+	// closing the reader stops the archive when the upload ends early
+	defer pr.Close()
+	name := filepath.Base(filepath.Clean(dir)) + ".zip"
+	auth, err := c.Upload(ctx, pr, name, "application/zip")
+	if err != nil {
+		c.warn("Failed to upload directory:", err)
+		return PushMessage{}, err
+	}
+	p := PushMessage{Type: "file", FileName: auth.FileName, FileType: auth.FileType, FileURL: auth.FileURL}
+	target.NotifyOption()(&p)
+	return c.sendPush(ctx, p)
+}
+
+//zipDirectory writes the selected files of the directory to w as a zip archive
+func zipDirectory(w io.Writer, dir string, cfg directoryConfig) error {
+	zw := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchesAny(cfg.exclude, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || (len(cfg.include) > 0 && !matchesAny(cfg.include, rel)) {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name, header.Method = rel, zip.Deflate
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+//matchesAny reports whether the slash separated path or its base name matches one of the patterns
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+			return true
+		}
+	}
+	return false
+}
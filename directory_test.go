@@ -0,0 +1,87 @@
+package pushbullet_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "senddir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, "build", filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "build")
+}
+
+func TestSendDirectory(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"main.log":        "started",
+		"logs/run.log":    "ok",
+		"logs/run.tmp":    "scratch",
+		".git/HEAD":       "ref",
+		"report/out.json": "{}",
+	})
+	defer os.RemoveAll(filepath.Dir(dir))
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+
+	_, err := c.SendDirectory(dir, pushbullet.ChannelTarget("builds"), pushbullet.IncludeFiles("*.log", "report/*"),
+		pushbullet.ExcludeFiles(".git", "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := server.Pushes()[0]
+	if p.FileName != "build.zip" || p.FileType != "application/zip" || p.ChannelTag != "builds" {
+		t.Errorf("Unexpected push: %+v", p)
+	}
+	archive, _ := server.File("build.zip")
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, " ") != "logs/run.log main.log report/out.json" {
+		t.Error("Unexpected archive contents:", names)
+	}
+}
+
+func TestSendDirectoryTooLarge(t *testing.T) {
+	noise := make([]byte, 4096)
+	rand.Read(noise)
+	dir := writeTree(t, map[string]string{"big.bin": string(noise)})
+	defer os.RemoveAll(filepath.Dir(dir))
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+	server.SetUser(pushbullet.User{MaxUploadSize: 1024})
+
+	if _, err := c.SendDirectory(dir, pushbullet.Target{}); err != pushbullet.ErrUploadTooLarge {
+		t.Error("Expected ErrUploadTooLarge, got:", err)
+	}
+	// the archive is streamed, so the upload is authorized before its size is known, but never completes
+	if files := server.Files(); len(files) != 0 {
+		t.Error("Oversized archive uploaded:", files)
+	}
+}
@@ -0,0 +1,65 @@
+package pushbullet
+
+import (
+	"fmt"
+	"strings"
+)
+
+//EchoMismatch is returned by sends when echo verification is enabled and the push created by Pushbullet differs
+//from the one requested, e.g. an address push silently converted to a note. The push was still sent; Created
+//holds it as returned by the API.
+type EchoMismatch struct {
+	Created PushMessage
+	Fields  []FieldMismatch
+}
+
+//FieldMismatch describes one field the API echoed back differently than it was sent.
+type FieldMismatch struct {
+	Field  string
+	Sent   string
+	Echoed string
+}
+
+func (e *EchoMismatch) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = fmt.Sprintf("%s sent as %q but created as %q", f.Field, f.Sent, f.Echoed)
+	}
+	return "Push was changed by Pushbullet: " + strings.Join(msgs, ", ")
+}
+
+//WithEchoVerification checks every created push against the request (type, target, and file URL), returning an
+//*EchoMismatch from the send when the API coerced any of them. Responses with an empty body are not verified.
+func WithEchoVerification() Option {
+	return func(c *Client) {
+		c.VerifyEcho = true
+	}
+}
+
+//verifyEcho compares the fields of a sent push which Pushbullet echoes back on creation
+func verifyEcho(sent, created PushMessage) error {
+	var fields []FieldMismatch
+	mismatch := func(field, s, e string) {
+		fields = append(fields, FieldMismatch{Field: field, Sent: s, Echoed: e})
+	}
+	if sent.Type != created.Type {
+		mismatch("type", sent.Type, created.Type)
+	}
+	if len(sent.DeviceID) > 0 && sent.DeviceID != created.TargetDeviceID {
+		mismatch("device_iden", sent.DeviceID, created.TargetDeviceID)
+	}
+	if len(sent.Email) > 0 && !strings.EqualFold(sent.Email, created.ReceiverEmail) &&
+		!strings.EqualFold(sent.Email, created.ReceiverEmailNormalized) {
+		mismatch("email", sent.Email, created.ReceiverEmail)
+	}
+	if len(sent.ChannelTag) > 0 && len(created.ChannelID) == 0 {
+		mismatch("channel_tag", sent.ChannelTag, "")
+	}
+	if len(sent.FileURL) > 0 && sent.FileURL != created.FileURL {
+		mismatch("file_url", sent.FileURL, created.FileURL)
+	}
+	if len(fields) > 0 {
+		return &EchoMismatch{Created: created, Fields: fields}
+	}
+	return nil
+}
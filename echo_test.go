@@ -0,0 +1,38 @@
+package pushbullet
+
+import (
+	"testing"
+)
+
+func TestEchoVerification(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"iden": "p1", "type": "note", "target_device_iden": "_deviceid_"}`)
+	defer mockServer.Close()
+
+	if err := c.SendAddressToTarget("device", "_deviceid_", "Build Test", "Place", "123 Main st., Newtown, CT"); err != nil {
+		t.Error("Verification should be off by default:", err)
+	}
+
+	c.VerifyEcho = true
+	if err := c.SendNoteToTarget("device", "_deviceid_", "Build Test", "This is a test of gopushbullet."); err != nil {
+		t.Error("Matching echo reported as mismatch:", err)
+	}
+	_, err := c.SendPush(PushRequest{Type: "address", DeviceID: "_deviceid_", Name: "Place", Address: "123 Main st., Newtown, CT"})
+	m, ok := err.(*EchoMismatch)
+	if !ok || len(m.Fields) != 1 || m.Fields[0].Field != "type" || m.Fields[0].Echoed != "note" || m.Created.ID != "p1" {
+		t.Errorf("Expected type mismatch, got %T: %v", err, err)
+	}
+	err = c.SendNoteToTarget("device", "_otherdevice_", "Build Test", "This is a test of gopushbullet.")
+	if m, ok := err.(*EchoMismatch); !ok || m.Fields[0].Field != "device_iden" {
+		t.Errorf("Expected target mismatch, got %T: %v", err, err)
+	}
+}
+
+func TestEchoVerificationSkipsEmptyBody(t *testing.T) {
+	mockServer, c := mockHTTP(200, "")
+	defer mockServer.Close()
+
+	c.VerifyEcho = true
+	if err := c.SendNoteToTarget("device", "_deviceid_", "Build Test", "This is a test of gopushbullet."); err != nil {
+		t.Error("Created push with an empty response reported as mismatch:", err)
+	}
+}
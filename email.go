@@ -0,0 +1,25 @@
+package pushbullet
+
+import (
+	"net/mail"
+	"strings"
+)
+
+//NormalizeEmail trims the address and lowercases its domain, returning a *ValidationError for anything which is not
+//a bare addr-spec such as "user@example.com". Pushbullet treats a push or chat for an unknown address as an
+//invitation, so a malformed address would otherwise fail silently.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	invalid := &ValidationError{Field: "email", Message: "not a valid address: " + email}
+	a, err := mail.ParseAddress(email)
+	if err != nil || len(a.Name) > 0 || a.Address != email {
+		return "", invalid
+	}
+	at := strings.LastIndex(email, "@")
+	domain := strings.ToLower(email[at+1:])
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") ||
+		strings.Contains(domain, "..") {
+		return "", invalid
+	}
+	return email[:at+1] + domain, nil
+}
@@ -0,0 +1,49 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	for in, want := range map[string]string{
+		"  Friend@Example.COM ":        "Friend@example.com",
+		"a.b+tag@mail.example.org":     "a.b+tag@mail.example.org",
+		"friend":                       "",
+		"friend@":                      "",
+		"friend@localhost":             "",
+		"friend@example..com":          "",
+		"Friend <f@example.com>":       "",
+		"f@example.com, g@example.com": "",
+	} {
+		got, err := NormalizeEmail(in)
+		if got != want || (err != nil) != (want == "") {
+			t.Errorf("NormalizeEmail(%q) = %q, %v", in, got, err)
+		}
+	}
+}
+
+func TestEmailTargetsNormalized(t *testing.T) {
+	var sent []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body["email"].(string))
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	if err := c.SendNoteToTarget("email", " Friend@Example.com", "hi", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateChat("Friend@EXAMPLE.com "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateChat("not an address"); err == nil {
+		t.Error("Expected a malformed address to be rejected")
+	}
+	if len(sent) != 2 || sent[0] != "Friend@example.com" || sent[1] != "Friend@example.com" {
+		t.Error("Unexpected addresses sent:", sent)
+	}
+}
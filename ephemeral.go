@@ -0,0 +1,37 @@
+package pushbullet
+
+import "context"
+
+//MirrorNotification is a notification mirrored to the user's other devices as an ephemeral, shown like the
+//notifications the Android app mirrors from a phone. Ephemerals are delivered to connected devices only and are not
+//kept in the push history.
+type MirrorNotification struct {
+	Type             string `json:"type"` // always "mirror"
+	ApplicationName  string `json:"application_name"`
+	Title            string `json:"title"`
+	Body             string `json:"body"`
+	Icon             string `json:"icon,omitempty"` // base64 encoded JPEG
+	SourceUserID     string `json:"source_user_iden"`
+	SourceDeviceID   string `json:"source_device_iden"`
+	NotificationID   string `json:"notification_id"`
+	NotificationTag  string `json:"notification_tag,omitempty"`
+	PackageName      string `json:"package_name"`
+	Dismissible      bool   `json:"dismissible"`
+	ConversationIden string `json:"conversation_iden,omitempty"`
+}
+
+//ephemeral wraps the payload of an ephemeral message
+type ephemeral struct {
+	Type string      `json:"type"`
+	Push interface{} `json:"push"`
+}
+
+//SendMirror mirrors a notification to the user's connected devices.
+func (c *Client) SendMirror(ctx context.Context, n MirrorNotification) error {
+	n.Type = "mirror"
+	_, apiError, err := c.makeCallContext(ctx, "POST", routeEphemerals, ephemeral{Type: "push", Push: n})
+	if err != nil {
+		c.warn("Failed to send mirror:", err, apiError.String())
+	}
+	return err
+}
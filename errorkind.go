@@ -0,0 +1,118 @@
+package pushbullet
+
+import (
+	"errors"
+	"net"
+)
+
+//ErrorKind classifies a failure for presentation, see KindOf.
+type ErrorKind string
+
+//Kinds of failure
+const (
+	KindInvalidRequest  ErrorKind = "invalid_request"
+	KindBadToken        ErrorKind = "bad_token"        // the access token is missing, invalid or revoked
+	KindUpgradeRequired ErrorKind = "upgrade_required" // the account needs Pushbullet Pro or the endpoint is retired
+	KindRateLimited     ErrorKind = "rate_limited"
+	KindNotFound        ErrorKind = "not_found"
+	KindServer          ErrorKind = "server"
+	KindMalformed       ErrorKind = "malformed_response"
+	KindNetwork         ErrorKind = "network"
+	KindUnknown         ErrorKind = "unknown"
+)
+
+//ErrorText is the presentation of an ErrorKind: a short title prefixing API error messages, and a sentence to show
+//users.
+type ErrorText struct {
+	Title   string
+	Message string
+}
+
+//ErrorCatalog holds the English text of each ErrorKind. Applications may replace or add entries before making
+//calls; use a Translator on the client for localization.
+var ErrorCatalog = map[ErrorKind]ErrorText{
+	KindInvalidRequest:  {"Invalid Request", "Pushbullet rejected the request."},
+	KindBadToken:        {"Invalid Access Token", "Your Pushbullet access token is invalid or has been revoked. Please sign in again."},
+	KindUpgradeRequired: {"Upgrade Required", "This feature needs Pushbullet Pro or is no longer available."},
+	KindRateLimited:     {"Rate Limited", "Too many requests were made to Pushbullet. Please try again later."},
+	KindNotFound:        {"Not Found", "The item no longer exists on Pushbullet."},
+	KindServer:          {"Server Error", "Pushbullet is having trouble. Please try again later."},
+	KindMalformed:       {"Malformed Response", "Pushbullet sent a response which could not be read."},
+	KindNetwork:         {"Network Error", "Pushbullet could not be reached. Check your connection."},
+	KindUnknown:         {"Error", "Something went wrong talking to Pushbullet."},
+}
+
+//Translator returns the message to show users for an error, or an empty string to fall back to the ErrorCatalog.
+type Translator func(kind ErrorKind, err error) string
+
+//WithTranslator localizes the messages returned by Describe.
+func WithTranslator(t Translator) Option {
+	return func(c *Client) {
+		c.Translator = t
+	}
+}
+
+//Kind classifies the API error by its type.
+func (e *Error) Kind() ErrorKind {
+	switch e.ErrorBody.Type {
+	case "invalid_request":
+		return KindInvalidRequest
+	case "invalid_access_token":
+		return KindBadToken
+	case "pushbullet_pro_required":
+		return KindUpgradeRequired
+	}
+	return KindServer
+}
+
+//KindOf classifies an error returned by the client.
+func KindOf(err error) ErrorKind {
+	var rl *RateLimitError
+	var status *StatusError
+	var decodeError *DecodeError
+	var netError net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &rl):
+		return KindRateLimited
+	case errors.As(err, &status):
+		switch code := status.StatusCode; {
+		case code == 401:
+			return KindBadToken
+		case code == 402 || code == 410 || code == 426:
+			return KindUpgradeRequired
+		case code == 404:
+			return KindNotFound
+		case code == 429:
+			return KindRateLimited
+		case code >= 500:
+			return KindServer
+		case code >= 400:
+			return KindInvalidRequest
+		}
+	case errors.As(err, &decodeError):
+		return KindMalformed
+	case errors.As(err, &netError):
+		return KindNetwork
+	}
+	return KindUnknown
+}
+
+//Describe returns a message suitable for showing users in place of err, from the client's Translator when it has
+//one for the error and the ErrorCatalog otherwise.
+func (c *Client) Describe(err error) string {
+	if err == nil {
+		return ""
+	}
+	kind := KindOf(err)
+	if c.Translator != nil {
+		if s := c.Translator(kind, err); len(s) > 0 {
+			return s
+		}
+	}
+	if text, ok := ErrorCatalog[kind]; ok {
+		return text.Message
+	}
+	return ErrorCatalog[KindUnknown].Message
+}
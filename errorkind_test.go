@@ -0,0 +1,53 @@
+package pushbullet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	for err, want := range map[error]ErrorKind{
+		&StatusError{StatusCode: 401}:                            KindBadToken,
+		&StatusError{StatusCode: 402}:                            KindUpgradeRequired,
+		&StatusError{StatusCode: 404}:                            KindNotFound,
+		&StatusError{StatusCode: 400}:                            KindInvalidRequest,
+		&StatusError{StatusCode: 503}:                            KindServer,
+		fmt.Errorf("sending: %w", &RateLimitError{}):             KindRateLimited,
+		&DecodeError{Err: errors.New("unexpected EOF")}:          KindMalformed,
+		&net.OpError{Op: "dial", Err: errors.New("refused")}:     KindNetwork,
+		errors.New("Error: API key required."):                   KindUnknown,
+		fmt.Errorf("listing: %w", &StatusError{StatusCode: 410}): KindUpgradeRequired,
+	} {
+		if got := KindOf(err); got != want {
+			t.Errorf("%v: expected %s, got %s", err, want, got)
+		}
+	}
+	if e := (&Error{errorBody{Type: "pushbullet_pro_required", Message: "Pro required"}}); e.String() != "Upgrade Required: Pro required" {
+		t.Error("Unexpected API error string:", e.String())
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	c := ClientWithKey("apikey")
+	if s := c.Describe(&StatusError{StatusCode: 401}); s != ErrorCatalog[KindBadToken].Message {
+		t.Error("Expected the catalog message:", s)
+	}
+	if c.Describe(nil) != "" {
+		t.Error("Expected no message without an error")
+	}
+
+	c = ClientWithKey("apikey", WithTranslator(func(kind ErrorKind, err error) string {
+		if kind == KindRateLimited {
+			return "Zu viele Anfragen. Bitte später erneut versuchen."
+		}
+		return ""
+	}))
+	if s := c.Describe(&RateLimitError{}); s != "Zu viele Anfragen. Bitte später erneut versuchen." {
+		t.Error("Expected the translation:", s)
+	}
+	if s := c.Describe(&StatusError{StatusCode: 500}); s != ErrorCatalog[KindServer].Message {
+		t.Error("Expected the catalog to be the fallback:", s)
+	}
+}
@@ -0,0 +1,300 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//EscalationStep sends an alert to its targets, After the previous step went unacknowledged for that long.
+type EscalationStep struct {
+	Targets []Target      `json:"targets"`
+	After   time.Duration `json:"after"` // ignored for the first step, which is sent at once
+}
+
+//EscalationPolicy is a chain of steps, such as "push to the on-call device; if not dismissed in 10m, push to the
+//team channel and email".
+type EscalationPolicy struct {
+	Name  string           `json:"name"`
+	Steps []EscalationStep `json:"steps"`
+}
+
+//Escalation is an alert working through a policy.
+type Escalation struct {
+	ID      string    `json:"id"`
+	Policy  string    `json:"policy"`
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	Step    int       `json:"step"`               // index of the last step sent
+	Next    time.Time `json:"next"`               // when the next step is due
+	PushIDs []string  `json:"push_ids,omitempty"` // pushes sent so far, any dismissal of which acknowledges
+}
+
+//EscalationStore persists the open escalations of an Escalator.
+type EscalationStore interface {
+	Load() ([]Escalation, error)
+	Save([]Escalation) error
+}
+
+//Escalator runs escalation policies. An escalation is acknowledged when any of its pushes is dismissed, as seen by
+//Sync, which must be refreshed by the caller (for example with Sync.Run), or by calling Ack. With a Store, open
+//escalations survive restarts: Run reloads them and carries on where they left off.
+//
+//	e := pushbullet.NewEscalator(client, s, policies...)
+//	e.Store = pushbullet.EscalationFile("escalations.json")
+//	go e.Run(ctx, time.Minute)
+//	e.Escalate(ctx, "oncall", "db down", err.Error())
+type Escalator struct {
+	Client   *Client
+	Sync     *Sync
+	Policies map[string]EscalationPolicy
+	Store    EscalationStore
+	OnError  func(Escalation, error)
+
+	sendMu sync.Mutex // serializes advances, so a step is never sent twice
+	mu     sync.Mutex
+	open   map[string]*Escalation
+	cancel map[string][]func()
+	loaded bool
+	now    func() time.Time
+}
+
+//NewEscalator returns an Escalator sending through c and watching for dismissals through s.
+func NewEscalator(c *Client, s *Sync, policies ...EscalationPolicy) *Escalator {
+	e := &Escalator{Client: c, Sync: s, Policies: make(map[string]EscalationPolicy)}
+	for _, p := range policies {
+		e.Policies[p.Name] = p
+	}
+	return e
+}
+
+//Escalate starts an escalation under the named policy, sending its first step, and returns its id.
+func (e *Escalator) Escalate(ctx context.Context, policy, title, body string) (string, error) {
+	if err := e.load(); err != nil {
+		return "", err
+	}
+	p, ok := e.Policies[policy]
+	if !ok || len(p.Steps) == 0 {
+		return "", errors.New("Unknown escalation policy " + policy)
+	}
+	esc := &Escalation{ID: newGUID(), Policy: policy, Title: title, Body: body, Step: -1}
+	e.mu.Lock()
+	e.open[esc.ID] = esc
+	e.mu.Unlock()
+	return esc.ID, e.advance(ctx, esc, -1)
+}
+
+//Ack acknowledges the escalation, stopping it.
+func (e *Escalator) Ack(id string) error {
+	e.mu.Lock()
+	_, ok := e.open[id]
+	e.closeLocked(id)
+	e.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return e.save()
+}
+
+//Open returns the escalations still running.
+func (e *Escalator) Open() []Escalation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	l := make([]Escalation, 0, len(e.open))
+	for _, esc := range e.open {
+		l = append(l, *esc)
+	}
+	return l
+}
+
+//Run loads the stored escalations and sends each due step, checking on every interval until the context is done.
+func (e *Escalator) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid escalation interval")
+	}
+	if err := e.load(); err != nil {
+		return err
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		e.escalateDue(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+//escalateDue sends the next step of every unacknowledged escalation which is due
+func (e *Escalator) escalateDue(ctx context.Context) {
+	now := e.clock()
+	type dueStep struct {
+		esc  *Escalation
+		from int
+	}
+	var due []dueStep
+	e.mu.Lock()
+	for _, esc := range e.open {
+		if !now.Before(esc.Next) {
+			due = append(due, dueStep{esc, esc.Step})
+		}
+	}
+	e.mu.Unlock()
+	for _, d := range due {
+		if err := e.advance(ctx, d.esc, d.from); err != nil && e.OnError != nil {
+			e.OnError(*d.esc, err)
+		}
+	}
+}
+
+//advance sends the step after from, unless the escalation has been acknowledged or already moved past it, and
+//closes the escalation after its last step. A step none of whose pushes could be sent is not advanced to, so it is
+//retried when the escalation is next due.
+func (e *Escalator) advance(ctx context.Context, esc *Escalation, from int) error {
+	e.sendMu.Lock()
+	defer e.sendMu.Unlock()
+	e.mu.Lock()
+	if e.open[esc.ID] != esc || esc.Step != from {
+		e.mu.Unlock()
+		return nil
+	}
+	steps := e.Policies[esc.Policy].Steps
+	step := from + 1
+	if step >= len(steps) {
+		e.closeLocked(esc.ID)
+		e.mu.Unlock()
+		return e.save()
+	}
+	e.mu.Unlock()
+
+	var errs NotifyErrors
+	var sent []string
+	for _, t := range steps[step].Targets {
+		created, err := e.Client.sendPush(ctx, NotifyPush(esc.Title, esc.Body, t.NotifyOption()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sent = append(sent, created.ID)
+	}
+
+	if len(sent) == 0 && len(errs) > 0 {
+		if err := e.save(); err != nil {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+	e.mu.Lock()
+	esc.Step = step
+	esc.PushIDs = append(esc.PushIDs, sent...)
+	switch {
+	case e.open[esc.ID] != esc:
+		// acknowledged while sending
+	case step+1 < len(steps):
+		esc.Next = e.clock().Add(steps[step+1].After)
+		for _, id := range sent {
+			e.trackLocked(esc.ID, id)
+		}
+	default:
+		// nothing is left to escalate to
+		e.closeLocked(esc.ID)
+	}
+	e.mu.Unlock()
+	if err := e.save(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//trackLocked acknowledges the escalation when the push is dismissed
+func (e *Escalator) trackLocked(escID, pushID string) {
+	if e.Sync == nil || len(pushID) == 0 {
+		return
+	}
+	untrack := e.Sync.TrackPush(pushID, func(PushMessage) { e.Ack(escID) }, nil)
+	e.cancel[escID] = append(e.cancel[escID], untrack)
+}
+
+func (e *Escalator) closeLocked(id string) {
+	delete(e.open, id)
+	for _, untrack := range e.cancel[id] {
+		untrack()
+	}
+	delete(e.cancel, id)
+}
+
+//load restores the stored escalations once, tracking their pushes again
+func (e *Escalator) load() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.loaded {
+		return nil
+	}
+	e.open = make(map[string]*Escalation)
+	e.cancel = make(map[string][]func())
+	if e.Store != nil {
+		stored, err := e.Store.Load()
+		if err != nil {
+			return err
+		}
+		for i := range stored {
+			esc := stored[i]
+			e.open[esc.ID] = &esc
+			for _, id := range esc.PushIDs {
+				e.trackLocked(esc.ID, id)
+			}
+		}
+	}
+	e.loaded = true
+	return nil
+}
+
+func (e *Escalator) save() error {
+	if e.Store == nil {
+		return nil
+	}
+	return e.Store.Save(e.Open())
+}
+
+func (e *Escalator) clock() time.Time {
+	if e.now != nil {
+		return e.now()
+	}
+	return time.Now()
+}
+
+//EscalationFile is an EscalationStore persisted as a JSON document at the path, rewritten atomically on every save.
+type EscalationFile string
+
+//Load reads the escalations, returning none when the file does not exist yet.
+func (f EscalationFile) Load() ([]Escalation, error) {
+	var l []Escalation
+	data, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, json.Unmarshal(data, &l)
+}
+
+//Save replaces the stored escalations.
+func (f EscalationFile) Save(l []Escalation) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(string(f), data)
+}
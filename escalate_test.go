@@ -0,0 +1,180 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// escalationServer creates pushes p1, p2, ... and reports those in dismissed as dismissed to a sync
+type escalationServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	sent      []PushMessage
+	dismissed map[string]bool
+	failing   bool // pushes fail with a server error
+}
+
+func newEscalationServer() *escalationServer {
+	s := &escalationServer{dismissed: make(map[string]bool)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch {
+		case r.Method == "POST" && s.failing:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == "POST" && r.URL.Path == "/pushes":
+			var p PushMessage
+			json.NewDecoder(r.Body).Decode(&p)
+			p.ID, p.Active = fmt.Sprint("p", len(s.sent)+1), true
+			s.sent = append(s.sent, p)
+			json.NewEncoder(w).Encode(p)
+		case r.URL.Path == "/pushes":
+			var page syncPage
+			for _, p := range s.sent {
+				p.Dismissed = s.dismissed[p.ID]
+				page.Pushes = append(page.Pushes, p)
+			}
+			json.NewEncoder(w).Encode(page)
+		default:
+			w.Write([]byte("{}"))
+		}
+	}))
+	return s
+}
+
+func (s *escalationServer) targets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var l []string
+	for _, p := range s.sent {
+		l = append(l, p.DeviceID+p.ChannelTag+p.Email)
+	}
+	return l
+}
+
+var oncallPolicy = EscalationPolicy{Name: "oncall", Steps: []EscalationStep{
+	{Targets: []Target{DeviceTarget("phone")}},
+	{Targets: []Target{ChannelTarget("team"), EmailTarget("lead@example.com")}, After: 10 * time.Minute},
+}}
+
+func TestEscalationUnacknowledged(t *testing.T) {
+	server := newEscalationServer()
+	defer server.Close()
+	c := mockClient(server.URL)
+	now := time.Now()
+	e := NewEscalator(c, NewSync(c, nil), oncallPolicy)
+	e.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	if _, err := e.Escalate(ctx, "oncall", "db down", ""); err != nil {
+		t.Fatal(err)
+	}
+	e.escalateDue(ctx)
+	if fmt.Sprint(server.targets()) != "[phone]" {
+		t.Fatal("Escalated before the timeout:", server.targets())
+	}
+	now = now.Add(10 * time.Minute)
+	e.escalateDue(ctx)
+	e.escalateDue(ctx)
+	if fmt.Sprint(server.targets()) != "[phone team lead@example.com]" || len(e.Open()) != 0 {
+		t.Error("Unexpected escalation:", server.targets(), e.Open())
+	}
+}
+
+func TestEscalationRetriesFailedStep(t *testing.T) {
+	server := newEscalationServer()
+	defer server.Close()
+	c := mockClient(server.URL)
+	now := time.Now()
+	e := NewEscalator(c, NewSync(c, nil), oncallPolicy)
+	e.now = func() time.Time { return now }
+	var failures []error
+	e.OnError = func(esc Escalation, err error) { failures = append(failures, err) }
+
+	ctx := context.Background()
+	if _, err := e.Escalate(ctx, "oncall", "db down", ""); err != nil {
+		t.Fatal(err)
+	}
+	server.mu.Lock()
+	server.failing = true
+	server.mu.Unlock()
+	now = now.Add(10 * time.Minute)
+	e.escalateDue(ctx)
+	if open := e.Open(); len(failures) != 1 || len(open) != 1 || open[0].Step != 0 {
+		t.Fatalf("Expected the failed step reported and not advanced to: %v %+v", failures, open)
+	}
+	if _, ok := failures[0].(NotifyErrors); !ok {
+		t.Errorf("Expected NotifyErrors, got %T", failures[0])
+	}
+
+	server.mu.Lock()
+	server.failing = false
+	server.mu.Unlock()
+	e.escalateDue(ctx)
+	if fmt.Sprint(server.targets()) != "[phone team lead@example.com]" || len(e.Open()) != 0 {
+		t.Error("Expected the step retried:", server.targets(), e.Open())
+	}
+}
+
+func TestEscalationDismissed(t *testing.T) {
+	server := newEscalationServer()
+	defer server.Close()
+	c := mockClient(server.URL)
+	now := time.Now()
+	s := NewSync(c, nil)
+	e := NewEscalator(c, s, oncallPolicy)
+	e.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	e.Escalate(ctx, "oncall", "db down", "")
+	server.mu.Lock()
+	server.dismissed["p1"] = true
+	server.mu.Unlock()
+	if err := s.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Hour)
+	e.escalateDue(ctx)
+	if len(server.targets()) != 1 || len(e.Open()) != 0 {
+		t.Error("Expected the dismissal to stop the escalation:", server.targets())
+	}
+}
+
+func TestEscalationSurvivesRestart(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "escalations")
+	defer os.RemoveAll(dir)
+	store := EscalationFile(filepath.Join(dir, "open.json"))
+	server := newEscalationServer()
+	defer server.Close()
+	c := mockClient(server.URL)
+	now := time.Now()
+
+	e := NewEscalator(c, nil, oncallPolicy)
+	e.Store, e.now = store, func() time.Time { return now }
+	id, err := e.Escalate(context.Background(), "oncall", "db down", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new process picks the escalation up from the store
+	restarted := NewEscalator(c, nil, oncallPolicy)
+	restarted.Store, restarted.now = store, func() time.Time { return now.Add(11 * time.Minute) }
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	restarted.Run(ctx, time.Hour)
+	if len(server.targets()) != 3 {
+		t.Error("Restored escalation not continued:", server.targets())
+	}
+	if stored, _ := store.Load(); len(stored) != 0 {
+		t.Error("Finished escalation still stored:", stored, id)
+	}
+}
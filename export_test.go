@@ -0,0 +1,11 @@
+package pushbullet
+
+import "time"
+
+// SetSelfTestLimits bounds the self-test's history lookups and stream wait for the external tests, returning a
+// function that restores them
+func SetSelfTestLimits(historyAttempts int, streamWait time.Duration) (restore func()) {
+	n, d := selfTestHistoryAttempts, selfTestStreamWait
+	selfTestHistoryAttempts, selfTestStreamWait = historyAttempts, streamWait
+	return func() { selfTestHistoryAttempts, selfTestStreamWait = n, d }
+}
@@ -0,0 +1,34 @@
+package pushbullet
+
+import "expvar"
+
+//Counters published by WithExpvar
+const (
+	CounterRequests         = "requests"          // HTTP requests made, including retries
+	CounterErrors           = "errors"            // requests which failed
+	CounterRetries          = "retries"           // requests retried after a temporary failure
+	CounterBytesUploaded    = "bytes_uploaded"    // file upload bytes sent
+	CounterStreamReconnects = "stream_reconnects" // stream connections re-established
+)
+
+//WithExpvar publishes the client's counters as an expvar map under name, served at /debug/vars by the expvar
+//package. Clients given the same name share one map. The name must not be in use by another kind of variable.
+func WithExpvar(name string) Option {
+	return func(c *Client) {
+		m, ok := expvar.Get(name).(*expvar.Map)
+		if !ok {
+			m = expvar.NewMap(name)
+			for _, counter := range []string{CounterRequests, CounterErrors, CounterRetries, CounterBytesUploaded, CounterStreamReconnects} {
+				m.Add(counter, 0)
+			}
+		}
+		c.counters = m
+	}
+}
+
+//count adds to a counter when counters are published
+func (c *Client) count(counter string, delta int64) {
+	if c.counters != nil {
+		c.counters.Add(counter, delta)
+	}
+}
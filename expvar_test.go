@@ -0,0 +1,35 @@
+package pushbullet
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExpvarCounters(t *testing.T) {
+	failures := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			w.WriteHeader(503)
+			return
+		}
+		w.Write([]byte(`{"devices": []}`))
+	}))
+	defer server.Close()
+	c := ClientWithKey("apikey", WithExpvar("pushbullet_test"), WithBackoff(ConstantBackoff{Retries: 1}))
+	c.BaseURL = server.URL + "/"
+	if _, err := c.GetDevices(); err != nil {
+		t.Fatal(err)
+	}
+	// a second client shares the published map
+	ClientWithKey("apikey", WithExpvar("pushbullet_test"))
+
+	m := expvar.Get("pushbullet_test").(*expvar.Map)
+	for counter, want := range map[string]string{CounterRequests: "2", CounterErrors: "1", CounterRetries: "1", CounterStreamReconnects: "0"} {
+		if got := m.Get(counter); got == nil || got.String() != want {
+			t.Errorf("Unexpected %s counter: %v", counter, got)
+		}
+	}
+}
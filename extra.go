@@ -0,0 +1,73 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+//Field sets of the types encoded with extra or preserved fields, without their methods so they encode with the
+//default rules
+type (
+	pushFields   PushMessage
+	deviceFields Device
+	userFields   User
+)
+
+//JSON names of the typed fields, including omitted empty ones
+var (
+	typedPushFields   = jsonFieldNames(reflect.TypeOf(PushMessage{}))
+	typedDeviceFields = jsonFieldNames(reflect.TypeOf(Device{}))
+	typedUserFields   = jsonFieldNames(reflect.TypeOf(User{}))
+)
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if len(name) > 0 && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+//MarshalJSON encodes the push, merging in any Extra fields and then any unknown fields preserved in Raw. Neither
+//replaces a typed field, even an empty one, and Extra takes precedence over Raw.
+func (p PushMessage) MarshalJSON() ([]byte, error) {
+	return mergeFields(pushFields(p), typedPushFields, p.Extra, p.Raw)
+}
+
+//MarshalJSON encodes the device along with any unknown fields preserved in Raw.
+func (d Device) MarshalJSON() ([]byte, error) {
+	return mergeFields(deviceFields(d), typedDeviceFields, nil, d.Raw)
+}
+
+//MarshalJSON encodes the user along with any unknown fields preserved in Raw.
+func (u User) MarshalJSON() ([]byte, error) {
+	return mergeFields(userFields(u), typedUserFields, nil, u.Raw)
+}
+
+//mergeFields encodes v and adds the extra fields and the unknown fields of raw which are not typed fields of v
+func mergeFields(v interface{}, typed map[string]bool, extra map[string]interface{}, raw json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || (len(extra) == 0 && len(raw) == 0) {
+		return data, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for k, v := range unknownFields(raw, typed) {
+		fields[k] = v
+	}
+	for k, v := range extra {
+		if typed[k] {
+			continue
+		}
+		if fields[k], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
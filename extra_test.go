@@ -0,0 +1,43 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestPushExtraFields(t *testing.T) {
+	var sent map[string]interface{}
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	_, err := c.SendPush(PushRequest{Type: "note", Title: "Build Test", Extra: map[string]interface{}{
+		"image_width": 640,
+		"title":       "clobbered",
+		"guid":        "clobbered",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent["image_width"] != float64(640) {
+		t.Error("Extra field not sent:", sent)
+	}
+	if sent["title"] != "Build Test" || sent["guid"] != nil {
+		t.Error("Extra field replaced a typed field:", sent["title"], sent["guid"])
+	}
+	if _, ok := sent["Extra"]; ok {
+		t.Error("Extra map encoded as a field")
+	}
+
+	data, _ := json.Marshal(PushMessage{Type: "note"})
+	var plain map[string]interface{}
+	json.Unmarshal(data, &plain)
+	if _, ok := plain["guid"]; ok {
+		t.Error("omitempty not honored without extras:", string(data))
+	}
+}
@@ -0,0 +1,239 @@
+//Package feeds polls RSS and Atom feeds and pushes each new item as a link, turning a client into a
+//channel publishing bot when combined with pushbullet.ToChannel.
+package feeds
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Item is a single feed entry.
+type Item struct {
+	GUID  string
+	Title string
+	Link  string
+}
+
+//Feed is a parsed RSS or Atom document.
+type Feed struct {
+	Title string
+	Items []Item
+}
+
+type rssItem struct {
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+	GUID  string `xml:"guid"`
+	About string `xml:"about,attr"`
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"` // RSS 1.0 (RDF) places items beside the channel
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+//Parse decodes an RSS 2.0, RSS 1.0, or Atom document. Items without a GUID are identified by their link.
+func Parse(r io.Reader) (Feed, error) {
+	var f Feed
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return f, err
+	}
+	var root struct{ XMLName xml.Name }
+	if err = xml.Unmarshal(data, &root); err != nil {
+		return f, err
+	}
+	switch root.XMLName.Local {
+	case "rss", "RDF":
+		var doc rssDoc
+		if err = xml.Unmarshal(data, &doc); err != nil {
+			return f, err
+		}
+		f.Title = strings.TrimSpace(doc.Channel.Title)
+		for _, it := range append(doc.Channel.Items, doc.Items...) {
+			item := Item{GUID: strings.TrimSpace(it.GUID), Title: strings.TrimSpace(it.Title), Link: strings.TrimSpace(it.Link)}
+			if len(item.GUID) == 0 {
+				item.GUID = it.About
+			}
+			f.Items = append(f.Items, item)
+		}
+	case "feed":
+		var doc atomDoc
+		if err = xml.Unmarshal(data, &doc); err != nil {
+			return f, err
+		}
+		f.Title = strings.TrimSpace(doc.Title)
+		for _, e := range doc.Entries {
+			item := Item{GUID: strings.TrimSpace(e.ID), Title: strings.TrimSpace(e.Title)}
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					item.Link = l.Href
+					break
+				}
+			}
+			f.Items = append(f.Items, item)
+		}
+	default:
+		return f, errors.New("feeds: unrecognized document element " + root.XMLName.Local)
+	}
+	for i := range f.Items {
+		if len(f.Items[i].GUID) == 0 {
+			f.Items[i].GUID = f.Items[i].Link
+		}
+	}
+	return f, nil
+}
+
+//Poller periodically fetches feeds and pushes items it has not seen before.
+type Poller struct {
+	Notifier   pushbullet.Notifier
+	Options    []pushbullet.NotifyOption // e.g. pushbullet.ToChannel("mychannel")
+	HTTPClient *http.Client
+	// PushExisting pushes the items present on the first fetch of a feed, which are otherwise only marked as seen.
+	PushExisting bool
+	// OnError, when set, is called with the error of each failed poll made by Run.
+	OnError func(error)
+
+	mu    sync.Mutex
+	feeds []string
+	seen  map[string]map[string]bool
+}
+
+//New returns a Poller which pushes new items through n.
+func New(n pushbullet.Notifier, opts ...pushbullet.NotifyOption) *Poller {
+	return &Poller{Notifier: n, Options: opts}
+}
+
+//Add registers a feed URL.
+func (p *Poller) Add(url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.feeds = append(p.feeds, url)
+}
+
+//Run polls every registered feed on each interval until the context is done. Failed polls are reported through
+//OnError and retried on the next interval.
+func (p *Poller) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid poll interval")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.Poll(ctx); err != nil && ctx.Err() == nil && p.OnError != nil {
+			p.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+//Poll fetches every feed once and pushes new items, returning the last error encountered.
+func (p *Poller) Poll(ctx context.Context) error {
+	p.mu.Lock()
+	feeds := append([]string(nil), p.feeds...)
+	p.mu.Unlock()
+
+	var lastErr error
+	for _, url := range feeds {
+		if err := p.poll(ctx, url); err != nil {
+			lastErr = fmt.Errorf("%s: %v", url, err)
+		}
+	}
+	return lastErr
+}
+
+func (p *Poller) poll(ctx context.Context, url string) error {
+	feed, err := p.fetch(ctx, url)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if p.seen == nil {
+		p.seen = make(map[string]map[string]bool)
+	}
+	seen, known := p.seen[url]
+	if !known {
+		seen = make(map[string]bool)
+		p.seen[url] = seen
+	}
+	var fresh []Item
+	current := make(map[string]bool, len(feed.Items))
+	for _, it := range feed.Items {
+		current[it.GUID] = true
+		if !seen[it.GUID] {
+			fresh = append(fresh, it)
+		}
+	}
+	// forget items which have dropped off the feed so the seen set stays bounded by the feed's length
+	for guid := range seen {
+		if !current[guid] {
+			delete(seen, guid)
+		}
+	}
+	p.mu.Unlock()
+
+	// feeds list newest first; push oldest first so devices show them in order
+	for i := len(fresh) - 1; i >= 0; i-- {
+		it := fresh[i]
+		if known || p.PushExisting {
+			opts := append([]pushbullet.NotifyOption{pushbullet.LinkURL(it.Link)}, p.Options...)
+			if err := p.Notifier.Notify(ctx, it.Title, feed.Title, opts...); err != nil {
+				return err
+			}
+		}
+		p.mu.Lock()
+		seen[it.GUID] = true
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+func (p *Poller) fetch(ctx context.Context, url string) (Feed, error) {
+	client := p.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return Feed{}, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return Feed{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return Feed{}, fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	return Parse(res.Body)
+}
@@ -0,0 +1,134 @@
+package feeds
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+const atomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example Feed</title>
+  <entry>
+    <title>Atom-Powered Robots Run Amok</title>
+    <link rel="alternate" href="http://example.org/2003/12/13/atom03"/>
+    <id>urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a</id>
+  </entry>
+</feed>`
+
+func rssFeed(items ...string) string {
+	var b strings.Builder
+	b.WriteString(`<rss version="2.0"><channel><title>Release notes</title>`)
+	for _, it := range items {
+		fmt.Fprintf(&b, `<item><title>%s</title><link>http://example.com/%s</link><guid>%s</guid></item>`, it, it, it)
+	}
+	b.WriteString(`</channel></rss>`)
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(atomFeed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Example Feed" || len(f.Items) != 1 || f.Items[0].Link != "http://example.org/2003/12/13/atom03" ||
+		f.Items[0].GUID != "urn:uuid:1225c695-cfb8-4ebb-aaaa-80da344efa6a" {
+		t.Errorf("Unexpected atom parse: %+v", f)
+	}
+	f, err = Parse(strings.NewReader(rssFeed("v2", "v1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Title != "Release notes" || len(f.Items) != 2 || f.Items[0].GUID != "v2" {
+		t.Errorf("Unexpected rss parse: %+v", f)
+	}
+	if _, err = Parse(strings.NewReader("<html></html>")); err == nil {
+		t.Error("Expected unknown document to fail")
+	}
+}
+
+func TestPollPushesOnlyNewItems(t *testing.T) {
+	body := rssFeed("v1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	rec := &pushbullet.RecordingNotifier{}
+	p := New(rec, pushbullet.ToChannel("releases"))
+	p.Add(server.URL)
+	ctx := context.Background()
+	if err := p.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Pushes()) != 0 {
+		t.Error("Existing items should only be marked seen")
+	}
+
+	body = rssFeed("v3", "v2", "v1")
+	if err := p.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Pushes()) != 2 || rec.Pushes()[0].Title != "v2" || rec.Pushes()[1].Title != "v3" {
+		t.Fatalf("Expected v2 then v3 to be pushed: %+v", rec.Pushes())
+	}
+	if rec.Pushes()[0].Type != "link" || rec.Pushes()[0].URL != "http://example.com/v2" || rec.Pushes()[0].ChannelTag != "releases" {
+		t.Errorf("Unexpected push: %+v", rec.Pushes()[0])
+	}
+	if err := p.Poll(ctx); err != nil || len(rec.Pushes()) != 2 {
+		t.Error("Items were pushed twice")
+	}
+}
+
+func TestPollForgetsDroppedItems(t *testing.T) {
+	body := rssFeed("v2", "v1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	rec := &pushbullet.RecordingNotifier{}
+	p := New(rec)
+	p.Add(server.URL)
+	ctx := context.Background()
+	p.Poll(ctx)
+	body = rssFeed("v4", "v3")
+	if err := p.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if seen := p.seen[server.URL]; len(seen) != 2 || !seen["v3"] || !seen["v4"] {
+		t.Error("Seen set not pruned to the latest fetch:", seen)
+	}
+}
+
+func TestRunRejectsInvalidInterval(t *testing.T) {
+	if err := New(&pushbullet.RecordingNotifier{}).Run(context.Background(), 0); err == nil {
+		t.Error("Expected a zero interval to be rejected")
+	}
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p := New(&pushbullet.RecordingNotifier{})
+	p.Add(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	p.OnError = func(err error) {
+		if !strings.Contains(err.Error(), "500") {
+			t.Error("Unexpected error:", err)
+		}
+		cancel()
+	}
+	if err := p.Run(ctx, time.Hour); err != context.Canceled {
+		t.Error("Expected Run to stop once the error was reported, got:", err)
+	}
+}
@@ -0,0 +1,102 @@
+package pushbullet
+
+import (
+	"strings"
+	"time"
+)
+
+//summaryLength is the number of characters of a title or body kept in a summary
+const summaryLength = 40
+
+//String summarizes the push on a single line for logs and command line output, for example
+//"note 'Deploy done' → device ujpah72o0, 2024-05-01 12:31". It includes no keys, tokens or file URLs.
+func (p PushMessage) String() string {
+	text := p.Title
+	if len(text) == 0 {
+		text = p.Body
+	}
+	if len(text) == 0 {
+		text = p.FileName
+	}
+	s := p.Type
+	if len(s) == 0 {
+		s = "push"
+	}
+	if len(text) > 0 {
+		s += " '" + summarize(text) + "'"
+	}
+	switch {
+	case len(p.DeviceID) > 0:
+		s += " → device " + p.DeviceID
+	case len(p.Email) > 0:
+		s += " → email " + p.Email
+	case len(p.ChannelTag) > 0:
+		s += " → channel " + p.ChannelTag
+	case len(p.ClientID) > 0:
+		s += " → client " + p.ClientID
+	case len(p.TargetDeviceID) > 0:
+		s += " → device " + p.TargetDeviceID
+	}
+	return s + stateSuffix(p.Created, p.Modified, p.Active)
+}
+
+//String summarizes the device on a single line, for example "device 'Pixel' (Google Pixel 7) ujpah72o0". The push
+//token and key fingerprint are left out.
+func (d Device) String() string {
+	s := "device '" + summarize(d.Nickname) + "'"
+	if model := strings.TrimSpace(d.Manufacturer + " " + d.Model); len(model) > 0 {
+		s += " (" + model + ")"
+	}
+	if len(d.ID) > 0 {
+		s += " " + d.ID
+	}
+	return s + stateSuffix(0, d.Modified, d.Active)
+}
+
+//String summarizes the chat on a single line, for example "chat with 'Ann' <ann@example.com> ujx, muted".
+func (c Chat) String() string {
+	s := "chat with"
+	if len(c.With.Name) > 0 {
+		s += " '" + summarize(c.With.Name) + "'"
+	}
+	if len(c.With.Email) > 0 {
+		s += " <" + c.With.Email + ">"
+	}
+	if len(c.ID) > 0 {
+		s += " " + c.ID
+	}
+	if c.Muted {
+		s += ", muted"
+	}
+	return s + stateSuffix(0, c.Modified, c.Active)
+}
+
+//String summarizes the subscription on a single line, for example "subscription to 'Deploys' #deploys ujx".
+func (s Subscription) String() string {
+	str := "subscription to '" + summarize(s.Channel.Name) + "' #" + s.Channel.Tag
+	if len(s.ID) > 0 {
+		str += " " + s.ID
+	}
+	return str + stateSuffix(0, s.Modified, s.Active)
+}
+
+//summarize collapses whitespace to keep text on one line, shortening it to summaryLength characters
+func summarize(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > summaryLength {
+		text = string(r[:summaryLength-1]) + "…"
+	}
+	return text
+}
+
+//stateSuffix adds the creation time when known, and marks objects returned by the API as deleted once inactive
+func stateSuffix(created, modified float64, active bool) string {
+	s := ""
+	if created > 0 {
+		s += ", " + time.Unix(0, int64(created*1e9)).UTC().Format("2006-01-02 15:04")
+	}
+	if modified > 0 && !active {
+		s += ", deleted"
+	}
+	return s
+}
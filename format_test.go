@@ -0,0 +1,34 @@
+package pushbullet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStringSummaries(t *testing.T) {
+	cases := []struct {
+		v    fmt.Stringer
+		want string
+	}{
+		{PushMessage{Type: "note", Title: "Deploy done", DeviceID: "ujpah72o0", Created: 1714566660, Modified: 1714566660, Active: true},
+			"note 'Deploy done' → device ujpah72o0, 2024-05-01 12:31"},
+		{PushMessage{Type: "note", Body: "line one\n  line two", Email: "ann@example.com"},
+			"note 'line one line two' → email ann@example.com"},
+		{PushMessage{Type: "file", FileName: "cat.jpg", FileURL: "https://dl.pushbulletusercontent.com/secret/cat.jpg"},
+			"file 'cat.jpg'"},
+		{PushMessage{Type: "link", Title: strings.Repeat("a", 50), ChannelTag: "deploys", Modified: 1},
+			"link '" + strings.Repeat("a", 39) + "…' → channel deploys, deleted"},
+		{Device{ID: "ujpah72o0", Nickname: "Pixel", Manufacturer: "Google", Model: "Pixel 7", PushToken: "token", Active: true, Modified: 1},
+			"device 'Pixel' (Google Pixel 7) ujpah72o0"},
+		{Chat{ID: "ujx", Muted: true, Active: true, With: ChatUser{Name: "Ann", Email: "ann@example.com"}},
+			"chat with 'Ann' <ann@example.com> ujx, muted"},
+		{Subscription{ID: "ujy", Channel: Channel{Tag: "deploys", Name: "Deploys"}, Modified: 1},
+			"subscription to 'Deploys' #deploys ujy, deleted"},
+	}
+	for _, tc := range cases {
+		if got := tc.v.String(); got != tc.want {
+			t.Errorf("Expected %q, got %q", tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+
+package pushbullet
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fuzzSeeds are the response bodies every decoding target starts from, including the truncations proxies produce
+var fuzzSeeds = []string{
+	``,
+	`{}`,
+	`null`,
+	`[]`,
+	`{"pushes": [{"iden": "p1", "type": "note", "title": "hi", "body": "there", "created": 1.5, "modified": 2.5}], "cursor": "c"}`,
+	`{"pushes": [{"iden": "p1", "type": "file", "image_url": "https://example.com/i.jpg", "guid": "corr:incident:1"}]}`,
+	`{"pushes": [{"iden": "p1", "type": "list", "items": [{"checked": true, "text": "milk"}], "awake_app_guids": ["x"]}]}`,
+	`{"pushes": [{"iden": "p1", "type": "note", "body": "json:{\"a\":1}"}]}`,
+	`{"pushes": [{"iden": "p1", "type": "note", "title": "trunc`,
+	`{"pushes": [null, 1, "x", {}]}`,
+	`{"type": "push", "push": {"type": "mirror", "application_name": "App", "title": "t", "body": "b", "dismissible": true}}`,
+	`{"type": "push", "push": {"type": "dismissal", "notification_id": 5}}`,
+	`{"error": {"type": "invalid_request", "message": "Access token is missing or invalid.", "cat": "~(=^‥^)"}}`,
+	`{"error": {"message": 5}}`,
+	`{"error": "rate limited"}`,
+	`<html><body>502 Bad Gateway</body></html>`,
+}
+
+func FuzzDecodePushHistory(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	c := &Client{KeepRaw: true}
+	strict := &Client{StrictDecoding: true}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, client := range []*Client{c, strict} {
+			var l PushList
+			if err := client.decode(data, &l); err != nil {
+				if _, ok := err.(*DecodeError); !ok {
+					t.Fatalf("Expected a DecodeError, got %T", err)
+				}
+				continue
+			}
+			for _, p := range l.Pushes {
+				// everything derived from a decoded push must cope with whatever was decoded
+				_ = p.String()
+				_ = p.UnknownFields()
+				_ = p.CorrelationID()
+				_ = p.IsImage()
+				var v interface{}
+				_ = DecodePayload(p, &v)
+				if _, err := p.Request().MarshalJSON(); err != nil {
+					t.Fatal("Decoded push does not encode:", err)
+				}
+			}
+		}
+	})
+}
+
+func FuzzDecodeEphemeral(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	c := &Client{}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var e struct {
+			Type string             `json:"type"`
+			Push MirrorNotification `json:"push"`
+		}
+		if err := c.decode(data, &e); err != nil {
+			if _, ok := err.(*DecodeError); !ok {
+				t.Fatalf("Expected a DecodeError, got %T", err)
+			}
+		}
+	})
+}
+
+func FuzzParseError(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		e := parseError(data)
+		if e != nil && len(e.ErrorBody.Message) == 0 {
+			t.Fatal("Error without a message")
+		}
+		_ = e.String()
+		h := http.Header{"X-Ratelimit-Limit": {string(data)}, "Retry-After": {string(data)}}
+		_ = rateLimitError(h, e, time.Now()).Error()
+	})
+}
@@ -2,18 +2,20 @@ package pushbullet
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 )
 
 //Error (any non-200 error code) contain information on the kind of error that happened.
@@ -29,19 +31,26 @@ type (
 	}
 )
 
+//StatusError is returned for an unsuccessful response other than a rate limit, with any error message sent by
+//Pushbullet available from the accompanying *Error.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("Status code: %v", e.StatusCode)
+}
+
 func (e *Error) String() string {
-	var t string
-	if e.ErrorBody.Type == "invalid_request" {
-		t = "Invalid Request"
-	} else {
-		t = "Server Error"
+	if e == nil {
+		return ""
 	}
-	return fmt.Sprintf("%v: %v", t, e.ErrorBody.Message)
+	return fmt.Sprintf("%v: %v", ErrorCatalog[e.Kind()].Title, e.ErrorBody.Message)
 }
 
 //PushMessage describes a message to be sent via Pushbullet. Only one of the first 4 properties may be specified with a message being sent.
 type PushMessage struct {
-	ID string `json:"`
+	ID string `json:"iden"`
 
 	// Target specific properties
 	DeviceID   string `json:"device_iden"`
@@ -58,14 +67,19 @@ type PushMessage struct {
 	Address string   `json:"address"` // Address is used with address type
 	Items   []string `json:"items"`   // Items are used with checklist types
 	// The following are used with file types
-	FileName       string `json:"file_name"`
-	FileType       string `json:"file_type"` // MIME type of the file
-	FileURL        string `json:"file_url"`
+	FileName string `json:"file_name"`
+	FileType string `json:"file_type"` // MIME type of the file
+	FileURL  string `json:"file_url"`
+	// ImageURL, ImageWidth and ImageHeight are set by Pushbullet on file pushes of images
+	ImageURL       string `json:"image_url,omitempty"` // URL of a thumbnail of the image
+	ImageWidth     int    `json:"image_width,omitempty"`
+	ImageHeight    int    `json:"image_height,omitempty"`
 	SourceDeviceID string `json:"source_device_iden"`
+	GUID           string `json:"guid,omitempty"` // unique identifier set by the sender, used to deduplicate retried sends
 
 	// Properties for response messages
-	Created                 float32 `json:"created"`
-	Modified                float32 `json:"modified"`
+	Created                 float64 `json:"created"`
+	Modified                float64 `json:"modified"`
 	Active                  bool    `json:"active"`
 	Dismissed               bool    `json:"dismissed"`
 	SenderID                string  `json:"sender_iden"`
@@ -74,16 +88,27 @@ type PushMessage struct {
 	ReceiverID              string  `json:"receiver_iden"`
 	ReceiverEmail           string  `json:"receiver_email"`
 	ReceiverEmailNormalized string  `json:"receiver_email_normalized"`
+	SenderName              string  `json:"sender_name,omitempty"`
+	TargetDeviceID          string  `json:"target_device_iden,omitempty"`
+	ChannelID               string  `json:"channel_iden,omitempty"` // set on pushes sent to a channel
+	Direction               string  `json:"direction,omitempty"`    // self, outgoing, or incoming
+
+	// Extra holds additional fields to send, for push parameters the library does not know about yet. They never
+	// replace the typed fields above.
+	Extra map[string]interface{} `json:"-"`
+	// Raw is the JSON the push was decoded from when the client keeps it, see WithRawFields.
+	Raw json.RawMessage `json:"-"`
 }
 
 //PushList describes a list of push messages
 type PushList struct {
 	Pushes []PushMessage `json:"pushes"`
+	Cursor string        `json:"cursor,omitempty"`
 }
 
 //ItemsList describes a list of checklist items
 type ItemsList struct {
-	items []Item `json:"items"`
+	Items []Item `json:"items"`
 }
 
 //Item describes a checklist item
@@ -102,23 +127,33 @@ type Device struct {
 	Nickname     string  `json:"nickname"`
 	Manufacturer string  `json:"manufacturer"`
 	Type         string  `json:"type"`
-	Created      float32 `json:"created"`
-	Modified     float32 `json:"modified"`
+	Created      float64 `json:"created"`
+	Modified     float64 `json:"modified"`
 	Model        string  `json:"model"`
 	Pushable     bool    `json:"pushable"`
+	Kind         string  `json:"kind"`
+	Icon         string  `json:"icon"`
+	HasSMS       bool    `json:"has_sms"`
+	// GeneratedNickname is true when the nickname was chosen by Pushbullet rather than the user.
+	GeneratedNickname bool   `json:"generated_nickname"`
+	KeyFingerprint    string `json:"key_fingerprint"`
+	RemoteFiles       string `json:"remote_files"`
+	// Raw is the JSON the device was decoded from when the client keeps it, see WithRawFields.
+	Raw json.RawMessage `json:"-"`
 }
 
 //DeviceList describes an array of devices
 type DeviceList struct {
 	Devices []Device `json:"devices"`
+	Cursor  string   `json:"cursor,omitempty"`
 }
 
 //Contact describes a contact entry.
 type Contact struct {
 	ID              string  `json:"iden"`
 	Name            string  `json:"name"`
-	Created         float32 `json:"created"`
-	Modified        float32 `json:"modified"`
+	Created         float64 `json:"created"`
+	Modified        float64 `json:"modified"`
 	Email           string  `json:"email"`
 	EmailNormalized string  `json:"email_normalized"`
 	Active          bool    `json:"active"`
@@ -127,13 +162,40 @@ type Contact struct {
 //ContactList describes an array of contacts
 type ContactList struct {
 	Contacts []Contact `json:"contacts"`
+	Cursor   string    `json:"cursor,omitempty"`
+}
+
+//Chat describes a conversation with another user.
+type Chat struct {
+	ID       string   `json:"iden"`
+	Active   bool     `json:"active"`
+	Created  float64  `json:"created"`
+	Modified float64  `json:"modified"`
+	Muted    bool     `json:"muted"`
+	With     ChatUser `json:"with"`
+}
+
+//ChatUser describes the other party of a chat.
+type ChatUser struct {
+	ID              string `json:"iden"`
+	Type            string `json:"type"` // user or email
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	EmailNormalized string `json:"email_normalized"`
+	ImageURL        string `json:"image_url"`
+}
+
+//ChatList describes an array of chats
+type ChatList struct {
+	Chats  []Chat `json:"chats"`
+	Cursor string `json:"cursor,omitempty"`
 }
 
 //Subscription describes a channel subscription.
 type Subscription struct {
 	ID       string  `json:"iden"`
-	Created  float32 `json:"created"`
-	Modified float32 `json:"modified"`
+	Created  float64 `json:"created"`
+	Modified float64 `json:"modified"`
 	Active   bool    `json:"active"`
 	Channel  Channel `json:"channel"`
 }
@@ -141,6 +203,7 @@ type Subscription struct {
 //SubscriptionList describes a list of subscribed channels
 type SubscriptionList struct {
 	Subscriptions []Subscription `json:"subscriptions"`
+	Cursor        string         `json:"cursor,omitempty"`
 }
 
 //Channel describes a channel on a subscription.
@@ -150,6 +213,10 @@ type Channel struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	ImageURL    string `json:"image_url"`
+	WebsiteURL  string `json:"website_url,omitempty"`
+	// The following are only returned by ChannelInfo
+	SubscriberCount int           `json:"subscriber_count,omitempty"`
+	RecentPushes    []PushMessage `json:"recent_pushes,omitempty"`
 }
 
 //User describes the authenticated user.
@@ -157,11 +224,15 @@ type User struct {
 	ID              string      `json:"iden"`
 	Email           string      `json:"email"`
 	EmailNormalized string      `json:"email_normalized"`
-	Created         float32     `json:"created"`
-	Modified        float32     `json:"modified"`
+	Created         float64     `json:"created"`
+	Modified        float64     `json:"modified"`
 	Name            string      `json:"name"`
 	ImageURL        string      `json:"image_url"`
 	Preferences     Preferences `json:"preferences"`
+	Active          bool        `json:"active"`
+	MaxUploadSize   int64       `json:"max_upload_size"` // in bytes
+	// Raw is the JSON the user was decoded from when the client keeps it, see WithRawFields.
+	Raw json.RawMessage `json:"-"`
 }
 
 //Preferences describes a set of user preferences.
@@ -191,49 +262,163 @@ type Authorization struct {
 	} `json:"data"`
 }
 
-//Client a Pushbullet API client
+//TokenSource supplies the API key used to authenticate requests, allowing keys to be kept out of plaintext configuration.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+//StaticToken is a TokenSource which always returns the same API key.
+type StaticToken string
+
+//Token returns the API key.
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+//Client a Pushbullet API client. A configured Client is safe for concurrent use by multiple goroutines; its exported
+//fields must not be changed while calls are in flight.
 type Client struct {
-	APIKey     string
-	BaseURL    string
-	HTTPClient *http.Client
+	APIKey      string
+	BaseURL     string // versioned API root which routes are relative to, see WithAPI
+	HTTPClient  *http.Client
+	TokenSource TokenSource         // consulted for the API key when APIKey is empty
+	BodyFilter  func(string) string // applied to the body of every outgoing push
+	// LinkNormalizer rewrites the URL of every outgoing link push, see WithLinkNormalization.
+	LinkNormalizer func(string) (string, error)
+	// StrictDecoding rejects responses containing fields the library does not know about.
+	StrictDecoding bool
+	// KeepRaw fills the Raw field of decoded pushes, devices and users, see WithRawFields.
+	KeepRaw bool
+	// VerifyEcho compares each created push with what was sent, see WithEchoVerification.
+	VerifyEcho bool
+	// Codec encodes requests and decodes responses, the standard library when nil.
+	Codec    Codec
+	LogLevel LogLevel    // LogSilent unless set
+	Logger   *log.Logger // destination for log output, the standard logger when nil
+	// StrictDeprecations fails calls to deprecated push types and endpoints, see DeprecationWarning.
+	StrictDeprecations bool
+	OnDeprecation      func(*DeprecationWarning) // receives deprecation warnings, which are logged when nil
+	// Backoff spaces out retries of requests which failed temporarily, see WithBackoff. Requests are not retried when nil.
+	Backoff Backoff
+	// HedgeAfter sends a second copy of a push still running after this long, see WithHedging. Zero disables hedging.
+	HedgeAfter time.Duration
+	// TLSConfig is the TLS configuration installed in HTTPClient by WithTLSConfig, WithMinTLSVersion and
+	// WithPinnedKeys, also used for stream connections.
+	TLSConfig *tls.Config
+	// Translator localizes the messages returned by Describe, see WithTranslator.
+	Translator Translator
+	// StreamURL is the realtime event stream used by Listen, DefaultStreamURL when empty.
+	StreamURL string
+	// StreamOptions configure the stream connection shared by Subscribe, see WithStreamOptions.
+	StreamOptions []ListenOption
+	// OnRequest receives an event for every API request, tagged with the annotations of its context, see
+	// WithRequestHook.
+	OnRequest func(RequestEvent)
+
+	devices  deviceCache
+	lastKey  keyMemo
+	counters *expvar.Map
+	latency  latencyStats
+	user     userCache
+	hub      streamHub
 }
 
+//Option configures a Client at construction.
+type Option func(*Client)
+
 //ClientWithKey returns a pushbullet.Client pointer with API key.
-func ClientWithKey(key string) *Client {
-	return &Client{
+func ClientWithKey(key string, opts ...Option) *Client {
+	c := &Client{
 		APIKey:     key,
-		BaseURL:    "https://api.pushbullet.com/v2/",
+		BaseURL:    DefaultAPIRoot + DefaultAPIVersion + "/",
 		HTTPClient: &http.Client{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+//ClientWithTokenSource returns a pushbullet.Client pointer which obtains its API key from the provided source on each call.
+func ClientWithTokenSource(src TokenSource, opts ...Option) *Client {
+	c := ClientWithKey("", opts...)
+	c.TokenSource = src
+	return c
+}
+
+//WithBodyFilter sets a filter applied to the body of every outgoing push.
+func WithBodyFilter(filter func(string) string) Option {
+	return func(c *Client) {
+		c.BodyFilter = filter
+	}
 }
 
 //GetUser gets the current authenticate users details.
 func (c *Client) GetUser() (u User, err error) {
-	r, apiError, err := c.makeCall("GET", "users/me", nil)
+	return c.GetUserContext(context.Background())
+}
+
+//GetUserContext is GetUser bound to a context which may cancel its requests
+func (c *Client) GetUserContext(ctx context.Context) (u User, err error) {
+	r, apiError, err := c.makeCallContext(ctx, "GET", routeUser, nil)
 	if err != nil {
-		log.Println("Failed to get user:", err, apiError.String())
+		c.warn("Failed to get user:", err, apiError.String())
 		return u, err
 	}
-	err = json.Unmarshal(r, &u)
+	err = c.decode(r, &u)
 	if err != nil {
 		return u, err
 	}
 	return u, nil
 }
 
-//SendNote simply sends a note type push to all of the users devices
-func (c *Client) SendNote(title, body string) error {
-	err := c.SendNoteToTarget("all", "", title, body)
-	return err
+//SendPush sends a fully composed push and returns the push as created by Pushbullet. A PushMessage read back from
+//Pushbullet is resent with its Request.
+func (c *Client) SendPush(r PushRequest) (PushMessage, error) {
+	return c.SendPushContext(context.Background(), r)
 }
 
-//SendNoteToTarget sends a note type push to a specific device.
-func (c *Client) SendNoteToTarget(targetType, target, title, body string) error {
-	var p = PushMessage{
-		Type:  "note",
-		Title: title,
-		Body:  body,
+//SendPushContext is SendPush bound to a context which may cancel its requests
+func (c *Client) SendPushContext(ctx context.Context, r PushRequest) (PushMessage, error) {
+	return c.sendPush(ctx, r.Message())
+}
+
+//sendPush sends the request fields of the push, for the helpers composing a PushMessage
+func (c *Client) sendPush(ctx context.Context, p PushMessage) (PushMessage, error) {
+	var created PushMessage
+	if err := c.deprecatedPushType(p); err != nil {
+		return created, err
+	}
+	p = c.preparePush(p)
+	if c.LinkNormalizer != nil && p.Type == "link" {
+		normalized, err := c.LinkNormalizer(p.URL)
+		if err != nil {
+			return created, ValidationErrors{{Field: "url", Message: err.Error()}}
+		}
+		p.URL = normalized
 	}
+	if err := p.Validate(); err != nil {
+		return created, err
+	}
+	call := c.makeCallContext
+	if c.HedgeAfter > 0 {
+		call = c.hedgedCall
+	}
+	responseBody, apiError, err := call(ctx, "POST", routePushes, p.Request())
+	if err != nil {
+		c.warn("Failed to send "+p.Type+":", err, apiError.String())
+		return created, err
+	}
+	err = c.decode(responseBody, &created)
+	// an empty body echoes nothing to compare; the push was created all the same
+	if err == nil && c.VerifyEcho && len(bytes.TrimSpace(responseBody)) > 0 {
+		err = verifyEcho(p, created)
+	}
+	return created, err
+}
+
+//sendToTarget addresses the push to the target and sends it
+func (c *Client) sendToTarget(ctx context.Context, targetType, target string, p PushMessage) error {
 	switch targetType {
 	case "device":
 		p.DeviceID = target
@@ -250,135 +435,157 @@ func (c *Client) SendNoteToTarget(targetType, target, title, body string) error
 		}
 	}
 
-	_, apiError, err := c.makeCall("POST", "pushes", p)
-	if err != nil {
-		log.Println("Failed to send note:", err, apiError.String())
-		return err
+	_, err := c.sendPush(ctx, p)
+	return err
+}
+
+//preparePush applies the client's configured processing to an outgoing push
+func (c *Client) preparePush(p PushMessage) PushMessage {
+	if c.BodyFilter != nil {
+		p.Body = c.BodyFilter(p.Body)
 	}
-	return nil
+	if email, err := NormalizeEmail(p.Email); err == nil {
+		p.Email = email
+	}
+	if (c.Backoff != nil || c.HedgeAfter > 0) && len(p.GUID) == 0 {
+		p.GUID = newGUID()
+	}
+	return p
+}
+
+//SendNote simply sends a note type push to all of the users devices
+func (c *Client) SendNote(title, body string) error {
+	return c.SendNoteContext(context.Background(), title, body)
+}
+
+//SendNoteContext is SendNote bound to a context which may cancel its requests
+func (c *Client) SendNoteContext(ctx context.Context, title, body string) error {
+	err := c.SendNoteToTargetContext(ctx, "all", "", title, body)
+	return err
+}
+
+//SendNoteToTarget sends a note type push to a specific device.
+func (c *Client) SendNoteToTarget(targetType, target, title, body string) error {
+	return c.SendNoteToTargetContext(context.Background(), targetType, target, title, body)
+}
+
+//SendNoteToTargetContext is SendNoteToTarget bound to a context which may cancel its requests
+func (c *Client) SendNoteToTargetContext(ctx context.Context, targetType, target, title, body string) error {
+	var p = PushMessage{
+		Type:  "note",
+		Title: title,
+		Body:  body,
+	}
+	return c.sendToTarget(ctx, targetType, target, p)
 }
 
 //SendLink simply sends a link type push to all of the users devices
 func (c *Client) SendLink(title, body, url string) error {
-	err := c.SendLinkToTarget("all", "", title, body, url)
+	return c.SendLinkContext(context.Background(), title, body, url)
+}
+
+//SendLinkContext is SendLink bound to a context which may cancel its requests
+func (c *Client) SendLinkContext(ctx context.Context, title, body, url string) error {
+	err := c.SendLinkToTargetContext(ctx, "all", "", title, body, url)
 	return err
 }
 
 //SendLinkToTarget sends a link type push to a specific device.
 func (c *Client) SendLinkToTarget(targetType, target, title, body, url string) error {
+	return c.SendLinkToTargetContext(context.Background(), targetType, target, title, body, url)
+}
+
+//SendLinkToTargetContext is SendLinkToTarget bound to a context which may cancel its requests
+func (c *Client) SendLinkToTargetContext(ctx context.Context, targetType, target, title, body, url string) error {
 	var p = PushMessage{
 		Type:  "link",
 		Title: title,
 		Body:  body,
 		URL:   url,
 	}
-	switch targetType {
-	case "device":
-		p.DeviceID = target
-	case "email":
-		p.Email = target
-	case "channel":
-		p.ChannelTag = target
-	case "client":
-		p.ClientID = target
-	default:
-		// only remaining acceptable type is "all" which takes no additional fields
-		if targetType != "all" {
-			return errors.New("Invalid target type")
-		}
-	}
-
-	_, apiError, err := c.makeCall("POST", "pushes", p)
-	if err != nil {
-		log.Println("Failed to get user:", err, apiError.String())
-		return err
-	}
-	return nil
+	return c.sendToTarget(ctx, targetType, target, p)
 }
 
-//SendAddress simply sends an address type push to all of the users devices
+//SendAddress simply sends an address to all of the users devices
 func (c *Client) SendAddress(title, name, address string) error {
-	err := c.SendLinkToTarget("all", "", title, name, address)
+	return c.SendAddressContext(context.Background(), title, name, address)
+}
+
+//SendAddressContext is SendAddress bound to a context which may cancel its requests
+func (c *Client) SendAddressContext(ctx context.Context, title, name, address string) error {
+	err := c.SendAddressToTargetContext(ctx, "all", "", title, name, address)
 	return err
 }
 
-//SendAddressToTarget sends an address type push to a specific device.
+//SendAddressToTarget sends an address to a specific device. Pushbullet no longer supports address pushes, so the
+//address is sent as a link push opening a map search, with the place name and address as its body.
 func (c *Client) SendAddressToTarget(targetType, target, title, name, address string) error {
-	var p = PushMessage{
-		Type:    "address",
-		Title:   title,
-		Name:    name,
-		Address: address,
-	}
-	switch targetType {
-	case "device":
-		p.DeviceID = target
-	case "email":
-		p.Email = target
-	case "channel":
-		p.ChannelTag = target
-	case "client":
-		p.ClientID = target
-	default:
-		// only remaining acceptable type is "all" which takes no additional fields
-		if targetType != "all" {
-			return errors.New("Invalid target type")
-		}
-	}
+	return c.SendAddressToTargetContext(context.Background(), targetType, target, title, name, address)
+}
 
-	_, apiError, err := c.makeCall("POST", "pushes", p)
-	if err != nil {
-		log.Println("Failed to send address:", err, apiError.String())
-		return err
+//SendAddressToTargetContext is SendAddressToTarget bound to a context which may cancel its requests
+func (c *Client) SendAddressToTargetContext(ctx context.Context, targetType, target, title, name, address string) error {
+	body := address
+	if len(name) > 0 {
+		body = name + "\n" + address
 	}
-	return nil
+	var p = PushMessage{
+		Type:  "link",
+		Title: title,
+		Body:  body,
+		URL:   mapsURL(address),
+	}
+	return c.sendToTarget(ctx, targetType, target, p)
 }
 
 //SendChecklist simply sends a checklist type push to all of the users devices
 func (c *Client) SendChecklist(title string, items []string) error {
-	err := c.SendChecklistToTarget("all", "", title, items)
+	return c.SendChecklistContext(context.Background(), title, items)
+}
+
+//SendChecklistContext is SendChecklist bound to a context which may cancel its requests
+func (c *Client) SendChecklistContext(ctx context.Context, title string, items []string) error {
+	err := c.SendChecklistToTargetContext(ctx, "all", "", title, items)
 	return err
 }
 
 //SendChecklistToTarget sends a checklist type push to a specific device.
 func (c *Client) SendChecklistToTarget(targetType, target, title string, items []string) error {
+	return c.SendChecklistToTargetContext(context.Background(), targetType, target, title, items)
+}
+
+//SendChecklistToTargetContext is SendChecklistToTarget bound to a context which may cancel its requests
+func (c *Client) SendChecklistToTargetContext(ctx context.Context, targetType, target, title string, items []string) error {
 	var p = PushMessage{
 		Type:  "checklist",
 		Title: title,
 		Items: items,
 	}
-	switch targetType {
-	case "device":
-		p.DeviceID = target
-	case "email":
-		p.Email = target
-	case "channel":
-		p.ChannelTag = target
-	case "client":
-		p.ClientID = target
-	default:
-		// only remaining acceptable type is "all" which takes no additional fields
-		if targetType != "all" {
-			return errors.New("Invalid target type")
-		}
-	}
-
-	_, apiError, err := c.makeCall("POST", "pushes", p)
-	if err != nil {
-		log.Println("Failed to send checklist:", err, apiError.String())
-		return err
-	}
-	return nil
+	return c.sendToTarget(ctx, targetType, target, p)
 }
 
-//SendFile simply sends a file type push to all of the users devices
+//SendFile sends a file type push named title to all of the users devices.
+//
+//Deprecated: a file push needs the URL and type of an uploaded file, which SendFile cannot be given, so the push
+//fails validation. Use SendFileToTarget with the "all" target type, or SendTempFile to upload and push a file.
 func (c *Client) SendFile(title string, items []string) error {
-	err := c.SendChecklistToTarget("all", "", title, items)
-	return err
+	return c.SendFileContext(context.Background(), title, items)
+}
+
+//SendFileContext is SendFile bound to a context which may cancel its requests
+//
+//Deprecated: use SendFileToTargetContext with the "all" target type.
+func (c *Client) SendFileContext(ctx context.Context, title string, items []string) error {
+	return c.SendFileToTargetContext(ctx, "all", "", title, "", "", "", items)
 }
 
 //SendFileToTarget sends a file type push to a specific device.
 func (c *Client) SendFileToTarget(targetType, target, fileName, fileType, fileURL, body string, items []string) error {
+	return c.SendFileToTargetContext(context.Background(), targetType, target, fileName, fileType, fileURL, body, items)
+}
+
+//SendFileToTargetContext is SendFileToTarget bound to a context which may cancel its requests
+func (c *Client) SendFileToTargetContext(ctx context.Context, targetType, target, fileName, fileType, fileURL, body string, items []string) error {
 	var p = PushMessage{
 		Type:     "file",
 		FileName: fileName,
@@ -386,39 +593,23 @@ func (c *Client) SendFileToTarget(targetType, target, fileName, fileType, fileUR
 		FileURL:  fileURL,
 		Body:     body,
 	}
-	switch targetType {
-	case "device":
-		p.DeviceID = target
-	case "email":
-		p.Email = target
-	case "channel":
-		p.ChannelTag = target
-	case "client":
-		p.ClientID = target
-	default:
-		// only remaining acceptable type is "all" which takes no additional fields
-		if targetType != "all" {
-			return errors.New("Invalid target type")
-		}
-	}
-
-	_, apiError, err := c.makeCall("POST", "pushes", p)
-	if err != nil {
-		log.Println("Failed to send file: ", err, apiError.String())
-		return err
-	}
-	return nil
+	return c.sendToTarget(ctx, targetType, target, p)
 }
 
 //GetDevices obtains a list of registered devices from Pushbullet
-func (c *Client) GetDevices() (DeviceList, error) {
+func (c *Client) GetDevices(opts ...ListOption) (DeviceList, error) {
+	return c.GetDevicesContext(context.Background(), opts...)
+}
+
+//GetDevicesContext is GetDevices bound to a context which may cancel its requests
+func (c *Client) GetDevicesContext(ctx context.Context, opts ...ListOption) (DeviceList, error) {
 	var d DeviceList
-	res, apiError, err := c.makeCall("GET", "devices", nil)
+	res, apiError, err := c.makeCallContext(ctx, "GET", listCall(routeDevices, nil, opts), nil)
 	if err != nil {
-		log.Println("Failed to get devices: ", err, apiError.String())
+		c.warn("Failed to get devices:", err, apiError.String())
 		return d, err
 	}
-	err = json.Unmarshal(res, &d)
+	err = c.decode(res, &d)
 	if err != nil {
 		return d, err
 	}
@@ -426,27 +617,77 @@ func (c *Client) GetDevices() (DeviceList, error) {
 }
 
 //GetContacts obtains a list of your contacts
-func (c *Client) GetContacts() (ContactList, error) {
+func (c *Client) GetContacts(opts ...ListOption) (ContactList, error) {
+	return c.GetContactsContext(context.Background(), opts...)
+}
+
+//GetContactsContext is GetContacts bound to a context which may cancel its requests
+func (c *Client) GetContactsContext(ctx context.Context, opts ...ListOption) (ContactList, error) {
 	var l ContactList
-	res, apiError, err := c.makeCall("GET", "contacts", nil)
+	if err := c.deprecatedContacts(); err != nil {
+		return l, err
+	}
+	res, apiError, err := c.makeCallContext(ctx, "GET", listCall(routeContacts, nil, opts), nil)
 	if err != nil {
-		log.Println("Failed to get contacts: ", err, apiError.String())
+		c.warn("Failed to get contacts:", err, apiError.String())
 		return l, err
 	}
-	err = json.Unmarshal(res, &l)
+	err = c.decode(res, &l)
 	if err != nil {
 		return l, err
 	}
 	return l, err
 }
 
+//GetChats obtains a list of your chats
+func (c *Client) GetChats(opts ...ListOption) (ChatList, error) {
+	return c.GetChatsContext(context.Background(), opts...)
+}
+
+//GetChatsContext is GetChats bound to a context which may cancel its requests
+func (c *Client) GetChatsContext(ctx context.Context, opts ...ListOption) (ChatList, error) {
+	var l ChatList
+	res, apiError, err := c.makeCallContext(ctx, "GET", listCall(routeChats, nil, opts), nil)
+	if err != nil {
+		c.warn("Failed to get chats:", err, apiError.String())
+		return l, err
+	}
+	err = c.decode(res, &l)
+	return l, err
+}
+
+//CreateChat starts a chat with the user or email address given, normalized with NormalizeEmail
+func (c *Client) CreateChat(email string) (Chat, error) {
+	return c.CreateChatContext(context.Background(), email)
+}
+
+//CreateChatContext is CreateChat bound to a context which may cancel its requests
+func (c *Client) CreateChatContext(ctx context.Context, email string) (chat Chat, err error) {
+	if email, err = NormalizeEmail(email); err != nil {
+		return
+	}
+	res, apiError, err := c.makeCallContext(ctx, "POST", routeChats, map[string]string{"email": email})
+	if err != nil {
+		c.warn("Failed to create chat:", err, apiError.String())
+		return
+	}
+	err = c.decode(res, &chat)
+	return
+}
+
 //CreateContact creates a new contact with the specified name and email
 func (c *Client) CreateContact(name, email string) error {
-	u := url.Values{}
-	u.Add("name", name)
-	u.Add("email", email)
-	_, err := c.HTTPClient.PostForm(c.BaseURL+"contacts", u)
+	return c.CreateContactContext(context.Background(), name, email)
+}
+
+//CreateContactContext is CreateContact bound to a context which may cancel its requests
+func (c *Client) CreateContactContext(ctx context.Context, name, email string) error {
+	if err := c.deprecatedContacts(); err != nil {
+		return err
+	}
+	_, apiError, err := c.makeCallContext(ctx, "POST", routeContacts, map[string]string{"name": name, "email": email})
 	if err != nil {
+		c.warn("Failed to create contact:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -454,10 +695,17 @@ func (c *Client) CreateContact(name, email string) error {
 
 //UpdateContact creates a new contact with the specified name and email
 func (c *Client) UpdateContact(contactID, name string) error {
-	u := url.Values{}
-	u.Add("name", name)
-	_, err := c.HTTPClient.PostForm(c.BaseURL+"contacts/"+contactID, u)
+	return c.UpdateContactContext(context.Background(), contactID, name)
+}
+
+//UpdateContactContext is UpdateContact bound to a context which may cancel its requests
+func (c *Client) UpdateContactContext(ctx context.Context, contactID, name string) error {
+	if err := c.deprecatedContacts(); err != nil {
+		return err
+	}
+	_, apiError, err := c.makeCallContext(ctx, "POST", contactRoute(contactID), map[string]string{"name": name})
 	if err != nil {
+		c.warn("Failed to update contact:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -465,9 +713,17 @@ func (c *Client) UpdateContact(contactID, name string) error {
 
 //DeleteContact creates a new contact with the specified name and email
 func (c *Client) DeleteContact(contactID string) error {
-	_, apiError, err := c.makeCall("DELETE", "contacts/"+contactID, nil)
+	return c.DeleteContactContext(context.Background(), contactID)
+}
+
+//DeleteContactContext is DeleteContact bound to a context which may cancel its requests
+func (c *Client) DeleteContactContext(ctx context.Context, contactID string) error {
+	if err := c.deprecatedContacts(); err != nil {
+		return err
+	}
+	_, apiError, err := c.makeCallContext(ctx, "DELETE", contactRoute(contactID), nil)
 	if err != nil {
-		log.Println("Failed to delete contact: ", err, apiError.String())
+		c.warn("Failed to delete contact:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -475,22 +731,35 @@ func (c *Client) DeleteContact(contactID string) error {
 
 //SubscribeChannel subscribes use to a specified channel
 func (c *Client) SubscribeChannel(channel string) error {
-	_, apiError, err := c.makeCall("POST", "subscriptions", nil)
+	return c.SubscribeChannelContext(context.Background(), channel)
+}
+
+//SubscribeChannelContext is SubscribeChannel bound to a context which may cancel its requests
+func (c *Client) SubscribeChannelContext(ctx context.Context, channel string) error {
+	if err := ValidateChannelTag(channel); err != nil {
+		return err
+	}
+	_, apiError, err := c.makeCallContext(ctx, "POST", routeSubscriptions, map[string]string{"channel_tag": channel})
 	if err != nil {
-		log.Println("Failed to add subscription: ", err, apiError.String())
+		c.warn("Failed to add subscription:", err, apiError.String())
 		return err
 	}
 	return nil
 }
 
 //ListSubscriptions returns a list of channels to which the user is subscribed
-func (c *Client) ListSubscriptions() (subscriptions SubscriptionList, err error) {
-	responseBody, apiError, err := c.makeCall("GET", "subscriptions", nil)
+func (c *Client) ListSubscriptions(opts ...ListOption) (subscriptions SubscriptionList, err error) {
+	return c.ListSubscriptionsContext(context.Background(), opts...)
+}
+
+//ListSubscriptionsContext is ListSubscriptions bound to a context which may cancel its requests
+func (c *Client) ListSubscriptionsContext(ctx context.Context, opts ...ListOption) (subscriptions SubscriptionList, err error) {
+	responseBody, apiError, err := c.makeCallContext(ctx, "GET", listCall(routeSubscriptions, nil, opts), nil)
 	if err != nil {
-		log.Println("Failed to add subscription: ", err, apiError.String())
+		c.warn("Failed to list subscriptions:", err, apiError.String())
 		return
 	}
-	err = json.Unmarshal(responseBody, &subscriptions)
+	err = c.decode(responseBody, &subscriptions)
 	if err != nil {
 		return
 	}
@@ -499,9 +768,14 @@ func (c *Client) ListSubscriptions() (subscriptions SubscriptionList, err error)
 
 //UnsubscribeChannel unsubscribes from the specified channel
 func (c *Client) UnsubscribeChannel(channelID string) error {
-	_, apiError, err := c.makeCall("DELETE", "subscriptions/"+channelID, nil)
+	return c.UnsubscribeChannelContext(context.Background(), channelID)
+}
+
+//UnsubscribeChannelContext is UnsubscribeChannel bound to a context which may cancel its requests
+func (c *Client) UnsubscribeChannelContext(ctx context.Context, channelID string) error {
+	_, apiError, err := c.makeCallContext(ctx, "DELETE", subscriptionRoute(channelID), nil)
 	if err != nil {
-		log.Println("Failed to unsubscribe channel: ", err, apiError.String())
+		c.warn("Failed to unsubscribe channel:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -509,31 +783,37 @@ func (c *Client) UnsubscribeChannel(channelID string) error {
 
 //ChannelInfo gets detained info for the requested channel
 func (c *Client) ChannelInfo(channelTag string) (channel Channel, err error) {
-	response, apiError, err := c.makeCall("GET", "channel-info?tag="+channelTag, nil)
+	return c.ChannelInfoContext(context.Background(), channelTag)
+}
+
+//ChannelInfoContext is ChannelInfo bound to a context which may cancel its requests
+func (c *Client) ChannelInfoContext(ctx context.Context, channelTag string) (channel Channel, err error) {
+	if err = ValidateChannelTag(channelTag); err != nil {
+		return
+	}
+	response, apiError, err := c.makeCallContext(ctx, "GET", query(routeChannelInfo, url.Values{"tag": {channelTag}}), nil)
 	if err != nil {
-		log.Println("Failed to get channel info: ", err, apiError.String())
+		c.warn("Failed to get channel info:", err, apiError.String())
 		return
 	}
-	err = json.Unmarshal(response, &channel)
+	err = c.decode(response, &channel)
 	return
 }
 
 //AuthorizeUpload requests an authorization to upload a file
 func (c *Client) AuthorizeUpload(fileName, fileType string) (Authorization, error) {
+	return c.AuthorizeUploadContext(context.Background(), fileName, fileType)
+}
+
+//AuthorizeUploadContext is AuthorizeUpload bound to a context which may cancel its requests
+func (c *Client) AuthorizeUploadContext(ctx context.Context, fileName, fileType string) (Authorization, error) {
 	var auth Authorization
-	u := url.Values{}
-	u.Add("file_name", fileName)
-	u.Add("file_type", fileType)
-	response, err := c.HTTPClient.PostForm(c.BaseURL+"upload-request", u)
-	if err != nil {
-		return auth, err
-	}
-	// read the response
-	body, err := ioutil.ReadAll(response.Body)
+	body, apiError, err := c.makeCallContext(ctx, "POST", routeUploadRequest, map[string]string{"file_name": fileName, "file_type": fileType})
 	if err != nil {
+		c.warn("Failed to authorize upload:", err, apiError.String())
 		return auth, err
 	}
-	err = json.Unmarshal(body, &auth)
+	err = c.decode(body, &auth)
 	if err != nil {
 		return auth, err
 	}
@@ -542,23 +822,34 @@ func (c *Client) AuthorizeUpload(fileName, fileType string) (Authorization, erro
 
 //UpdatePreferences overwrites user preferences with specified ones
 func (c *Client) UpdatePreferences(preferences Preferences) error {
-	_, apiError, err := c.makeCall("POST", "users/me", preferences)
+	return c.UpdatePreferencesContext(context.Background(), preferences)
+}
+
+//UpdatePreferencesContext is UpdatePreferences bound to a context which may cancel its requests
+func (c *Client) UpdatePreferencesContext(ctx context.Context, preferences Preferences) error {
+	_, apiError, err := c.makeCallContext(ctx, "POST", routeUser, preferences)
 	if err != nil {
-		log.Println("Failed to update preferences: ", apiError, err)
+		c.warn("Failed to update preferences:", err, apiError.String())
 		return err
 	}
 	return err
 }
 
-//GetPushHistory gets pushes modified after the provided timestamp
-func (c *Client) GetPushHistory(modifiedAfter float32) ([]PushMessage, error) {
+//GetPushHistory gets pushes modified after the provided timestamp, typically the Modified time of the newest push already seen
+func (c *Client) GetPushHistory(modifiedAfter float64, opts ...ListOption) ([]PushMessage, error) {
+	return c.GetPushHistoryContext(context.Background(), modifiedAfter, opts...)
+}
+
+//GetPushHistoryContext is GetPushHistory bound to a context which may cancel its requests
+func (c *Client) GetPushHistoryContext(ctx context.Context, modifiedAfter float64, opts ...ListOption) ([]PushMessage, error) {
 	var pushList PushList
-	responseBody, apiError, err := c.makeCall("GET", "pushes?modified_after="+strconv.FormatFloat(float64(modifiedAfter), 'f', 4, 32), nil)
+	q := url.Values{"modified_after": {strconv.FormatFloat(modifiedAfter, 'f', -1, 64)}}
+	responseBody, apiError, err := c.makeCallContext(ctx, "GET", listCall(routePushes, q, opts), nil)
 	if err != nil {
-		log.Println("Error getting push history: ", apiError, err)
+		c.warn("Error getting push history:", err, apiError.String())
 		return pushList.Pushes, err
 	}
-	err = json.Unmarshal(responseBody, &pushList)
+	err = c.decode(responseBody, &pushList)
 	if err != nil {
 		return pushList.Pushes, err
 	}
@@ -567,9 +858,14 @@ func (c *Client) GetPushHistory(modifiedAfter float32) ([]PushMessage, error) {
 
 //DeletePush deletes a push message
 func (c *Client) DeletePush(pushID string) error {
-	_, apiError, err := c.makeCall("DELETE", "pushes/"+pushID, nil)
+	return c.DeletePushContext(context.Background(), pushID)
+}
+
+//DeletePushContext is DeletePush bound to a context which may cancel its requests
+func (c *Client) DeletePushContext(ctx context.Context, pushID string) error {
+	_, apiError, err := c.makeCallContext(ctx, "DELETE", pushRoute(pushID), nil)
 	if err != nil {
-		log.Println("Failed to delete push: ", apiError, err)
+		c.warn("Failed to delete push:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -577,9 +873,14 @@ func (c *Client) DeletePush(pushID string) error {
 
 //DismissPush allows for dismissal of a push message
 func (c *Client) DismissPush(ID string) error {
-	_, apiError, err := c.makeCall("GET", "pushes/"+ID, nil)
+	return c.DismissPushContext(context.Background(), ID)
+}
+
+//DismissPushContext is DismissPush bound to a context which may cancel its requests
+func (c *Client) DismissPushContext(ctx context.Context, ID string) error {
+	_, apiError, err := c.makeCallContext(ctx, "POST", pushRoute(ID), map[string]bool{"dismissed": true})
 	if err != nil {
-		log.Println("Failed to dismiss push: ", apiError, err)
+		c.warn("Failed to dismiss push:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -587,9 +888,17 @@ func (c *Client) DismissPush(ID string) error {
 
 //UpdateList allows for updating a list type push
 func (c *Client) UpdateList(pushID string, list ItemsList) error {
-	_, apiError, err := c.makeCall("POST", "pushes/"+pushID, list)
+	return c.UpdateListContext(context.Background(), pushID, list)
+}
+
+//UpdateListContext is UpdateList bound to a context which may cancel its requests
+func (c *Client) UpdateListContext(ctx context.Context, pushID string, list ItemsList) error {
+	if err := c.deprecatedPushType(PushMessage{Type: "checklist"}); err != nil {
+		return err
+	}
+	_, apiError, err := c.makeCallContext(ctx, "POST", pushRoute(pushID), list)
 	if err != nil {
-		log.Println("Failed to update list: ", apiError, err)
+		c.warn("Failed to update list:", err, apiError.String())
 		return err
 	}
 	return nil
@@ -597,123 +906,135 @@ func (c *Client) UpdateList(pushID string, list ItemsList) error {
 
 //makeCall handles most http transactions under standard methods
 func (c *Client) makeCall(method string, call string, data interface{}) (responseBody []byte, apiError *Error, err error) {
+	return c.makeCallContext(context.Background(), method, call, data)
+}
+
+//makeCallContext is makeCall bound to a context which may cancel the request
+func (c *Client) makeCallContext(ctx context.Context, method string, call string, data interface{}) (responseBody []byte, apiError *Error, err error) {
 	// make sure API key seems OK
-	if len(c.APIKey) == 0 {
-		return responseBody, apiError, errors.New("Error: API key required.")
+	key, err := c.apiKey()
+	if err != nil {
+		return responseBody, apiError, err
 	}
 
 	var payload []byte
 	// create the payload
 	if data != nil {
-		payload, err = json.Marshal(data)
+		payload, err = c.codec().Marshal(data)
 		if err != nil {
 			return responseBody, apiError, err
 		}
 	}
 
-	// make the call
-	req, err := http.NewRequest(method, c.BaseURL+call, bytes.NewBuffer(payload))
+	// make the call, retrying temporary failures when a Backoff is configured
+	retryable := c.Backoff != nil && (method == "GET" || method == "DELETE" || hasGUID(data))
+	var prev time.Duration
+	for retry := 1; ; retry++ {
+		var status int
+		responseBody, status, apiError, err = c.doCall(ctx, method, call, key, payload)
+		c.count(CounterRequests, 1)
+		if err != nil {
+			c.count(CounterErrors, 1)
+		}
+		if err == nil || !retryable || !temporary(ctx, status) {
+			return responseBody, apiError, c.redactError(err)
+		}
+		delay, ok := c.Backoff.Delay(retry, prev)
+		if !ok {
+			return responseBody, apiError, c.redactError(err)
+		}
+		if rl, isRateLimit := err.(*RateLimitError); isRateLimit && rl.RetryAfter() > delay {
+			delay = rl.RetryAfter()
+		}
+		prev = delay
+		c.debugf("--- %s %s retry %d in %v after: %v", method, call, retry, delay, err)
+		if sleepContext(ctx, delay) != nil {
+			return responseBody, apiError, c.redactError(err)
+		}
+		c.count(CounterRetries, 1)
+	}
+}
+
+//doCall makes a single request, returning the status code when a response was received
+func (c *Client) doCall(ctx context.Context, method, call, key string, payload []byte) (responseBody []byte, status int, apiError *Error, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint(call), bytes.NewBuffer(payload))
 	if err != nil {
-		return responseBody, apiError, err
+		return responseBody, status, apiError, err
 	}
-	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.APIKey+":")))
+	req.Header.Add("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(key+":")))
 	req.Header.Add("Content-Type", "application/json")
+	annotations := annotationSuffix(ctx)
+	c.debugf("--> %s %s %s%s", method, req.URL, payload, annotations)
+	start := time.Now()
 	res, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return responseBody, apiError, err
+		c.latency.record(endpointName(method, call), time.Since(start), true)
+		c.observe(req, call, 0, time.Since(start), err)
+		c.debugf("<-- %s %s failed after %v: %v%s", method, req.URL, time.Since(start), err, annotations)
+		return responseBody, status, apiError, err
 	}
 	defer res.Body.Close()
+	status = res.StatusCode
+	defer func() {
+		c.latency.record(endpointName(method, call), time.Since(start), err != nil)
+		c.observe(req, call, status, time.Since(start), err)
+	}()
 
 	// read the response
 	responseBody, err = ioutil.ReadAll(res.Body)
+	c.debugf("<-- %s %s %s (%v) %s%s", method, req.URL, res.Status, time.Since(start), responseBody, annotations)
 	if err != nil {
-		return responseBody, apiError, err
+		return responseBody, status, apiError, err
 	}
 
 	// if the response was an error message
-	if res.StatusCode != http.StatusOK {
-		apiError = &Error{}
-		err = json.Unmarshal(responseBody, &apiError)
-		if err != nil {
-			return responseBody, apiError, err
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		apiError = parseError(responseBody)
+		if res.StatusCode == http.StatusTooManyRequests {
+			return responseBody, status, apiError, rateLimitError(res.Header, apiError, time.Now())
 		}
-		return responseBody, apiError, fmt.Errorf("Status code: %v", res.StatusCode)
+		return responseBody, status, apiError, &StatusError{StatusCode: res.StatusCode}
 	}
 
-	return responseBody, apiError, err
+	return responseBody, status, apiError, err
 }
 
-func uploadFileByPath(authorization Authorization, file string) (err error) {
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-	// Add file
-	f, err := os.Open(file)
-	if err != nil {
-		return
-	}
-	fw, err := w.CreateFormFile("file", file)
-	if err != nil {
-		return
-	}
-	if _, err = io.Copy(fw, f); err != nil {
-		return
+//parseError decodes the error message of an unsuccessful response, returning nil when there is none: proxies and
+//outages can answer with empty, truncated or non-JSON bodies
+func parseError(body []byte) *Error {
+	apiError := &Error{}
+	if json.Unmarshal(body, apiError) != nil || len(apiError.ErrorBody.Message) == 0 {
+		return nil
 	}
-	// Add the other fields
-	if fw, err = w.CreateFormField("awsaccesskeyid"); err != nil {
-		return
-	}
-	if _, err = fw.Write([]byte(authorization.Data.Awsaccesskeyid)); err != nil {
-		return
-	}
-	if fw, err = w.CreateFormField("acl"); err != nil {
-		return
-	}
-	if _, err = fw.Write([]byte(authorization.Data.Acl)); err != nil {
-		return
-	}
-	if fw, err = w.CreateFormField("key"); err != nil {
-		return
-	}
-	if _, err = fw.Write([]byte(authorization.Data.Key)); err != nil {
-		return
-	}
-	if fw, err = w.CreateFormField("signature"); err != nil {
-		return
-	}
-	if _, err = fw.Write([]byte(authorization.Data.Signature)); err != nil {
-		return
-	}
-	if fw, err = w.CreateFormField("policy"); err != nil {
-		return
-	}
-	if _, err = fw.Write([]byte(authorization.Data.Policy)); err != nil {
-		return
-	}
-	if fw, err = w.CreateFormField("content-type"); err != nil {
-		return
+	return apiError
+}
+
+//apiKey returns the configured API key, consulting the TokenSource when no key is set directly
+func (c *Client) apiKey() (string, error) {
+	key := c.APIKey
+	if len(key) == 0 && c.TokenSource != nil {
+		var err error
+		key, err = c.TokenSource.Token()
+		if err != nil {
+			return key, err
+		}
+		c.lastKey.set(key)
 	}
-	if _, err = fw.Write([]byte(authorization.Data.ContentType)); err != nil {
-		return
+	if len(key) == 0 {
+		return key, errors.New("Error: API key required.")
 	}
-	w.Close()
+	return key, nil
+}
 
-	req, err := http.NewRequest("POST", authorization.UploadURL, &b)
+func (c *Client) uploadFileByPath(authorization Authorization, file string) error {
+	f, err := os.Open(file)
 	if err != nil {
-		return
+		return err
 	}
-	req.Header.Set("Content-Type", w.FormDataContentType())
-
-	// Submit the request
-	client := &http.Client{}
-	res, err := client.Do(req)
+	defer f.Close()
+	limit, err := c.uploadLimit(context.Background())
 	if err != nil {
-		return
-	}
-
-	// Check the response
-	if res.StatusCode >= 300 {
-		err = fmt.Errorf("Bad Status Result: %s", res.Status)
+		return err
 	}
-
-	return err
+	return c.uploadFile(context.Background(), authorization, file, f, limit)
 }
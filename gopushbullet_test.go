@@ -34,10 +34,35 @@ func mockHTTP(status int, body string) (*httptest.Server, *Client) {
 	}
 	httpClient := &http.Client{Transport: tr}
 
-	client := &Client{"apikey", server.URL, httpClient}
+	client := &Client{APIKey: "apikey", BaseURL: server.URL + "/", HTTPClient: httpClient}
 	return server, client
 }
 
+// mockRoutes serves a fixed body per request path, responding 404 to anything else. The routes may be changed
+// between requests.
+func mockRoutes(routes map[string]string) (*httptest.Server, *Client) {
+	return mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			w.WriteHeader(404)
+			fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "not found"}}`)
+			return
+		}
+		fmt.Fprintln(w, body)
+	})
+}
+
+// mockHandler serves every request with handler
+func mockHandler(handler http.HandlerFunc) (*httptest.Server, *Client) {
+	server := httptest.NewServer(handler)
+	return server, mockClient(server.URL)
+}
+
+// mockClient returns a client for the API served at serverURL
+func mockClient(serverURL string) *Client {
+	return &Client{APIKey: "apikey", BaseURL: serverURL + "/", HTTPClient: &http.Client{}}
+}
+
 func TestGetUser(t *testing.T) {
 	k, err := getKey()
 	if err != nil {
@@ -402,6 +427,30 @@ func TestSendChecklistToClientID(t *testing.T) {
 	}
 }
 
+// Push - Files
+
+func TestSendFile(t *testing.T) {
+	var sent []PushMessage
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		sent = append(sent, p)
+		fmt.Fprintln(w, "{}")
+	})
+	defer server.Close()
+
+	var invalid ValidationErrors
+	if err := c.SendFile("report.pdf", []string{"item1"}); !errors.As(err, &invalid) || len(sent) > 0 {
+		t.Errorf("Expected a file push without a URL refused, got %v: %+v", err, sent)
+	}
+	if err := c.SendFileToTarget("all", "", "report.pdf", "application/pdf", "https://dl.pushbulletusercontent.com/report.pdf", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].Type != "file" || sent[0].FileName != "report.pdf" {
+		t.Errorf("Expected a file push: %+v", sent)
+	}
+}
+
 // Contacts
 func TestGetContacts(t *testing.T) {
 	contactJson := `{
@@ -440,3 +489,27 @@ func TestDeletePush(t *testing.T) {
 		t.Error("Failure calling DeletePush:", err)
 	}
 }
+
+// Token sources
+type failingTokenSource struct{}
+
+func (failingTokenSource) Token() (string, error) {
+	return "", errors.New("keyring locked")
+}
+
+func TestTokenSource(t *testing.T) {
+	mockServer, c := mockHTTP(200, "{}")
+	defer mockServer.Close()
+	c.APIKey = ""
+	c.TokenSource = StaticToken("apikey")
+	err := c.SendNote("Build Test", "This is a test of gopushbullet's TokenSource support.")
+	if err != nil {
+		t.Error(err)
+	}
+
+	c.TokenSource = failingTokenSource{}
+	err = c.SendNote("Build Test", "This is a test of gopushbullet's TokenSource support.")
+	if err == nil || err.Error() != "keyring locked" {
+		t.Error("Expected token source error, got:", err)
+	}
+}
@@ -0,0 +1,286 @@
+//Package heartbeat is a dead man's switch: jobs check in periodically, and when one misses its check-in by more
+//than its grace period an alert is pushed. A job which checks in again after an alert sends a recovery push.
+//
+//Check-in times and sent alerts are persisted to a state file, so restarting the monitor neither forgets a job
+//which has already gone quiet nor alerts again for one it has already reported.
+package heartbeat
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//Job is a periodic job expected to check in every Interval.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Grace is the extra time allowed past the interval before alerting.
+	Grace time.Duration
+	// Title and Body override the alert text. Both may use %s for the job name.
+	Title, Body string
+	// Options are applied to the job's pushes after the monitor's, e.g. a different target device.
+	Options []pushbullet.NotifyOption
+}
+
+//Status is the state of a job.
+type Status struct {
+	Name        string
+	LastCheckIn time.Time // zero when the job has never checked in
+	Deadline    time.Time // when the job will be reported overdue
+	Overdue     bool
+	Alerted     bool // an alert has been sent for the current outage
+}
+
+//state is what is persisted for each job
+type state struct {
+	Since       time.Time `json:"since"` // when the job was first registered
+	LastCheckIn time.Time `json:"last_check_in,omitempty"`
+	Alerted     bool      `json:"alerted,omitempty"`
+}
+
+//Monitor tracks check-ins and alerts on missed ones.
+type Monitor struct {
+	Notifier pushbullet.Notifier
+	Options  []pushbullet.NotifyOption // applied to every push, e.g. a target device
+	// Path is the state file, kept in memory only when empty.
+	Path string
+	// Token must be the last element of a check-in's path; Handler rejects every request while it is empty.
+	Token string
+
+	mu     sync.Mutex
+	jobs   map[string]Job
+	states map[string]*state
+	loaded bool
+	now    func() time.Time
+}
+
+//New returns a Monitor alerting through n and persisting its state at path.
+func New(n pushbullet.Notifier, path string, opts ...pushbullet.NotifyOption) *Monitor {
+	return &Monitor{Notifier: n, Path: path, Options: opts}
+}
+
+func (m *Monitor) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+//Add registers a job. A job seen in the state file resumes from its last check-in; a new one is given a full
+//interval and grace period from now before it is reported.
+func (m *Monitor) Add(job Job) error {
+	if len(job.Name) == 0 {
+		return errors.New("Job name is required")
+	}
+	if job.Interval <= 0 {
+		return errors.New("Invalid check-in interval for " + job.Name)
+	}
+	if job.Grace < 0 {
+		return errors.New("Invalid grace period for " + job.Name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.loadLocked(); err != nil {
+		return err
+	}
+	m.jobs[job.Name] = job
+	if _, ok := m.states[job.Name]; ok {
+		return nil
+	}
+	m.states[job.Name] = &state{Since: m.clock()}
+	return m.saveLocked()
+}
+
+//CheckIn records that the job ran, sending a recovery push when it had been reported overdue.
+func (m *Monitor) CheckIn(ctx context.Context, name string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[name]
+	if !ok {
+		m.mu.Unlock()
+		return errors.New("Unknown job: " + name)
+	}
+	st := m.states[name]
+	recovered, last := st.Alerted, st.LastCheckIn
+	st.LastCheckIn, st.Alerted = m.clock(), false
+	err := m.saveLocked()
+	now := st.LastCheckIn
+	m.mu.Unlock()
+	if err != nil || !recovered {
+		return err
+	}
+
+	body := "Checked in again."
+	if !last.IsZero() {
+		body = "Checked in again after " + now.Sub(last).Round(time.Second).String() + " without one."
+	}
+	return m.Notifier.Notify(ctx, job.Name+" checked in", body, m.options(job)...)
+}
+
+//Check alerts once for every job which is overdue. A failed alert is retried on the next check.
+func (m *Monitor) Check(ctx context.Context) error {
+	now := m.clock()
+	var errs pushbullet.NotifyErrors
+	for _, s := range m.Status() {
+		if !s.Overdue || s.Alerted {
+			continue
+		}
+		m.mu.Lock()
+		job := m.jobs[s.Name]
+		m.mu.Unlock()
+
+		title, body := job.Name+" missed its check-in", ""
+		if s.LastCheckIn.IsZero() {
+			body = fmt.Sprintf("No check-in since monitoring started, expected every %v.", job.Interval)
+		} else {
+			body = fmt.Sprintf("Last check-in %v ago at %s, expected every %v.", now.Sub(s.LastCheckIn).Round(time.Second),
+				s.LastCheckIn.Format(time.RFC1123), job.Interval)
+		}
+		if len(job.Title) > 0 {
+			title = expand(job.Title, job.Name)
+		}
+		if len(job.Body) > 0 {
+			body = expand(job.Body, job.Name)
+		}
+		if err := m.Notifier.Notify(ctx, title, body, m.options(job)...); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		m.mu.Lock()
+		// a check-in may have arrived while alerting
+		if st := m.states[s.Name]; st.LastCheckIn.Equal(s.LastCheckIn) {
+			st.Alerted = true
+		}
+		if err := m.saveLocked(); err != nil {
+			errs = append(errs, err)
+		}
+		m.mu.Unlock()
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//Run checks the jobs every interval until the context is done.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid check interval")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+//Status returns the state of every job, ordered by name.
+func (m *Monitor) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock()
+	statuses := make([]Status, 0, len(m.jobs))
+	for name, job := range m.jobs {
+		st := m.states[name]
+		from := st.LastCheckIn
+		if from.IsZero() {
+			from = st.Since
+		}
+		deadline := from.Add(job.Interval + job.Grace)
+		statuses = append(statuses, Status{Name: name, LastCheckIn: st.LastCheckIn, Deadline: deadline,
+			Overdue: now.After(deadline), Alerted: st.Alerted})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+//Handler accepts check-ins over HTTP, so cron jobs and scripts can report with curl. A POST of the job name and
+//the monitor's Token as the path, such as /nightly/long-random-token, checks the job in; mount it with
+//http.StripPrefix to serve it below a prefix.
+func (m *Monitor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name, token := path.Split(strings.Trim(r.URL.Path, "/"))
+		if len(m.Token) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(m.Token)) != 1 {
+			http.Error(w, "Invalid token", http.StatusForbidden)
+			return
+		}
+		name = strings.TrimSuffix(name, "/")
+		m.mu.Lock()
+		_, ok := m.jobs[name]
+		m.mu.Unlock()
+		if !ok {
+			http.Error(w, "Unknown job", http.StatusNotFound)
+			return
+		}
+		if err := m.CheckIn(r.Context(), name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (m *Monitor) options(job Job) []pushbullet.NotifyOption {
+	return append(append([]pushbullet.NotifyOption(nil), m.Options...), job.Options...)
+}
+
+//expand substitutes the job name for %s, if present
+func expand(text, name string) string {
+	return strings.Replace(text, "%s", name, -1)
+}
+
+func (m *Monitor) loadLocked() error {
+	if m.loaded {
+		return nil
+	}
+	m.jobs = make(map[string]Job)
+	m.states = make(map[string]*state)
+	if len(m.Path) > 0 {
+		data, err := ioutil.ReadFile(m.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			if err = json.Unmarshal(data, &m.states); err != nil {
+				return fmt.Errorf("Reading heartbeat state %s: %v", m.Path, err)
+			}
+		}
+	}
+	m.loaded = true
+	return nil
+}
+
+//saveLocked rewrites the state file atomically
+func (m *Monitor) saveLocked() error {
+	if len(m.Path) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(m.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(m.Path, data)
+}
@@ -0,0 +1,183 @@
+package heartbeat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newMonitor(rec pushbullet.Notifier, path string, clock *fakeClock) *Monitor {
+	m := New(rec, path)
+	m.now = clock.now
+	return m
+}
+
+func TestMissedCheckInAlertsOnce(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	clock := &fakeClock{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newMonitor(rec, "", clock)
+	if err := m.Add(Job{Name: "backup", Interval: time.Hour, Grace: 10 * time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	clock.advance(50 * time.Minute)
+	m.CheckIn(ctx, "backup")
+	clock.advance(65 * time.Minute)
+	m.Check(ctx)
+	if len(rec.Pushes()) != 0 {
+		t.Fatalf("Alerted within the grace period: %+v", rec.Pushes())
+	}
+
+	clock.advance(10 * time.Minute)
+	m.Check(ctx)
+	m.Check(ctx)
+	if len(rec.Pushes()) != 1 || rec.Pushes()[0].Title != "backup missed its check-in" {
+		t.Fatalf("Expected one alert, got: %+v", rec.Pushes())
+	}
+
+	clock.advance(5 * time.Minute)
+	if err := m.CheckIn(ctx, "backup"); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.Pushes()) != 2 || rec.Pushes()[1].Body != "Checked in again after 1h20m0s without one." {
+		t.Errorf("Expected a recovery push, got: %+v", rec.Pushes())
+	}
+	if m.Status()[0].Overdue {
+		t.Error("Job still overdue after checking in")
+	}
+}
+
+func TestCustomAlert(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	clock := &fakeClock{time.Now()}
+	m := newMonitor(rec, "", clock)
+	m.Add(Job{Name: "etl", Interval: time.Minute, Title: "[PAGE] %s is stuck", Body: "Check the %s logs",
+		Options: []pushbullet.NotifyOption{pushbullet.ToDevice("pager")}})
+
+	clock.advance(2 * time.Minute)
+	m.Check(context.Background())
+	p := rec.Pushes()
+	if len(p) != 1 || p[0].Title != "[PAGE] etl is stuck" || p[0].Body != "Check the etl logs" || p[0].DeviceID != "pager" {
+		t.Errorf("Unexpected alert: %+v", p)
+	}
+}
+
+func TestStateSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat.json")
+	rec := &pushbullet.RecordingNotifier{}
+	clock := &fakeClock{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	job := Job{Name: "backup", Interval: time.Hour}
+	ctx := context.Background()
+
+	m := newMonitor(rec, path, clock)
+	m.Add(job)
+	m.CheckIn(ctx, "backup")
+
+	// a restart shortly after the check-in keeps the original deadline rather than a fresh one
+	clock.advance(30 * time.Minute)
+	m = newMonitor(rec, path, clock)
+	m.Add(job)
+	if want := clock.t.Add(30 * time.Minute); !m.Status()[0].Deadline.Equal(want) {
+		t.Errorf("Deadline = %v, want %v", m.Status()[0].Deadline, want)
+	}
+
+	clock.advance(time.Hour)
+	m.Check(ctx)
+	if len(rec.Pushes()) != 1 {
+		t.Fatalf("Expected an alert, got: %+v", rec.Pushes())
+	}
+
+	// nor does a restart after alerting repeat the alert
+	m = newMonitor(rec, path, clock)
+	m.Add(job)
+	m.Check(ctx)
+	if len(rec.Pushes()) != 1 {
+		t.Errorf("Alert repeated after restart: %+v", rec.Pushes())
+	}
+}
+
+func TestFailedAlertRetried(t *testing.T) {
+	failing := true
+	var sent []string
+	n := pushbullet.NotifierFunc(func(ctx context.Context, title, body string, opts ...pushbullet.NotifyOption) error {
+		if failing {
+			return context.DeadlineExceeded
+		}
+		sent = append(sent, title)
+		return nil
+	})
+	clock := &fakeClock{time.Now()}
+	m := newMonitor(n, "", clock)
+	m.Add(Job{Name: "sync", Interval: time.Minute})
+
+	clock.advance(2 * time.Minute)
+	if err := m.Check(context.Background()); err == nil {
+		t.Error("Expected the failed alert to be reported")
+	}
+	failing = false
+	m.Check(context.Background())
+	if len(sent) != 1 {
+		t.Errorf("Expected the alert to be retried, sent: %v", sent)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	clock := &fakeClock{time.Now()}
+	m := newMonitor(&pushbullet.RecordingNotifier{}, "", clock)
+	m.Add(Job{Name: "nightly", Interval: time.Hour})
+	server := httptest.NewServer(http.StripPrefix("/ping", m.Handler()))
+	defer server.Close()
+	post := func(path string) int {
+		res, err := http.Post(server.URL+path, "text/plain", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if code := post("/ping/nightly"); code != 403 {
+		t.Errorf("Expected check-ins to be rejected without a monitor token: %d", code)
+	}
+	m.Token = "s3cret"
+	for path, want := range map[string]int{"/ping/nightly/s3cret": 204, "/ping/weekly/s3cret": 404,
+		"/ping/nightly/wrong": 403, "/ping/nightly": 403} {
+		if code := post(path); code != want {
+			t.Errorf("POST %s = %d, want %d", path, code, want)
+		}
+	}
+	if m.Status()[0].LastCheckIn != clock.t {
+		t.Error("Check-in over HTTP not recorded")
+	}
+	res, err := http.Get(server.URL + "/ping/nightly/s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 405 {
+		t.Errorf("GET = %d, want 405", res.StatusCode)
+	}
+}
+
+func TestAddValidates(t *testing.T) {
+	m := New(&pushbullet.RecordingNotifier{}, "")
+	for _, job := range []Job{{Interval: time.Hour}, {Name: "x"}, {Name: "x", Interval: time.Hour, Grace: -1}} {
+		if err := m.Add(job); err == nil {
+			t.Errorf("Accepted invalid job %+v", job)
+		}
+	}
+	if err := m.CheckIn(context.Background(), "x"); err == nil {
+		t.Error("Accepted a check-in for an unknown job")
+	}
+}
@@ -0,0 +1,52 @@
+package pushbullet
+
+import (
+	"context"
+	"time"
+)
+
+//WithHedging sends a second copy of a push when the first has not completed within the delay, taking whichever
+//finishes first. Both copies carry the same guid so the API keeps only one; the extra load buys protection from
+//slow requests for latency critical alerts.
+func WithHedging(after time.Duration) Option {
+	return func(c *Client) {
+		c.HedgeAfter = after
+	}
+}
+
+//callResult is the outcome of one copy of a hedged request
+type callResult struct {
+	body     []byte
+	apiError *Error
+	err      error
+}
+
+//hedgedCall makes the call, starting a second identical one if the first is still running after HedgeAfter
+func (c *Client) hedgedCall(ctx context.Context, method, call string, data interface{}) ([]byte, *Error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // abandons the slower copy
+	results := make(chan callResult, 2)
+	send := func() {
+		body, apiError, err := c.makeCallContext(ctx, method, call, data)
+		results <- callResult{body, apiError, err}
+	}
+	go send()
+
+	timer := time.NewTimer(c.HedgeAfter)
+	defer timer.Stop()
+	var r callResult
+	select {
+	case r = <-results:
+		return r.body, r.apiError, r.err
+	case <-timer.C:
+		c.debugf("--- %s %s hedged after %v", method, call, c.HedgeAfter)
+		go send()
+	}
+	// the first success wins; a failure only counts when both copies failed
+	for pending := 2; pending > 0; pending-- {
+		if r = <-results; r.err == nil {
+			break
+		}
+	}
+	return r.body, r.apiError, r.err
+}
@@ -0,0 +1,63 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHedgedSend(t *testing.T) {
+	var mu sync.Mutex
+	var guids []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p PushMessage
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &p)
+		mu.Lock()
+		guids = append(guids, p.GUID)
+		first := len(guids) == 1
+		mu.Unlock()
+		if first {
+			// the first copy stalls until the client gives up on it
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte(`{"iden": "p1"}`))
+	})
+	defer server.Close()
+	c.HedgeAfter = 20 * time.Millisecond
+
+	start := time.Now()
+	created, err := c.SendPush(PushRequest{Type: "note", Title: "Paging"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != "p1" || time.Since(start) > time.Second {
+		t.Error("Hedged copy did not win:", created.ID, time.Since(start))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(guids) != 2 || len(guids[0]) == 0 || guids[0] != guids[1] {
+		t.Error("Expected two copies with the same guid:", guids)
+	}
+}
+
+func TestHedgingSkippedForFastSend(t *testing.T) {
+	requests := 0
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+	c.HedgeAfter = time.Second
+
+	if err := c.SendNote("Build", "fast"); err != nil || requests != 1 {
+		t.Error("Expected a single request:", err, requests)
+	}
+}
@@ -0,0 +1,178 @@
+package pushbullet
+
+import (
+	"context"
+	"sync"
+)
+
+//maxStreamBacklog bounds the events held for a subscriber which has not caught up. Past it the backlog is replaced
+//by a StreamReconnected event, telling the subscriber to fetch everything again as after a lost connection.
+const maxStreamBacklog = 1000
+
+//WithStreamOptions configures the stream connection shared by Subscribe.
+func WithStreamOptions(opts ...ListenOption) Option {
+	return func(c *Client) {
+		c.StreamOptions = opts
+	}
+}
+
+//Subscribe calls handler with every event of the realtime stream until the context is done, like Listen, except
+//that all of the client's subscribers share one connection: the first to subscribe opens it, configured by
+//StreamOptions, and the last to leave closes it. A sync engine and a bot can then both follow the stream without
+//each using a connection of the account.
+//
+//Every subscriber receives the events in order from its own goroutine, so a slow handler holds up only its own
+//events. Subscribe returns the context's error once it is done, or the error which ended the shared connection,
+//such as a bad API key, once the events received before it have been delivered.
+func (c *Client) Subscribe(ctx context.Context, handler func(StreamEvent)) error {
+	q := newStreamQueue()
+	conn := c.hub.join(c, q)
+	defer c.hub.leave(q)
+	for {
+		if e, ok := q.pop(); ok {
+			handler(e)
+			continue
+		}
+		select {
+		case <-q.wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-conn.ended:
+			if q.len() == 0 {
+				return conn.err
+			}
+		}
+	}
+}
+
+//streamHub fans the events of one stream connection out to the subscribers of a client
+type streamHub struct {
+	mu   sync.Mutex
+	subs map[*streamQueue]bool
+	conn *hubConn
+}
+
+//hubConn is a shared connection, which ends with err when Listen returns
+type hubConn struct {
+	cancel context.CancelFunc
+	ended  chan struct{}
+	err    error
+}
+
+//join adds a subscriber, connecting when it is the first
+func (h *streamHub) join(c *Client, q *streamQueue) *hubConn {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[*streamQueue]bool)
+	}
+	h.subs[q] = true
+	if h.conn == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		conn := &hubConn{cancel: cancel, ended: make(chan struct{})}
+		h.conn = conn
+		go func() {
+			conn.err = c.Listen(ctx, h.broadcast, c.StreamOptions...)
+			h.mu.Lock()
+			if h.conn == conn {
+				h.conn = nil // the next subscriber reconnects
+			}
+			h.mu.Unlock()
+			close(conn.ended)
+		}()
+	}
+	return h.conn
+}
+
+//leave removes a subscriber, disconnecting when it was the last
+func (h *streamHub) leave(q *streamQueue) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, q)
+	if len(h.subs) == 0 && h.conn != nil {
+		h.conn.cancel()
+		h.conn = nil
+	}
+}
+
+func (h *streamHub) broadcast(e StreamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for q := range h.subs {
+		q.push(e)
+	}
+}
+
+//streamQueue holds the events not yet delivered to a handler, and all of them while paused
+type streamQueue struct {
+	mu     sync.Mutex
+	events []StreamEvent
+	paused bool
+	wake   chan struct{}
+}
+
+func newStreamQueue() *streamQueue {
+	return &streamQueue{wake: make(chan struct{}, 1)}
+}
+
+//push holds the event, replacing a backlog grown past maxStreamBacklog with a StreamReconnected event
+func (q *streamQueue) push(e StreamEvent) {
+	q.mu.Lock()
+	if len(q.events) >= maxStreamBacklog {
+		q.events = []StreamEvent{{Type: StreamReconnected}}
+	}
+	q.events = append(q.events, e)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *streamQueue) pause(paused bool) {
+	q.mu.Lock()
+	q.paused = paused
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *streamQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+//deliver calls handler with the events as they are pushed until the context is done or stop is closed, then with
+//those left unless paused
+func (q *streamQueue) deliver(ctx context.Context, handler func(StreamEvent), stop <-chan struct{}) {
+	for ctx.Err() == nil {
+		if e, ok := q.pop(); ok {
+			handler(e)
+			continue
+		}
+		select {
+		case <-q.wake:
+		case <-ctx.Done():
+		case <-stop:
+			for e, ok := q.pop(); ok && ctx.Err() == nil; e, ok = q.pop() {
+				handler(e)
+			}
+			return
+		}
+	}
+}
+
+func (q *streamQueue) pop() (StreamEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.events) == 0 || q.paused {
+		return StreamEvent{}, false
+	}
+	e := q.events[0]
+	q.events = q.events[1:]
+	return e, true
+}
+
+func (q *streamQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
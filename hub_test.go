@@ -0,0 +1,97 @@
+package pushbullet
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSubscribeSharesConnection(t *testing.T) {
+	send := make(chan struct{})
+	server := newStreamServer(func(n int, conn net.Conn, rw *bufio.ReadWriter) {
+		// nops until both subscribers have seen one, proving they are subscribed
+		tick := time.NewTicker(5 * time.Millisecond)
+		defer tick.Stop()
+		for waiting := true; waiting; {
+			select {
+			case <-tick.C:
+				serverFrame(rw, true, opText, `{"type": "nop"}`)
+			case <-send:
+				waiting = false
+			}
+		}
+		serverFrame(rw, true, opText, `{"type": "tickle", "subtype": "push"}`)
+		time.Sleep(time.Second)
+	})
+	defer server.Close()
+	c := server.client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	subscribed := make(chan struct{}, 2)
+	tickled := make(chan string, 2)
+	for _, name := range []string{"sync", "bot"} {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			first := true
+			c.Subscribe(ctx, func(e StreamEvent) {
+				if first {
+					first = false
+					subscribed <- struct{}{}
+				}
+				if e.Type == StreamTickle {
+					tickled <- name
+				}
+			})
+		}()
+	}
+	<-subscribed
+	<-subscribed
+	close(send)
+	got := map[string]bool{<-tickled: true, <-tickled: true}
+	if !got["sync"] || !got["bot"] {
+		t.Error("Expected both subscribers to see the tickle:", got)
+	}
+	cancel()
+	wg.Wait()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 1 {
+		t.Errorf("Expected one shared connection, made %d", server.conns)
+	}
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	if len(c.hub.subs) != 0 || c.hub.conn != nil {
+		t.Error("Connection kept open after the last subscriber left")
+	}
+}
+
+func TestSubscribeBadKey(t *testing.T) {
+	server := newStreamServer(func(int, net.Conn, *bufio.ReadWriter) {})
+	defer server.Close()
+	c := server.client()
+	c.APIKey = "wrong"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Subscribe(ctx, func(StreamEvent) {}); KindOf(err) != KindBadToken {
+		t.Errorf("Expected the connection's error, got: %v", err)
+	}
+}
+
+func TestStreamQueueBacklog(t *testing.T) {
+	q := newStreamQueue()
+	for i := 0; i < maxStreamBacklog+2; i++ {
+		q.push(StreamEvent{Type: StreamTickle})
+	}
+	if e, _ := q.pop(); e.Type != StreamReconnected || q.len() != 2 {
+		t.Errorf("Expected the backlog replaced by a reconnection: %v, %d left", e.Type, q.len())
+	}
+}
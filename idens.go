@@ -0,0 +1,208 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//IdenStore persists the push idens recorded for application keys, such as ticket numbers or alert fingerprints.
+type IdenStore interface {
+	//Load returns the idens recorded for key, none when there are none.
+	Load(key string) ([]string, error)
+	//Save replaces the idens recorded for key, forgetting the key when there are none.
+	Save(key string, idens []string) error
+}
+
+//IdenFile is an IdenStore persisted as a JSON document at the path, rewritten atomically on every save. It is not
+//safe for concurrent use on its own; a PushIndex serializes its calls.
+type IdenFile string
+
+//Load returns the idens recorded for key.
+func (f IdenFile) Load(key string) ([]string, error) {
+	all, err := f.read()
+	return all[key], err
+}
+
+//Save replaces the idens recorded for key.
+func (f IdenFile) Save(key string, idens []string) error {
+	all, err := f.read()
+	if err != nil {
+		return err
+	}
+	if len(idens) == 0 {
+		delete(all, key)
+	} else {
+		all[key] = idens
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(string(f), data)
+}
+
+func (f IdenFile) read() (map[string][]string, error) {
+	all := make(map[string][]string)
+	data, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return all, err
+	}
+	return all, json.Unmarshal(data, &all)
+}
+
+//memoryIdens is the IdenStore of a PushIndex without one
+type memoryIdens map[string][]string
+
+func (m memoryIdens) Load(key string) ([]string, error) { return m[key], nil }
+
+func (m memoryIdens) Save(key string, idens []string) error {
+	if len(idens) == 0 {
+		delete(m, key)
+	} else {
+		m[key] = idens
+	}
+	return nil
+}
+
+//PushIndex remembers the pushes sent for application keys, so later updates can dismiss or delete exactly the
+//pushes created for a business object, even after a restart.
+//
+//	idx := pushbullet.NewPushIndex(client, pushbullet.IdenFile("pushes.json"))
+//	idx.Send(ctx, "ticket-4521", pushbullet.NotifyPush("Ticket opened", summary))
+//	...
+//	idx.Delete(ctx, "ticket-4521")
+type PushIndex struct {
+	Client *Client
+	Store  IdenStore // held in memory when nil
+
+	mu sync.Mutex
+}
+
+//NewPushIndex returns a PushIndex sending through c and recording to store.
+func NewPushIndex(c *Client, store IdenStore) *PushIndex {
+	return &PushIndex{Client: c, Store: store}
+}
+
+//Send sends the push and records its iden under key, alongside those already recorded.
+func (x *PushIndex) Send(ctx context.Context, key string, p PushMessage) (PushMessage, error) {
+	if len(key) == 0 {
+		return PushMessage{}, errors.New("Push index key is empty")
+	}
+	sent, err := x.Client.sendPush(ctx, p)
+	if err != nil {
+		return sent, err
+	}
+	if len(sent.ID) == 0 {
+		return sent, nil
+	}
+	return sent, x.Record(key, sent.ID)
+}
+
+//Record adds idens of pushes sent by other means under key.
+func (x *PushIndex) Record(key string, idens ...string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	store := x.store()
+	recorded, err := store.Load(key)
+	if err != nil {
+		return err
+	}
+	return store.Save(key, append(recorded, idens...))
+}
+
+//Idens returns the idens recorded under key, oldest first.
+func (x *PushIndex) Idens(key string) ([]string, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.store().Load(key)
+}
+
+//Forget drops the idens recorded under key without touching the pushes.
+func (x *PushIndex) Forget(key string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.store().Save(key, nil)
+}
+
+//Dismiss dismisses the pushes recorded under key, which stay recorded.
+func (x *PushIndex) Dismiss(key string) error {
+	return x.DismissContext(context.Background(), key)
+}
+
+//DismissContext is Dismiss bound to a context which may cancel its requests
+func (x *PushIndex) DismissContext(ctx context.Context, key string) error {
+	idens, err := x.Idens(key)
+	if err != nil {
+		return err
+	}
+	var errs NotifyErrors
+	for _, id := range idens {
+		if err := x.Client.DismissPushContext(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//Delete deletes the pushes recorded under key and forgets them. Idens whose deletion failed stay recorded, so the
+//call can be repeated.
+func (x *PushIndex) Delete(ctx context.Context, key string) error {
+	idens, err := x.Idens(key)
+	if err != nil {
+		return err
+	}
+	var errs NotifyErrors
+	deleted := make(map[string]bool)
+	for _, id := range idens {
+		err := x.Client.DeletePushContext(ctx, id)
+		var status *StatusError
+		if err != nil && !(errors.As(err, &status) && status.StatusCode == 404) {
+			errs = append(errs, err)
+			continue
+		}
+		deleted[id] = true
+	}
+	if err := x.forget(key, deleted); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//forget drops the deleted idens from those recorded under key, keeping any recorded meanwhile
+func (x *PushIndex) forget(key string, deleted map[string]bool) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	recorded, err := x.store().Load(key)
+	if err != nil {
+		return err
+	}
+	var kept []string
+	for _, id := range recorded {
+		if !deleted[id] {
+			kept = append(kept, id)
+		}
+	}
+	return x.store().Save(key, kept)
+}
+
+func (x *PushIndex) store() IdenStore {
+	if x.Store == nil {
+		x.Store = make(memoryIdens)
+	}
+	return x.Store
+}
@@ -0,0 +1,75 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPushIndex(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	n := 0
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "DELETE" {
+			id := strings.TrimPrefix(r.URL.Path, "/pushes/")
+			if id == "broken" {
+				w.WriteHeader(500)
+				return
+			}
+			deleted = append(deleted, id)
+			w.Write([]byte("{}"))
+			return
+		}
+		n++
+		json.NewEncoder(w).Encode(PushMessage{ID: "push" + string(rune('0'+n))})
+	})
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "idens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := IdenFile(filepath.Join(dir, "idens.json"))
+	ctx := context.Background()
+
+	idx := NewPushIndex(c, store)
+	idx.Send(ctx, "ticket-1", NotifyPush("opened", ""))
+	idx.Send(ctx, "ticket-2", NotifyPush("opened", ""))
+	idx.Send(ctx, "ticket-1", NotifyPush("updated", ""))
+	if _, err := idx.Send(ctx, "", NotifyPush("opened", "")); err == nil {
+		t.Error("Expected an error for an empty key")
+	}
+
+	// a new index over the same file stands in for a restart
+	idx = NewPushIndex(c, store)
+	if idens, err := idx.Idens("ticket-1"); err != nil || len(idens) != 2 || idens[0] != "push1" || idens[1] != "push3" {
+		t.Fatal("Unexpected idens:", idens, err)
+	}
+	idx.Record("ticket-1", "broken")
+	if err := idx.Delete(ctx, "ticket-1"); err == nil {
+		t.Error("Expected the failed deletion to be reported")
+	}
+	if len(deleted) != 2 || deleted[0] != "push1" || deleted[1] != "push3" {
+		t.Error("Unexpected deletions:", deleted)
+	}
+	if idens, _ := idx.Idens("ticket-1"); len(idens) != 1 || idens[0] != "broken" {
+		t.Error("Expected only the failed iden kept:", idens)
+	}
+	if idens, _ := idx.Idens("ticket-2"); len(idens) != 1 {
+		t.Error("Other keys affected:", idens)
+	}
+	idx.Forget("ticket-2")
+	if idens, _ := store.Load("ticket-2"); len(idens) != 0 {
+		t.Error("Expected the key forgotten:", idens)
+	}
+}
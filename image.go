@@ -0,0 +1,39 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+//ErrNoImage is returned by DownloadThumbnail for a push without an image thumbnail.
+var ErrNoImage = errors.New("Push has no image")
+
+//IsImage reports whether the push is a file push of an image.
+func (p PushMessage) IsImage() bool {
+	return p.Type == "file" && (strings.HasPrefix(p.FileType, "image/") || len(p.ImageURL) > 0)
+}
+
+//DownloadThumbnail writes the thumbnail Pushbullet generated for an image push to w, returning the number of bytes
+//written. The full size image is at FileURL.
+func (c *Client) DownloadThumbnail(ctx context.Context, p PushMessage, w io.Writer) (int64, error) {
+	if len(p.ImageURL) == 0 {
+		return 0, ErrNoImage
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", p.ImageURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return 0, fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	return io.Copy(w, res.Body)
+}
@@ -0,0 +1,42 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestImagePushes(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/thumbs/p1" {
+			w.Write([]byte("thumbnail"))
+			return
+		}
+		fmt.Fprintf(w, `{"pushes": [
+			{"iden": "p1", "type": "file", "file_type": "image/png", "image_url": "%s/thumbs/p1", "image_width": 640, "image_height": 480},
+			{"iden": "p2", "type": "file", "file_type": "application/pdf"}]}`, server.URL)
+	}))
+	defer server.Close()
+	c := mockClient(server.URL)
+
+	pushes, err := c.GetPushHistory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, doc := pushes[0], pushes[1]
+	if !img.IsImage() || doc.IsImage() || img.ImageWidth != 640 || img.ImageHeight != 480 {
+		t.Errorf("Unexpected image pushes: %+v, %+v", img, doc)
+	}
+
+	var buf bytes.Buffer
+	if n, err := c.DownloadThumbnail(context.Background(), img, &buf); err != nil || n != 9 || buf.String() != "thumbnail" {
+		t.Error("Unexpected thumbnail:", n, err, buf.String())
+	}
+	if _, err := c.DownloadThumbnail(context.Background(), doc, &buf); err != ErrNoImage {
+		t.Error("Expected ErrNoImage, got:", err)
+	}
+}
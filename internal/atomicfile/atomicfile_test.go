@@ -0,0 +1,32 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	for _, data := range []string{"first", "second"} {
+		if err := Write(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ioutil.ReadFile(path); err != nil || string(got) != data {
+			t.Errorf("Read %q, %v, want %q", got, err, data)
+		}
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Temporary files left behind: %d files", len(files))
+	}
+	if err := Write(filepath.Join(dir, "missing", "state.json"), nil); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
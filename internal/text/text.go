@@ -0,0 +1,19 @@
+//Package text holds the string helpers shared by the packages which fit messages into a service's limits.
+package text
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//Truncate shortens s to at most n runes, ending it with an ellipsis when anything was cut. Runes are never split.
+func Truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
@@ -0,0 +1,21 @@
+package text
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 5, "hello"},
+		{"héllo world", 6, "héllo…"},
+		{"日本語のテキスト", 4, "日本語…"},
+		{"hello", 1, "…"},
+		{"hello", 0, ""},
+	} {
+		if got := Truncate(tc.s, tc.n); got != tc.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tc.s, tc.n, got, tc.want)
+		}
+	}
+}
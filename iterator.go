@@ -0,0 +1,149 @@
+package pushbullet
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+//PushIterator walks the push history one push at a time, requesting further pages as they are needed.
+//
+//	it := c.IteratePushes(ctx, 0, Prefetch(2))
+//	defer it.Close()
+//	for it.Next() {
+//		p := it.Push()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type PushIterator struct {
+	c             *Client
+	ctx           context.Context
+	cancel        context.CancelFunc
+	modifiedAfter float64
+	listOpts      []ListOption
+	prefetch      int
+
+	pages  chan pushPage // filled by the prefetcher when prefetching
+	buf    []PushMessage
+	cur    PushMessage
+	cursor string
+	done   bool
+	err    error
+}
+
+//pushPage is one page of push history or the error which ended the walk
+type pushPage struct {
+	pushes []PushMessage
+	cursor string
+	err    error
+}
+
+//IteratorOption configures a PushIterator.
+type IteratorOption func(*PushIterator)
+
+//Prefetch fetches up to pages pages ahead in a background goroutine while the caller processes the current one,
+//hiding API latency from jobs scanning long histories. At most pages fetched pages are buffered at a time.
+func Prefetch(pages int) IteratorOption {
+	return func(it *PushIterator) {
+		it.prefetch = pages
+	}
+}
+
+//PageOptions applies list options, such as Limit to set the page size or IncludeInactive, to every page request.
+func PageOptions(opts ...ListOption) IteratorOption {
+	return func(it *PushIterator) {
+		it.listOpts = append(it.listOpts, opts...)
+	}
+}
+
+//IteratePushes returns an iterator over the pushes modified after the provided timestamp, newest first.
+//Close should be called when the caller stops before the end of the history.
+func (c *Client) IteratePushes(ctx context.Context, modifiedAfter float64, opts ...IteratorOption) *PushIterator {
+	it := &PushIterator{c: c, modifiedAfter: modifiedAfter}
+	it.ctx, it.cancel = context.WithCancel(ctx)
+	for _, opt := range opts {
+		opt(it)
+	}
+	if it.prefetch > 0 {
+		it.pages = make(chan pushPage, it.prefetch)
+		go it.prefetchPages()
+	}
+	return it
+}
+
+//Next advances to the next push, returning false at the end of the history or on error.
+func (it *PushIterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.done {
+			return false
+		}
+		page := it.nextPage()
+		if page.err != nil {
+			it.err, it.done = page.err, true
+			return false
+		}
+		it.buf, it.cursor = page.pushes, page.cursor
+		it.done = len(page.cursor) == 0
+	}
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+//Push returns the current push.
+func (it *PushIterator) Push() PushMessage {
+	return it.cur
+}
+
+//Err returns the error which stopped the iteration, if any.
+func (it *PushIterator) Err() error {
+	return it.err
+}
+
+//Close stops the iterator and any prefetching.
+func (it *PushIterator) Close() {
+	it.cancel()
+	it.done, it.buf = true, nil
+}
+
+func (it *PushIterator) nextPage() pushPage {
+	if it.pages == nil {
+		return it.fetch(it.cursor)
+	}
+	page, ok := <-it.pages
+	if !ok {
+		return pushPage{err: it.ctx.Err()}
+	}
+	return page
+}
+
+//prefetchPages fetches pages into the bounded buffer until the history ends, a request fails, or the iterator is closed
+func (it *PushIterator) prefetchPages() {
+	defer close(it.pages)
+	cursor := ""
+	for {
+		page := it.fetch(cursor)
+		select {
+		case it.pages <- page:
+		case <-it.ctx.Done():
+			return
+		}
+		if page.err != nil || len(page.cursor) == 0 {
+			return
+		}
+		cursor = page.cursor
+	}
+}
+
+func (it *PushIterator) fetch(cursor string) pushPage {
+	q := url.Values{"modified_after": {strconv.FormatFloat(it.modifiedAfter, 'f', -1, 64)}}
+	if len(cursor) > 0 {
+		q.Set("cursor", cursor)
+	}
+	var l PushList
+	if err := it.c.getContext(it.ctx, listCall(routePushes, q, it.listOpts), &l); err != nil {
+		return pushPage{err: err}
+	}
+	return pushPage{pushes: l.Pushes, cursor: l.Cursor}
+}
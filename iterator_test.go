@@ -0,0 +1,82 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedPushes serves pages of two pushes each, p0 to p<total-1>, counting the requests made
+func pagedPushes(total int, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		start := 0
+		fmt.Sscan(r.URL.Query().Get("cursor"), &start)
+		fmt.Fprint(w, `{"pushes": [`)
+		for i := start; i < start+2 && i < total; i++ {
+			if i > start {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"iden": "p%d", "active": true}`, i)
+		}
+		fmt.Fprint(w, `]`)
+		if start+2 < total {
+			fmt.Fprintf(w, `, "cursor": "%d"`, start+2)
+		}
+		fmt.Fprint(w, `}`)
+	}))
+}
+
+func TestIteratePushes(t *testing.T) {
+	for _, opts := range [][]IteratorOption{nil, {Prefetch(1)}, {Prefetch(3), PageOptions(Limit(2))}} {
+		var requests int32
+		server := pagedPushes(5, &requests)
+		c := mockClient(server.URL)
+
+		it := c.IteratePushes(context.Background(), 0, opts...)
+		var got []string
+		for it.Next() {
+			got = append(got, it.Push().ID)
+		}
+		it.Close()
+		server.Close()
+		if it.Err() != nil {
+			t.Error(it.Err())
+		}
+		if fmt.Sprint(got) != "[p0 p1 p2 p3 p4]" || requests != 3 {
+			t.Errorf("Unexpected iteration with %d options: %v in %d requests", len(opts), got, requests)
+		}
+	}
+}
+
+func TestPrefetchIsBounded(t *testing.T) {
+	var requests int32
+	server := pagedPushes(20, &requests)
+	defer server.Close()
+	c := mockClient(server.URL)
+
+	it := c.IteratePushes(context.Background(), 0, Prefetch(2))
+	defer it.Close()
+	if !it.Next() {
+		t.Fatal(it.Err())
+	}
+	time.Sleep(50 * time.Millisecond)
+	// one page taken by the caller, two buffered, and one blocked waiting for buffer space
+	if n := atomic.LoadInt32(&requests); n > 4 {
+		t.Error("Prefetch ran ahead of its buffer:", n)
+	}
+}
+
+func TestIteratePushesError(t *testing.T) {
+	mockServer, c := mockHTTP(500, `{"error": {"type": "server", "message": "down"}}`)
+	defer mockServer.Close()
+
+	it := c.IteratePushes(context.Background(), 0, Prefetch(1))
+	if it.Next() || it.Err() == nil {
+		t.Error("Expected the failed page to end iteration with an error")
+	}
+}
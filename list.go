@@ -0,0 +1,67 @@
+package pushbullet
+
+import (
+	"net/url"
+	"strconv"
+)
+
+//ListOption adjusts the query of a list call such as GetDevices or GetPushHistory.
+type ListOption func(url.Values)
+
+//Limit caps the number of records returned in a page, sparing callers which only need the latest few
+//the latency and memory of a full default page.
+func Limit(n int) ListOption {
+	return func(q url.Values) {
+		q.Set("limit", strconv.Itoa(n))
+	}
+}
+
+//IncludeInactive returns deleted (inactive) records alongside active ones. Without it list calls only return
+//active records; tools which track deletions, such as sync engines, need the tombstones.
+func IncludeInactive() ListOption {
+	return func(q url.Values) {
+		q.Del("active")
+	}
+}
+
+//Deleted reports whether the push is a tombstone for a deleted push.
+func (p PushMessage) Deleted() bool {
+	return !p.Active
+}
+
+//Deleted reports whether the device is a tombstone for a deleted device.
+func (d Device) Deleted() bool {
+	return !d.Active
+}
+
+//Deleted reports whether the chat is a tombstone for a deleted chat.
+func (c Chat) Deleted() bool {
+	return !c.Active
+}
+
+//Deleted reports whether the subscription is a tombstone for a cancelled subscription.
+func (s Subscription) Deleted() bool {
+	return !s.Active
+}
+
+//Deleted reports whether the contact is a tombstone for a deleted contact.
+func (c Contact) Deleted() bool {
+	return !c.Active
+}
+
+//listCall builds the call for a list endpoint from its base query and options, filtering to active records by default
+func listCall(resource string, q url.Values, opts []ListOption) string {
+	return query(resource, listQuery(q, opts))
+}
+
+//listQuery applies the list options to the base query
+func listQuery(q url.Values, opts []ListOption) url.Values {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("active", "true")
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
@@ -0,0 +1,62 @@
+package pushbullet
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestListLimit(t *testing.T) {
+	var queries []url.Values
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	c.GetPushHistory(1412047948.579031, Limit(5))
+	c.GetDevices(Limit(5))
+	c.GetChats(Limit(5))
+	c.ListSubscriptions(Limit(5))
+	c.GetContacts(Limit(5))
+	if len(queries) != 5 {
+		t.Fatal("Expected five calls, got:", len(queries))
+	}
+	for _, q := range queries {
+		if q.Get("limit") != "5" {
+			t.Error("Limit not sent:", q)
+		}
+	}
+	if queries[0].Get("modified_after") != "1412047948.579031" {
+		t.Error("modified_after lost or truncated:", queries[0])
+	}
+
+	queries = nil
+	c.GetDevices()
+	c.GetContacts()
+	for _, q := range queries {
+		if len(q) != 1 || q.Get("active") != "true" {
+			t.Error("Unexpected query without options:", q)
+		}
+	}
+}
+
+func TestIncludeInactive(t *testing.T) {
+	var query url.Values
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"devices": [{"iden": "d1", "active": true}, {"iden": "d2", "active": false}]}`))
+	})
+	defer server.Close()
+
+	d, err := c.GetDevices(IncludeInactive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, filtered := query["active"]; filtered {
+		t.Error("active filter sent with IncludeInactive:", query)
+	}
+	if d.Devices[0].Deleted() || !d.Devices[1].Deleted() {
+		t.Error("Deleted() does not reflect active flag:", d.Devices)
+	}
+}
@@ -0,0 +1,53 @@
+package pushbullet
+
+import (
+	"fmt"
+	"log"
+)
+
+//LogLevel controls how much a Client logs.
+type LogLevel int
+
+//Log levels, each including the output of those before it
+const (
+	LogSilent LogLevel = iota // nothing is logged
+	LogWarn                   // failed API calls
+	LogDebug                  // every request and response
+)
+
+//WithLogLevel sets the client's log level.
+func WithLogLevel(level LogLevel) Option {
+	return func(c *Client) {
+		c.LogLevel = level
+	}
+}
+
+//WithLogger sends the client's log output to l rather than the standard logger.
+func WithLogger(l *log.Logger) Option {
+	return func(c *Client) {
+		c.Logger = l
+	}
+}
+
+//warn logs a failure when the log level is LogWarn or above
+func (c *Client) warn(v ...interface{}) {
+	if c.LogLevel >= LogWarn {
+		c.output(fmt.Sprintln(v...))
+	}
+}
+
+//debugf logs call tracing when the log level is LogDebug
+func (c *Client) debugf(format string, v ...interface{}) {
+	if c.LogLevel >= LogDebug {
+		c.output(fmt.Sprintf(format, v...))
+	}
+}
+
+func (c *Client) output(s string) {
+	s = c.redact(s)
+	if c.Logger != nil {
+		c.Logger.Output(3, s)
+		return
+	}
+	log.Output(3, s)
+}
@@ -0,0 +1,44 @@
+package pushbullet
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogLevels(t *testing.T) {
+	mockServer, c := mockHTTP(401, `{"error": {"type": "invalid_request", "message": "bad key"}}`)
+	defer mockServer.Close()
+	var buf bytes.Buffer
+	c.Logger = log.New(&buf, "", 0)
+
+	c.SendNote("Build Test", "silent")
+	if buf.Len() != 0 {
+		t.Error("Silent client logged:", buf.String())
+	}
+
+	c.LogLevel = LogWarn
+	c.SendNote("Build Test", "warn")
+	if !strings.HasPrefix(buf.String(), "Failed to send note: Status code: 401 Invalid Request: bad key") ||
+		strings.Contains(buf.String(), "-->") {
+		t.Error("Unexpected warn output:", buf.String())
+	}
+
+	buf.Reset()
+	c.GetDevices()
+	if !strings.HasPrefix(buf.String(), "Failed to get devices: Status code: 401") {
+		t.Error("Unexpected spacing in warn output:", buf.String())
+	}
+
+	buf.Reset()
+	c.LogLevel = LogDebug
+	c.SendNote("Build Test", "debug")
+	out := buf.String()
+	if !strings.Contains(out, "--> POST") || !strings.Contains(out, `"body":"debug"`) || !strings.Contains(out, "<-- POST") {
+		t.Error("Debug output missing call trace:", out)
+	}
+	if strings.Contains(out, "apikey") {
+		t.Error("Debug output contains the API key")
+	}
+}
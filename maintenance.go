@@ -0,0 +1,213 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+//MaintenanceWindow silences the events it matches between Start and End. Each non-empty condition must hold, as
+//for a Rule; a window with none matches every event.
+type MaintenanceWindow struct {
+	Name    string            `json:"name"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Source  []string          `json:"source,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Targets []Target          `json:"targets,omitempty"` // routes silenced; all of them when empty
+	// Digest hands matching events to the Maintenance's Digester instead of dropping them.
+	Digest bool `json:"digest,omitempty"`
+}
+
+//Active reports whether the window is in effect at t.
+func (w MaintenanceWindow) Active(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+//Matches reports whether the window silences the event's push to target.
+func (w MaintenanceWindow) Matches(e Event, target Target) bool {
+	if !(Rule{Source: w.Source, Labels: w.Labels}).Matches(e) {
+		return false
+	}
+	return len(w.Targets) == 0 || containsTarget(w.Targets, target)
+}
+
+func containsTarget(targets []Target, t Target) bool {
+	for _, c := range targets {
+		if c == t {
+			return true
+		}
+	}
+	return false
+}
+
+//DefaultAuditSize is the number of audit records a Maintenance keeps when AuditSize is not set.
+const DefaultAuditSize = 1000
+
+//Suppression is the audit record of an event whose pushes maintenance windows silenced.
+type Suppression struct {
+	Windows  []string  `json:"windows"`
+	At       time.Time `json:"at"`
+	Title    string    `json:"title"`
+	Source   string    `json:"source,omitempty"`
+	Targets  []Target  `json:"targets"`
+	Digested bool      `json:"digested,omitempty"`
+}
+
+//Maintenance holds the maintenance windows of a Router, which skips the pushes they silence.
+//
+//	m := pushbullet.NewMaintenance(pushbullet.NewDigester(client))
+//	m.Add(pushbullet.MaintenanceWindow{Name: "db upgrade", Start: start, End: start.Add(time.Hour),
+//		Labels: map[string]string{"service": "db"}, Digest: true})
+//	router.Maintenance = m
+type Maintenance struct {
+	// Digester receives the events of windows with Digest set; they are dropped when it is nil.
+	Digester *Digester
+	// OnSuppress, when set, is called with each audit record, e.g. to persist it.
+	OnSuppress func(Suppression)
+	// AuditSize is the number of audit records kept, the oldest being dropped first; DefaultAuditSize when zero.
+	AuditSize int
+
+	mu        sync.Mutex
+	windows   []MaintenanceWindow
+	audit     []Suppression // a ring once full, with the oldest record at auditNext
+	auditNext int
+	now       func() time.Time
+}
+
+//NewMaintenance returns a Maintenance digesting to d, which may be nil.
+func NewMaintenance(d *Digester) *Maintenance {
+	return &Maintenance{Digester: d}
+}
+
+//Add declares a maintenance window, replacing any window of the same name.
+func (m *Maintenance) Add(w MaintenanceWindow) error {
+	if len(w.Name) == 0 {
+		return errors.New("Maintenance window has no name")
+	}
+	if !w.End.After(w.Start) {
+		return errors.New("Maintenance window ends before it starts")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeLocked(w.Name)
+	m.windows = append(m.windows, w)
+	return nil
+}
+
+//Remove ends the named maintenance window early, reporting whether there was one.
+func (m *Maintenance) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.removeLocked(name)
+}
+
+func (m *Maintenance) removeLocked(name string) bool {
+	for i, w := range m.windows {
+		if w.Name == name {
+			m.windows = append(m.windows[:i], m.windows[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//Windows returns the current and upcoming maintenance windows; expired ones are forgotten.
+func (m *Maintenance) Windows() []MaintenanceWindow {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked(m.clock())
+	return append([]MaintenanceWindow(nil), m.windows...)
+}
+
+func (m *Maintenance) pruneLocked(now time.Time) {
+	kept := m.windows[:0]
+	for _, w := range m.windows {
+		if now.Before(w.End) {
+			kept = append(kept, w)
+		}
+	}
+	m.windows = kept
+}
+
+//Audit returns the most recent records of what was suppressed, oldest first.
+func (m *Maintenance) Audit() []Suppression {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append(append([]Suppression(nil), m.audit[m.auditNext:]...), m.audit[:m.auditNext]...)
+}
+
+//Suppress returns the targets of the event whose pushes an active window silences. When there are any, the event
+//is recorded in the audit once, and digested once if one of the windows digests.
+func (m *Maintenance) Suppress(e Event, targets ...Target) []Target {
+	m.mu.Lock()
+	now := m.clock()
+	m.pruneLocked(now)
+	var s Suppression
+	for _, t := range targets {
+		for _, w := range m.windows {
+			if !w.Active(now) || !w.Matches(e, t) {
+				continue
+			}
+			s.Targets = append(s.Targets, t)
+			if !containsString(s.Windows, w.Name) {
+				s.Windows = append(s.Windows, w.Name)
+			}
+			s.Digested = s.Digested || (w.Digest && m.Digester != nil)
+			break
+		}
+	}
+	if len(s.Targets) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	s.At, s.Title, s.Source = now, e.Title, e.Source
+	m.recordLocked(s)
+	m.mu.Unlock()
+
+	if s.Digested {
+		m.Digester.Notify(context.Background(), e.Title, e.Body)
+	}
+	if m.OnSuppress != nil {
+		m.OnSuppress(s)
+	}
+	return s.Targets
+}
+
+//recordLocked adds the record to the audit, overwriting the oldest once AuditSize records are held
+func (m *Maintenance) recordLocked(s Suppression) {
+	size := m.AuditSize
+	if size <= 0 {
+		size = DefaultAuditSize
+	}
+	if m.auditNext > 0 && len(m.audit) != size {
+		// AuditSize changed since the ring filled: put the records back in order before resizing
+		m.audit, m.auditNext = append(append([]Suppression(nil), m.audit[m.auditNext:]...), m.audit[:m.auditNext]...), 0
+	}
+	if len(m.audit) > size {
+		m.audit = append([]Suppression(nil), m.audit[len(m.audit)-size:]...)
+	}
+	if len(m.audit) < size {
+		m.audit = append(m.audit, s)
+		return
+	}
+	m.audit[m.auditNext] = s
+	m.auditNext = (m.auditNext + 1) % size
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Maintenance) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,85 @@
+package pushbullet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceSuppression(t *testing.T) {
+	rec := &RecordingNotifier{}
+	m := NewMaintenance(NewDigester(rec))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	phone, team := DeviceTarget("phone"), ChannelTarget("team")
+	if err := m.Add(MaintenanceWindow{Name: "db upgrade", Start: now.Add(-time.Minute), End: now.Add(time.Hour),
+		Labels: map[string]string{"service": "db"}, Targets: []Target{team}, Digest: true}); err != nil {
+		t.Fatal(err)
+	}
+	m.Add(MaintenanceWindow{Name: "later", Start: now.Add(2 * time.Hour), End: now.Add(3 * time.Hour)})
+	if err := m.Add(MaintenanceWindow{Name: "backwards", Start: now, End: now}); err == nil {
+		t.Error("Expected an error for an empty window")
+	}
+
+	r := &Router{Default: []Target{phone, team}, Maintenance: m}
+	db := Event{Title: "db slow", Labels: map[string]string{"service": "db"}}
+	if p := r.Route(db); len(p) != 1 || p[0].DeviceID != "phone" {
+		t.Errorf("Expected only the team channel to be silenced: %+v", p)
+	}
+	if p := r.Route(Event{Title: "web slow"}); len(p) != 2 {
+		t.Errorf("Unmatched event silenced: %+v", p)
+	}
+
+	audit := m.Audit()
+	if len(audit) != 1 || audit[0].Windows[0] != "db upgrade" || len(audit[0].Targets) != 1 || audit[0].Targets[0] != team ||
+		!audit[0].Digested || !audit[0].At.Equal(now) {
+		t.Fatalf("Unexpected audit: %+v", audit)
+	}
+	m.Digester.Flush(context.Background())
+	if p := rec.Pushes(); len(p) != 1 || p[0].Title != "Digest: 1 notification" {
+		t.Errorf("Expected the suppressed event to be digested: %+v", p)
+	}
+
+	now = now.Add(90 * time.Minute)
+	if w := m.Windows(); len(w) != 1 || w[0].Name != "later" {
+		t.Errorf("Expected the expired window to be forgotten: %+v", w)
+	}
+	now = now.Add(time.Hour)
+	if p := r.Route(db); len(p) != 0 {
+		t.Errorf("Expected everything silenced: %+v", p)
+	}
+	if a := m.Audit(); len(a) != 2 || len(a[1].Targets) != 2 || a[1].Digested {
+		t.Errorf("Expected one audit record for both targets: %+v", a)
+	}
+	if !m.Remove("later") || len(r.Route(db)) != 2 {
+		t.Error("Expected removing the window to end it")
+	}
+}
+
+func TestMaintenanceDigestsOncePerEvent(t *testing.T) {
+	rec := &RecordingNotifier{}
+	m := NewMaintenance(NewDigester(rec))
+	m.AuditSize = 2
+	now := time.Now()
+	m.Add(MaintenanceWindow{Name: "all", Start: now.Add(-time.Minute), End: now.Add(time.Hour), Digest: true})
+	r := &Router{Default: []Target{DeviceTarget("phone"), ChannelTarget("team")}, Maintenance: m}
+
+	for _, title := range []string{"first", "second", "third"} {
+		if p := r.Route(Event{Title: title}); len(p) != 0 {
+			t.Errorf("Expected %s silenced: %+v", title, p)
+		}
+	}
+	a := m.Audit()
+	if len(a) != 2 || a[0].Title != "second" || a[1].Title != "third" || len(a[1].Targets) != 2 {
+		t.Errorf("Expected the two newest records: %+v", a)
+	}
+	m.Digester.Flush(context.Background())
+	if p := rec.Pushes(); len(p) != 1 || p[0].Title != "Digest: 3 notifications" {
+		t.Errorf("Expected each event digested once: %+v", p)
+	}
+	m.AuditSize = 3
+	r.Route(Event{Title: "fourth"})
+	if a := m.Audit(); len(a) != 3 || a[0].Title != "second" || a[2].Title != "fourth" {
+		t.Errorf("Audit not kept in order when resized: %+v", a)
+	}
+}
@@ -0,0 +1,140 @@
+package pushbullet
+
+import (
+	"regexp"
+	"strings"
+)
+
+//mdEscapeBlock is the size of a run of private use characters standing in for escaped ASCII while inline markup is removed
+const mdEscapeBlock = 128
+
+//mdPrivateUse lists the private use ranges searched for a block absent from the input
+var mdPrivateUse = [][2]rune{{0xE000, 0xF900}, {0xF0000, 0xFFFFE}, {0x100000, 0x10FFFE}}
+
+var (
+	mdFence    = regexp.MustCompile("^\\s*(```|~~~)")
+	mdHeading  = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdRule     = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	mdQuote    = regexp.MustCompile(`^\s{0,3}>\s?`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	mdAutolink = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	mdCode     = regexp.MustCompile("`([^`]+)`")
+	mdStrong   = []*regexp.Regexp{regexp.MustCompile(`\*\*(\S(?:.*?\S)??)\*\*`), regexp.MustCompile(`__(\S(?:.*?\S)??)__`)}
+	mdEmphasis = []*regexp.Regexp{
+		regexp.MustCompile(`(^|[^\w*])\*(\S(?:[^*]*?\S)??)\*($|[^\w*])`),
+		regexp.MustCompile(`(^|[^\w_])_(\S(?:[^_]*?\S)??)_($|[^\w_])`),
+	}
+	mdStrike    = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	mdEscape    = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!>~|])`)
+	mdBlankRuns = regexp.MustCompile(`\n{3,}`)
+)
+
+//MarkdownToText normalizes Markdown into plain text suitable for a push body, which devices display verbatim:
+//links become "text (url)", bullets become "•", and heading, emphasis, quote, and code markers are removed.
+func MarkdownToText(md string) string {
+	lines := strings.Split(strings.Replace(md, "\r\n", "\n", -1), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if mdFence.MatchString(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+		if mdRule.MatchString(line) {
+			out = append(out, "")
+			continue
+		}
+		line = mdQuote.ReplaceAllString(line, "")
+		if m := mdHeading.FindStringSubmatch(line); m != nil {
+			line = m[1]
+		}
+		line = mdBullet.ReplaceAllString(line, "$1• ")
+		out = append(out, markdownInline(line))
+	}
+	text := strings.Join(out, "\n")
+	return strings.TrimSpace(mdBlankRuns.ReplaceAllString(text, "\n\n"))
+}
+
+//markdownInline strips inline markup from a single line, leaving code spans untouched
+func markdownInline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range mdCode.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(markdownSpan(line[last:loc[0]]))
+		b.WriteString(line[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	b.WriteString(markdownSpan(line[last:]))
+	return b.String()
+}
+
+func markdownSpan(s string) string {
+	// hide escaped characters from the markup patterns, restoring them unescaped afterwards
+	base, ok := mdEscapeBase(s)
+	if ok {
+		s = mdEscape.ReplaceAllStringFunc(s, func(m string) string {
+			return string(base + rune(m[1]))
+		})
+	}
+	s = mdImage.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdImage.FindStringSubmatch(m)
+		if len(sub[1]) == 0 {
+			return sub[2]
+		}
+		return sub[1] + " (" + sub[2] + ")"
+	})
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLink.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return sub[2]
+		}
+		return sub[1] + " (" + sub[2] + ")"
+	})
+	s = mdAutolink.ReplaceAllString(s, "$1")
+	for _, re := range mdStrong {
+		s = re.ReplaceAllString(s, "$1")
+	}
+	s = mdStrike.ReplaceAllString(s, "$1")
+	for _, re := range mdEmphasis {
+		// each match consumes the character after it, so adjacent spans take another pass
+		for prev := ""; prev != s; {
+			prev, s = s, re.ReplaceAllString(s, "$1$2$3")
+		}
+	}
+	if !ok {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= base && r < base+mdEscapeBlock {
+			return r - base
+		}
+		return r
+	}, s)
+}
+
+//mdEscapeBase finds a block of private use characters which does not occur in s
+func mdEscapeBase(s string) (rune, bool) {
+	used := make(map[rune]bool)
+	for _, r := range s {
+		used[r/mdEscapeBlock] = true
+	}
+	for _, pua := range mdPrivateUse {
+		for base := pua[0]; base+mdEscapeBlock <= pua[1]; base += mdEscapeBlock {
+			if !used[base/mdEscapeBlock] {
+				return base, true
+			}
+		}
+	}
+	return 0, false
+}
+
+//WithMarkdownBodies converts Markdown push bodies to plain text before sending.
+func WithMarkdownBodies() Option {
+	return WithBodyFilter(MarkdownToText)
+}
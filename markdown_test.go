@@ -0,0 +1,56 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMarkdownToText(t *testing.T) {
+	cases := map[string]string{
+		"# Deploy *finished*":                         "Deploy finished",
+		"See [the log](https://ci.example.com/1) now": "See the log (https://ci.example.com/1) now",
+		"![graph](https://example.com/g.png)":         "graph (https://example.com/g.png)",
+		"<https://example.com>":                       "https://example.com",
+		"**bold** and _italic_ and ~~gone~~":          "bold and italic and gone",
+		"snake_case_name stays":                       "snake_case_name stays",
+		"- one\n* two\n  + nested\n1. ordered":        "• one\n• two\n  • nested\n1. ordered",
+		"> quoted **text**":                           "quoted text",
+		"run `go test ./...` then `**not bold**`":     "run go test ./... then **not bold**",
+		"```\nfunc main() {\n\t*x = 1\n}\n```":        "func main() {\n\t*x = 1\n}",
+		"above\n\n---\n\n\nbelow":                     "above\n\nbelow",
+		"price \\*not emphasis\\*":                    "price *not emphasis*",
+		"[https://example.com](https://example.com)":  "https://example.com",
+		"**a** **b**":                                 "a b",
+		"__a__ and __b__":                             "a and b",
+		"_a_ _b_":                                     "a b",
+		"*a* *b* *c*":                                 "a b c",
+		"\ue02a stays \\*":                            "\ue02a stays *",
+	}
+	for md, want := range cases {
+		if got := MarkdownToText(md); got != want {
+			t.Errorf("MarkdownToText(%q) = %q, want %q", md, got, want)
+		}
+	}
+}
+
+func TestMarkdownBodiesOption(t *testing.T) {
+	var sent PushMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	c := ClientWithKey("apikey", WithMarkdownBodies())
+	c.BaseURL = server.URL + "/"
+
+	if err := c.SendNote("Build Test", "**Done** in [CI](https://ci.example.com)"); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Body != "Done in CI (https://ci.example.com)" {
+		t.Error("Body filter not applied:", sent.Body)
+	}
+}
@@ -0,0 +1,71 @@
+package pushbullet
+
+import (
+	"context"
+	"strings"
+)
+
+//MigrationReport describes the outcome of MigrateContactsToChats.
+type MigrationReport struct {
+	Created []Chat       // chats started for contacts which had none
+	Skipped []Contact    // contacts which already had a chat
+	Failed  []ContactErr // contacts whose chat could not be created
+}
+
+//ContactErr pairs a contact with the error encountered while migrating it.
+type ContactErr struct {
+	Contact Contact
+	Err     error
+}
+
+//MigrateContactsToChats starts a chat for every active legacy contact which does not already have one, easing the
+//move off the deprecated contacts endpoint. Failures for individual contacts are recorded in the report and do
+//not stop the migration; the error is only set when the contacts or chats cannot be listed or ctx is done. Being
+//the way off the contacts endpoint, it reads them even with StrictDeprecations set.
+//Contacts are left in place and may be removed with DeleteContact once the chats are confirmed.
+func (c *Client) MigrateContactsToChats(ctx context.Context) (MigrationReport, error) {
+	var report MigrationReport
+	contacts, _, err := c.allContacts(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	chats, _, err := c.AllChats(ctx)
+	if err != nil {
+		return report, err
+	}
+	existing := make(map[string]bool, len(chats))
+	for _, chat := range chats {
+		existing[chatKey(chat.With.Email, chat.With.EmailNormalized)] = true
+	}
+
+	for _, contact := range contacts {
+		key := chatKey(contact.Email, contact.EmailNormalized)
+		if existing[key] {
+			report.Skipped = append(report.Skipped, contact)
+			continue
+		}
+		chat, err := c.CreateChatContext(ctx, contact.Email)
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		if err != nil {
+			report.Failed = append(report.Failed, ContactErr{Contact: contact, Err: err})
+			continue
+		}
+		existing[key] = true
+		report.Created = append(report.Created, chat)
+	}
+	return report, nil
+}
+
+//chatKey identifies the address of a contact or chat, preferring the address as normalized by Pushbullet, which
+//also lowercases the local part
+func chatKey(email, normalized string) string {
+	if len(normalized) > 0 {
+		return normalized
+	}
+	if n, err := NormalizeEmail(email); err == nil {
+		email = n
+	}
+	return strings.ToLower(email)
+}
@@ -0,0 +1,59 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMigrateContactsToChats(t *testing.T) {
+	var created []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/contacts" && r.URL.Query().Get("cursor") == "":
+			fmt.Fprintln(w, `{"contacts": [
+				{"iden": "c1", "name": "Carmack", "email": "Carmack@idsoftware.com", "email_normalized": "carmack@idsoftware.com", "active": true},
+				{"iden": "c2", "name": "Elon", "email": "elon@tesla.com", "active": true}
+			], "cursor": "more"}`)
+		case r.URL.Path == "/contacts":
+			fmt.Fprintln(w, `{"contacts": [
+				{"iden": "c3", "name": "Broken", "email": "broken@example.com", "active": true},
+				{"iden": "c4", "name": "Ada", "email": "Ada@Example.com", "active": true}
+			]}`)
+		case r.URL.Path == "/chats" && r.Method == "GET" && r.URL.Query().Get("cursor") == "":
+			fmt.Fprintln(w, `{"chats": [{"iden": "ch1", "active": true, "with": {"email": "carmack@idsoftware.com", "email_normalized": "carmack@idsoftware.com"}}], "cursor": "more"}`)
+		case r.URL.Path == "/chats" && r.Method == "GET":
+			fmt.Fprintln(w, `{"chats": [{"iden": "ch2", "active": true, "with": {"email": "ada@example.com", "email_normalized": "ada@example.com"}}]}`)
+		case r.URL.Path == "/chats" && r.Method == "POST":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["email"] == "broken@example.com" {
+				w.WriteHeader(400)
+				fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "bad email"}}`)
+				return
+			}
+			created = append(created, req["email"])
+			fmt.Fprintf(w, `{"iden": "new", "active": true, "with": {"email": %q}}`, req["email"])
+		}
+	})
+	defer server.Close()
+
+	report, err := c.MigrateContactsToChats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0] != "elon@tesla.com" {
+		t.Error("Unexpected chats created:", created)
+	}
+	if len(report.Created) != 1 || report.Created[0].With.Email != "elon@tesla.com" {
+		t.Error("Created chat not reported:", report.Created)
+	}
+	if len(report.Skipped) != 2 || report.Skipped[0].ID != "c1" || report.Skipped[1].ID != "c4" {
+		t.Error("Existing chat not skipped:", report.Skipped)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Contact.ID != "c3" || report.Failed[0].Err == nil {
+		t.Error("Failure not reported:", report.Failed)
+	}
+}
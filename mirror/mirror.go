@@ -0,0 +1,163 @@
+//Package mirror forwards the desktop notifications of a Linux session to the user's other devices, the reverse of
+//the notification mirroring done by the Android app. Notifications are captured with dbus-monitor(1) watching the
+//org.freedesktop.Notifications interface.
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Notification is a desktop notification captured from the session bus.
+type Notification struct {
+	App     string
+	Icon    string
+	Summary string
+	Body    string
+}
+
+//Mirror forwards captured notifications through a client.
+//
+//	m := &mirror.Mirror{Client: client, SourceDeviceID: laptop.ID}
+//	err := m.Run(ctx)
+type Mirror struct {
+	Client *pushbullet.Client
+	// SourceDeviceID is the device the notifications are shown as coming from.
+	SourceDeviceID string
+	// Ignore lists application names which are not forwarded. Notifications from "Pushbullet", such as those
+	// shown by sink.Desktop, are never forwarded so mirrored pushes do not loop.
+	Ignore []string
+	// AsPush sends note pushes, kept in the push history, instead of ephemeral mirrors.
+	AsPush bool
+
+	userID string
+	serial int
+}
+
+//monitorCommand returns the command whose output Run parses
+var monitorCommand = func(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, "dbus-monitor", "--session", "interface='org.freedesktop.Notifications',member='Notify'")
+}
+
+//Run captures notifications until the context is done or dbus-monitor exits. Output which cannot be read stops
+//dbus-monitor, and its error is returned.
+func (m *Mirror) Run(ctx context.Context) error {
+	if !m.AsPush && len(m.userID) == 0 {
+		u, err := m.Client.GetUserContext(ctx)
+		if err != nil {
+			return err
+		}
+		m.userID = u.ID
+	}
+	cmd := monitorCommand(ctx)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	parseErr := Parse(out, func(n Notification) {
+		m.Forward(ctx, n)
+	})
+	if parseErr != nil {
+		// nothing reads its output any more, so it would block
+		cmd.Process.Kill()
+	}
+	err = cmd.Wait()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if parseErr != nil {
+		return fmt.Errorf("mirror: reading dbus-monitor output: %w", parseErr)
+	}
+	return err
+}
+
+//Forward sends a notification to the user's other devices, unless its application is ignored.
+func (m *Mirror) Forward(ctx context.Context, n Notification) error {
+	if strings.EqualFold(n.App, "Pushbullet") {
+		return nil
+	}
+	for _, app := range m.Ignore {
+		if strings.EqualFold(app, n.App) {
+			return nil
+		}
+	}
+	if m.AsPush {
+		title := n.Summary
+		if len(n.App) > 0 {
+			title = n.App + ": " + n.Summary
+		}
+		_, err := m.Client.SendPushContext(ctx, pushbullet.PushRequest{Type: "note", Title: title, Body: n.Body, SourceDeviceID: m.SourceDeviceID})
+		return err
+	}
+	m.serial++
+	return m.Client.SendMirror(ctx, pushbullet.MirrorNotification{
+		ApplicationName: n.App,
+		Title:           n.Summary,
+		Body:            n.Body,
+		SourceUserID:    m.userID,
+		SourceDeviceID:  m.SourceDeviceID,
+		NotificationID:  strconv.Itoa(m.serial),
+		PackageName:     n.App,
+		Dismissible:     true,
+	})
+}
+
+//Parse reads dbus-monitor output and calls fn with each Notify call. The arguments of Notify are the application
+//name, the id of a notification to replace, the icon, the summary, the body, the actions, the hints and the timeout.
+func Parse(r io.Reader, fn func(Notification)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var (
+		args    []string
+		inCall  bool
+		partial *strings.Builder // a string argument spanning lines
+	)
+	flush := func() {
+		if inCall && len(args) >= 4 {
+			fn(Notification{App: args[0], Icon: args[1], Summary: args[2], Body: args[3]})
+		}
+		args, inCall = nil, false
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if partial != nil {
+			partial.WriteString("\n")
+			if strings.HasSuffix(line, `"`) {
+				partial.WriteString(strings.TrimSuffix(line, `"`))
+				args = append(args, partial.String())
+				partial = nil
+			} else {
+				partial.WriteString(line)
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			flush()
+			inCall = strings.HasPrefix(line, "method call") && strings.Contains(line, "member=Notify")
+			continue
+		}
+		// only the top level string arguments, indented by three spaces, are wanted
+		if !inCall || !strings.HasPrefix(line, `   string "`) {
+			continue
+		}
+		value := strings.TrimPrefix(line, `   string "`)
+		if strings.HasSuffix(value, `"`) {
+			args = append(args, strings.TrimSuffix(value, `"`))
+		} else {
+			partial = &strings.Builder{}
+			partial.WriteString(value)
+		}
+	}
+	flush()
+	return scanner.Err()
+}
@@ -0,0 +1,125 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+const monitorOutput = `signal time=1430000000.1 sender=org.freedesktop.DBus -> destination=:1.42 serial=2 path=/org/freedesktop/DBus; interface=org.freedesktop.DBus; member=NameAcquired
+   string ":1.42"
+method call time=1430000001.2 sender=:1.23 -> destination=:1.5 serial=7 path=/org/freedesktop/Notifications; interface=org.freedesktop.Notifications; member=Notify
+   string "Thunderbird"
+   uint32 0
+   string "mail-unread"
+   string "New mail"
+   string "From: Bob
+Subject: lunch?"
+   array [
+      string "default"
+      string "Open"
+   ]
+   array [
+      dict entry(
+         string "urgency"
+         variant             byte 1
+      )
+   ]
+   int32 -1
+method call time=1430000002.3 sender=:1.24 -> destination=:1.5 serial=8 path=/org/freedesktop/Notifications; interface=org.freedesktop.Notifications; member=Notify
+   string "Pushbullet"
+   uint32 0
+   string ""
+   string "Mirrored"
+   string ""
+   array [
+   ]
+   array [
+   ]
+   int32 -1
+`
+
+func TestParse(t *testing.T) {
+	var got []Notification
+	if err := Parse(strings.NewReader(monitorOutput), func(n Notification) { got = append(got, n) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected two notifications, got: %+v", got)
+	}
+	want := Notification{App: "Thunderbird", Icon: "mail-unread", Summary: "New mail", Body: "From: Bob\nSubject: lunch?"}
+	if got[0] != want {
+		t.Errorf("Unexpected notification: %+v", got[0])
+	}
+}
+
+func TestForward(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	m := &Mirror{Client: server.Client(), SourceDeviceID: "laptop", Ignore: []string{"Slack"}, userID: "u1"}
+	ctx := context.Background()
+
+	m.Forward(ctx, Notification{App: "Thunderbird", Summary: "New mail", Body: "lunch?"})
+	m.Forward(ctx, Notification{App: "slack", Summary: "ignored"})
+	m.Forward(ctx, Notification{App: "Pushbullet", Summary: "loop"})
+	ephemerals := server.Ephemerals()
+	if len(ephemerals) != 1 || len(server.Pushes()) != 0 {
+		t.Fatalf("Unexpected forwarding: %s %v", ephemerals, server.Pushes())
+	}
+	var sent map[string]interface{}
+	json.Unmarshal(ephemerals[0], &sent)
+	push := sent["push"].(map[string]interface{})
+	if sent["type"] != "push" || push["type"] != "mirror" || push["title"] != "New mail" ||
+		push["source_user_iden"] != "u1" || push["source_device_iden"] != "laptop" {
+		t.Errorf("Unexpected ephemeral: %v", sent)
+	}
+
+	m.AsPush = true
+	m.Forward(ctx, Notification{App: "Thunderbird", Summary: "New mail", Body: "lunch?"})
+	if pushes := server.Pushes(); len(pushes) != 1 || pushes[0].Title != "Thunderbird: New mail" {
+		t.Errorf("Unexpected push: %+v", pushes)
+	}
+}
+
+// TestMonitorHelper stands in for dbus-monitor when run by TestRunReportsUnreadableOutput, printing a line too long
+// to parse and then waiting to be killed
+func TestMonitorHelper(t *testing.T) {
+	if os.Getenv("MIRROR_MONITOR_HELPER") != "1" {
+		return
+	}
+	fmt.Print(monitorOutput)
+	fmt.Println(strings.Repeat("x", 2<<20))
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func TestRunReportsUnreadableOutput(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	orig := monitorCommand
+	defer func() { monitorCommand = orig }()
+	monitorCommand = func(ctx context.Context) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^TestMonitorHelper$")
+		cmd.Env = append(os.Environ(), "MIRROR_MONITOR_HELPER=1")
+		return cmd
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	m := &Mirror{Client: server.Client(), userID: "u1"}
+	if err := m.Run(ctx); !errors.Is(err, bufio.ErrTooLong) || ctx.Err() != nil {
+		t.Fatal("Expected the parse error returned once dbus-monitor is stopped:", err)
+	}
+	if len(server.Ephemerals()) == 0 {
+		t.Error("Expected the notifications before the long line forwarded")
+	}
+}
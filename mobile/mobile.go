@@ -0,0 +1,256 @@
+//Package mobile is a facade over the client for Android and iOS companion apps built with gomobile bind. Its
+//signatures use only strings, numbers, booleans and pointers to the package's own types; lists are exposed through
+//Len and Get, and changes are reported through callback interfaces rather than channels.
+//
+//	gomobile bind -target=android github.com/kariudo/gopushbullet/mobile
+package mobile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Client sends and lists pushes for one account.
+type Client struct {
+	c      *pushbullet.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+//NewClient returns a client for the access token.
+func NewClient(token string) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Client{c: pushbullet.ClientWithKey(token), ctx: ctx, cancel: cancel}
+}
+
+//Close cancels the client's requests in flight and stops its watchers; later calls fail.
+func (c *Client) Close() {
+	c.cancel()
+}
+
+//SetAPIRoot points the client at another API root, such as a test server.
+func (c *Client) SetAPIRoot(root string) {
+	pushbullet.WithAPI(root, "")(c.c)
+}
+
+//SetTimeoutSeconds bounds each request; zero removes the bound.
+func (c *Client) SetTimeoutSeconds(seconds int) {
+	c.c.HTTPClient.Timeout = time.Duration(seconds) * time.Second
+}
+
+//User is the account the client acts for.
+type User struct {
+	Iden     string
+	Email    string
+	Name     string
+	ImageURL string
+}
+
+//Me returns the account the client acts for.
+func (c *Client) Me() (*User, error) {
+	u, err := c.c.CurrentUserContext(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &User{Iden: u.ID, Email: u.Email, Name: u.Name, ImageURL: u.ImageURL}, nil
+}
+
+//SendNote pushes a note, to the device with the iden or to all devices when deviceIden is empty.
+func (c *Client) SendNote(deviceIden, title, body string) error {
+	_, err := c.c.SendPushContext(c.ctx, pushbullet.PushRequest{Type: "note", DeviceID: deviceIden, Title: title, Body: body})
+	return err
+}
+
+//SendLink pushes a link, to the device with the iden or to all devices when deviceIden is empty.
+func (c *Client) SendLink(deviceIden, title, body, url string) error {
+	_, err := c.c.SendPushContext(c.ctx, pushbullet.PushRequest{Type: "link", DeviceID: deviceIden, Title: title, Body: body, URL: url})
+	return err
+}
+
+//SendFile uploads the file at path and pushes it, to the device with the iden or to all devices when deviceIden is
+//empty.
+func (c *Client) SendFile(deviceIden, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	auth, err := c.c.Upload(c.ctx, f, path, "")
+	if err != nil {
+		return err
+	}
+	_, err = c.c.SendPushContext(c.ctx, pushbullet.PushRequest{Type: "file", DeviceID: deviceIden, FileName: auth.FileName,
+		FileType: auth.FileType, FileURL: auth.FileURL})
+	return err
+}
+
+//DismissPush dismisses the push with the iden.
+func (c *Client) DismissPush(iden string) error {
+	return c.c.DismissPushContext(c.ctx, iden)
+}
+
+//DeletePush deletes the push with the iden.
+func (c *Client) DeletePush(iden string) error {
+	return c.c.DeletePushContext(c.ctx, iden)
+}
+
+//Device is one of the account's devices.
+type Device struct {
+	Iden         string
+	Nickname     string
+	Manufacturer string
+	Model        string
+	Pushable     bool
+}
+
+//DeviceList is a list of devices.
+type DeviceList struct {
+	devices []*Device
+}
+
+//Len returns the number of devices.
+func (l *DeviceList) Len() int { return len(l.devices) }
+
+//Get returns the device at index i, or nil when out of range.
+func (l *DeviceList) Get(i int) *Device {
+	if i < 0 || i >= len(l.devices) {
+		return nil
+	}
+	return l.devices[i]
+}
+
+//Devices returns the active devices.
+func (c *Client) Devices() (*DeviceList, error) {
+	all, _, err := c.c.AllDevices(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	l := &DeviceList{}
+	for _, d := range all {
+		l.devices = append(l.devices, newDevice(d))
+	}
+	return l, nil
+}
+
+func newDevice(d pushbullet.Device) *Device {
+	return &Device{Iden: d.ID, Nickname: d.Nickname, Manufacturer: d.Manufacturer, Model: d.Model, Pushable: d.Pushable}
+}
+
+//Push is a push in the account's history.
+type Push struct {
+	Iden       string
+	Type       string // note, link or file
+	Title      string
+	Body       string
+	URL        string
+	FileName   string
+	FileURL    string
+	ImageURL   string
+	SenderName string
+	DeviceIden string // the target device, empty for all devices
+	Created    float64
+	Modified   float64
+	Dismissed  bool
+	Deleted    bool
+}
+
+func newPush(p pushbullet.PushMessage) *Push {
+	return &Push{Iden: p.ID, Type: p.Type, Title: p.Title, Body: p.Body, URL: p.URL, FileName: p.FileName,
+		FileURL: p.FileURL, ImageURL: p.ImageURL, SenderName: p.SenderName, DeviceIden: p.DeviceID,
+		Created: p.Created, Modified: p.Modified, Dismissed: p.Dismissed, Deleted: !p.Active}
+}
+
+//PushList is a list of pushes.
+type PushList struct {
+	pushes []*Push
+}
+
+//Len returns the number of pushes.
+func (l *PushList) Len() int { return len(l.pushes) }
+
+//Get returns the push at index i, or nil when out of range.
+func (l *PushList) Get(i int) *Push {
+	if i < 0 || i >= len(l.pushes) {
+		return nil
+	}
+	return l.pushes[i]
+}
+
+//Pushes returns the pushes modified after the timestamp, newest first.
+func (c *Client) Pushes(modifiedAfter float64) (*PushList, error) {
+	history, err := c.c.GetPushHistoryContext(c.ctx, modifiedAfter)
+	if err != nil {
+		return nil, err
+	}
+	l := &PushList{}
+	for _, p := range history {
+		l.pushes = append(l.pushes, newPush(p))
+	}
+	return l, nil
+}
+
+//ChangeHandler receives the changes a Watcher sees. Change types are "added", "updated" and "deleted". Calls come
+//from a background goroutine, one at a time.
+type ChangeHandler interface {
+	OnPush(change string, p *Push)
+	OnDevice(change string, d *Device)
+	OnError(message string) // a message suitable for showing users
+}
+
+//Watcher polls the account for changes until stopped.
+type Watcher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+//Watch reports changes to pushes and devices made after it starts to the handler, polling every intervalSeconds.
+func (c *Client) Watch(handler ChangeHandler, intervalSeconds int) (*Watcher, error) {
+	if handler == nil {
+		return nil, errors.New("No change handler")
+	}
+	if intervalSeconds <= 0 {
+		return nil, errors.New("Invalid watch interval")
+	}
+	s := pushbullet.NewSync(c.c, func(e pushbullet.ChangeEvent) {
+		if e.Initial {
+			return
+		}
+		switch {
+		case e.Push != nil:
+			handler.OnPush(e.Type.String(), newPush(*e.Push))
+		case e.Device != nil:
+			handler.OnDevice(e.Type.String(), newDevice(*e.Device))
+		}
+	})
+	s.PushesSince = float64(time.Now().UnixNano()) / 1e9
+	ctx, cancel := context.WithCancel(c.ctx)
+	w := &Watcher{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		t := time.NewTicker(time.Duration(intervalSeconds) * time.Second)
+		defer t.Stop()
+		for {
+			if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+				handler.OnError(c.c.Describe(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return w, nil
+}
+
+//Stop ends the watch, returning once no further callbacks will be made.
+func (w *Watcher) Stop() {
+	w.once.Do(w.cancel)
+	<-w.done
+}
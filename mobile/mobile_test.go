@@ -0,0 +1,85 @@
+package mobile
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+type recordingHandler struct {
+	mu      sync.Mutex
+	changes []string
+	errors  []string
+}
+
+func (h *recordingHandler) OnPush(change string, p *Push) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changes = append(h.changes, change+" push "+p.Title)
+}
+
+func (h *recordingHandler) OnDevice(change string, d *Device) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.changes = append(h.changes, change+" device "+d.Nickname)
+}
+
+func (h *recordingHandler) OnError(message string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errors = append(h.errors, message)
+}
+
+func TestFacade(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	server.SetUser(pushbullet.User{ID: "u1", Email: "elon@teslamotors.com", Name: "Elon Musk", Active: true})
+	server.AddDevice(pushbullet.Device{ID: "d1", Nickname: "Phone", Pushable: true})
+	c := NewClient(pushbullettest.APIKey)
+	c.SetAPIRoot(server.URL)
+	c.SetTimeoutSeconds(5)
+
+	if u, err := c.Me(); err != nil || u.Name != "Elon Musk" {
+		t.Fatal("Unexpected user:", u, err)
+	}
+	devices, err := c.Devices()
+	if err != nil || devices.Len() != 1 || devices.Get(0).Nickname != "Phone" || devices.Get(1) != nil {
+		t.Fatal("Unexpected devices:", devices, err)
+	}
+	if err := c.SendNote("d1", "Hello", "from the app"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Pushes(); len(sent) != 1 || sent[0].DeviceID != "d1" || sent[0].Type != "note" {
+		t.Errorf("Unexpected push: %+v", sent)
+	}
+
+	h := &recordingHandler{}
+	w, err := c.Watch(h, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if err := server.Client().SendNote("Ping", ""); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1200 * time.Millisecond)
+	w.Stop()
+	w.Stop()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if fmt.Sprint(h.changes) != "[added push Ping]" || len(h.errors) != 0 {
+		t.Errorf("Unexpected changes: %v, errors: %v", h.changes, h.errors)
+	}
+	if _, err := c.Watch(nil, 1); err == nil {
+		t.Error("Expected an error without a handler")
+	}
+	c.Close()
+	if err := c.SendNote("d1", "Hello", "after close"); err == nil {
+		t.Error("Expected an error after Close")
+	}
+}
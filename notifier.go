@@ -0,0 +1,127 @@
+package pushbullet
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+//Notifier delivers a titled message. Applications may code against Notifier and swap or fan out
+//to other channels; Client implements it by sending a push.
+type Notifier interface {
+	Notify(ctx context.Context, title, body string, opts ...NotifyOption) error
+}
+
+//NotifyOption adjusts the push composed for a notification.
+type NotifyOption func(*PushMessage)
+
+//ToDevice targets the notification at a single device.
+func ToDevice(deviceID string) NotifyOption {
+	return func(p *PushMessage) { p.DeviceID = deviceID }
+}
+
+//ToEmail targets the notification at a user by email.
+func ToEmail(email string) NotifyOption {
+	return func(p *PushMessage) { p.Email = email }
+}
+
+//ToChannel targets the notification at the subscribers of an owned channel.
+func ToChannel(tag string) NotifyOption {
+	return func(p *PushMessage) { p.ChannelTag = tag }
+}
+
+//ToClient targets the notification at the users of an OAuth client.
+func ToClient(clientID string) NotifyOption {
+	return func(p *PushMessage) { p.ClientID = clientID }
+}
+
+//LinkURL sends the notification as a link push to url.
+func LinkURL(url string) NotifyOption {
+	return func(p *PushMessage) {
+		p.Type = "link"
+		p.URL = url
+	}
+}
+
+//NotifyPush composes the push message a notification is sent as.
+func NotifyPush(title, body string, opts ...NotifyOption) PushMessage {
+	p := PushMessage{Type: "note", Title: title, Body: body}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+//Notify sends the notification as a push, a note unless LinkURL is given, to all devices unless a target is given.
+func (c *Client) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	_, err := c.sendPush(ctx, NotifyPush(title, body, opts...))
+	return err
+}
+
+//NotifierFunc adapts a function into a Notifier.
+type NotifierFunc func(ctx context.Context, title, body string, opts ...NotifyOption) error
+
+//Notify calls f.
+func (f NotifierFunc) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	return f(ctx, title, body, opts...)
+}
+
+//MultiNotifier fans each notification out to every notifier concurrently.
+type MultiNotifier []Notifier
+
+//Notify delivers to every notifier, returning a NotifyErrors listing those which failed.
+func (m MultiNotifier) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs NotifyErrors
+	)
+	for _, n := range m {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+			if err := n.Notify(ctx, title, body, opts...); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//NotifyErrors collects the failures of a fanned out notification.
+type NotifyErrors []error
+
+func (e NotifyErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//RecordingNotifier records notifications instead of delivering them, for use in tests.
+type RecordingNotifier struct {
+	mu     sync.Mutex
+	pushes []PushMessage
+}
+
+//Notify records the push the notification would have been sent as.
+func (r *RecordingNotifier) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pushes = append(r.pushes, NotifyPush(title, body, opts...))
+	return nil
+}
+
+//Pushes returns a copy of the pushes recorded so far, safe to call while notifications are still arriving.
+func (r *RecordingNotifier) Pushes() []PushMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PushMessage(nil), r.pushes...)
+}
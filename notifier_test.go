@@ -0,0 +1,61 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestClientNotify(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"iden": "p1"}`)
+	defer mockServer.Close()
+
+	var n Notifier = c
+	if err := n.Notify(context.Background(), "Build Test", "This is a test of gopushbullet's Notify() function.", ToDevice("_deviceid_")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNotifyPushOptions(t *testing.T) {
+	p := NotifyPush("Deploy", "done", ToChannel("ops"), LinkURL("http://example.com"))
+	if p.Type != "link" || p.URL != "http://example.com" || p.ChannelTag != "ops" || p.Title != "Deploy" {
+		t.Errorf("Options not applied: %+v", p)
+	}
+}
+
+func TestMultiNotifier(t *testing.T) {
+	rec := &RecordingNotifier{}
+	failing := NotifierFunc(func(ctx context.Context, title, body string, opts ...NotifyOption) error {
+		return errors.New("channel down")
+	})
+	err := MultiNotifier{rec, failing}.Notify(context.Background(), "Deploy", "done")
+	if errs, ok := err.(NotifyErrors); !ok || len(errs) != 1 {
+		t.Error("Expected one notify error, got:", err)
+	}
+	if len(rec.Pushes()) != 1 || rec.Pushes()[0].Title != "Deploy" {
+		t.Error("Recording notifier missed the notification:", rec.Pushes())
+	}
+}
+
+func TestRecordingNotifierConcurrentReads(t *testing.T) {
+	rec := &RecordingNotifier{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rec.Notify(context.Background(), "Deploy", "done")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = len(rec.Pushes())
+		}()
+	}
+	wg.Wait()
+	pushes := rec.Pushes()
+	pushes[0].Title = "changed"
+	if len(pushes) != 10 || rec.Pushes()[0].Title != "Deploy" {
+		t.Error("Pushes did not return an independent copy of every notification")
+	}
+}
@@ -0,0 +1,63 @@
+package pushbullet
+
+import "time"
+
+//TargetSelector chooses targets at send time, such as the device of whoever is on call.
+type TargetSelector interface {
+	Select(now time.Time) []Target
+}
+
+//TargetSelectorFunc adapts a function into a TargetSelector.
+type TargetSelectorFunc func(now time.Time) []Target
+
+//Select calls f(now).
+func (f TargetSelectorFunc) Select(now time.Time) []Target {
+	return f(now)
+}
+
+//OnCallTarget stands for the targets of an Alerter's OnCall selector in the rules of its Router.
+func OnCallTarget() Target { return Target{Kind: "oncall"} }
+
+//Override hands the rotation to other targets for a while, such as cover for a holiday.
+type Override struct {
+	Start   time.Time
+	End     time.Time
+	Targets []Target
+}
+
+//Rotation is a TargetSelector cycling through Shifts, each on call for Length in turn from Start, with Overrides
+//taking precedence while they last.
+//
+//	week := 7 * 24 * time.Hour
+//	oncall := &pushbullet.Rotation{Start: monday9am, Length: week, Shifts: [][]pushbullet.Target{
+//		{pushbullet.DeviceTarget(alicePhone)},
+//		{pushbullet.DeviceTarget(bobPhone), pushbullet.EmailTarget("bob@example.com")},
+//	}}
+type Rotation struct {
+	Start     time.Time
+	Length    time.Duration
+	Shifts    [][]Target
+	Overrides []Override
+}
+
+//Select returns the targets on call at now.
+func (r *Rotation) Select(now time.Time) []Target {
+	for _, o := range r.Overrides {
+		if !now.Before(o.Start) && now.Before(o.End) {
+			return o.Targets
+		}
+	}
+	if len(r.Shifts) == 0 || r.Length <= 0 {
+		return nil
+	}
+	d := now.Sub(r.Start)
+	n := int64(d / r.Length)
+	if d < 0 && d%r.Length != 0 {
+		n--
+	}
+	shift := n % int64(len(r.Shifts))
+	if shift < 0 {
+		shift += int64(len(r.Shifts))
+	}
+	return r.Shifts[shift]
+}
@@ -0,0 +1,48 @@
+package pushbullet
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRotation(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	r := &Rotation{Start: start, Length: week, Shifts: [][]Target{{DeviceTarget("alice")}, {DeviceTarget("bob")}, {DeviceTarget("carol")}}}
+	r.Overrides = []Override{{Start: start.Add(15 * 24 * time.Hour), End: start.Add(16 * 24 * time.Hour), Targets: []Target{DeviceTarget("dave")}}}
+
+	for offset, want := range map[time.Duration]string{
+		0:                   "alice",
+		week - time.Second:  "alice",
+		week:                "bob",
+		3 * week:            "alice",
+		15*24*time.Hour + 1: "dave",
+		-time.Hour:          "carol",
+	} {
+		if got := r.Select(start.Add(offset)); len(got) != 1 || got[0].ID != want {
+			t.Errorf("At %v expected %s, got %v", offset, want, got)
+		}
+	}
+}
+
+func TestAlertResolvesOnCall(t *testing.T) {
+	oncall := "alice"
+	a := &Alerter{OnCall: TargetSelectorFunc(func(time.Time) []Target { return []Target{DeviceTarget(oncall)} })}
+	if p := a.route(SeverityError, "db down", ""); len(p) != 1 || p[0].DeviceID != "alice" {
+		t.Errorf("Unexpected pushes: %+v", p)
+	}
+
+	a.Router = &Router{Rules: []Rule{{Severity: []string{"critical"}, Targets: []Target{OnCallTarget(), ChannelTarget("team")}}}}
+	oncall = "bob"
+	var got []string
+	for _, p := range a.route(SeverityCritical, "db down", "") {
+		got = append(got, p.DeviceID+p.ChannelTag)
+	}
+	if fmt.Sprint(got) != "[bob team]" {
+		t.Error("Expected the on-call device to be resolved at send time:", got)
+	}
+	if len(a.Router.Rules[0].Targets) != 2 || a.Router.Rules[0].Targets[0] != OnCallTarget() {
+		t.Error("Router rules modified:", a.Router.Rules[0].Targets)
+	}
+}
@@ -0,0 +1,78 @@
+package pushbullet
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+//payloadMarker starts the line of a push body carrying an embedded payload
+const payloadMarker = "gopushbullet-payload:"
+
+//ErrNoPayload is returned by DecodePayload for pushes which carry no embedded payload.
+var ErrNoPayload = errors.New("No payload in push")
+
+//PayloadOption configures EncodePayload.
+type PayloadOption func(*payloadConfig)
+
+type payloadConfig struct {
+	base64 bool
+	text   string
+}
+
+//PayloadBase64 encodes the payload as base64, for channels which mangle JSON punctuation.
+func PayloadBase64() PayloadOption {
+	return func(c *payloadConfig) { c.base64 = true }
+}
+
+//PayloadText precedes the payload line with human readable text, shown on devices which display the push.
+func PayloadText(text string) PayloadOption {
+	return func(c *payloadConfig) { c.text = text }
+}
+
+//EncodePayload returns a push body embedding v as compact JSON behind a recognizable marker, so two programs can
+//exchange typed messages over Pushbullet. DecodePayload recovers it.
+func EncodePayload(v interface{}, opts ...PayloadOption) (string, error) {
+	var cfg payloadConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	line := payloadMarker + "json:" + string(data)
+	if cfg.base64 {
+		line = payloadMarker + "b64:" + base64.RawURLEncoding.EncodeToString(data)
+	}
+	if len(cfg.text) > 0 {
+		return cfg.text + "\n" + line, nil
+	}
+	return line, nil
+}
+
+//DecodePayload unmarshals the payload embedded in the push body by EncodePayload into v, returning ErrNoPayload
+//when there is none.
+func DecodePayload(p PushMessage, v interface{}) error {
+	lines := strings.Split(p.Body, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, payloadMarker) {
+			continue
+		}
+		line = strings.TrimPrefix(line, payloadMarker)
+		switch {
+		case strings.HasPrefix(line, "json:"):
+			return json.Unmarshal([]byte(strings.TrimPrefix(line, "json:")), v)
+		case strings.HasPrefix(line, "b64:"):
+			data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(line, "b64:"))
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, v)
+		}
+		return errors.New("Unknown payload encoding")
+	}
+	return ErrNoPayload
+}
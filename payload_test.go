@@ -0,0 +1,41 @@
+package pushbullet
+
+import (
+	"strings"
+	"testing"
+)
+
+type deployPayload struct {
+	Service string   `json:"service"`
+	Version int      `json:"version"`
+	Hosts   []string `json:"hosts"`
+}
+
+func TestPayloadRoundTrip(t *testing.T) {
+	in := deployPayload{Service: "api", Version: 42, Hosts: []string{"a", "b\nc"}}
+	for _, opts := range [][]PayloadOption{nil, {PayloadBase64()}, {PayloadText("Deploying api v42")}} {
+		body, err := EncodePayload(in, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out deployPayload
+		if err := DecodePayload(PushMessage{Type: "note", Body: body}, &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Service != in.Service || out.Version != in.Version || len(out.Hosts) != 2 || out.Hosts[1] != "b\nc" {
+			t.Errorf("Payload changed in transit: %+v from %q", out, body)
+		}
+	}
+
+	body, _ := EncodePayload(in, PayloadText("Deploying"))
+	if !strings.HasPrefix(body, "Deploying\ngopushbullet-payload:json:{") {
+		t.Error("Unexpected body:", body)
+	}
+}
+
+func TestDecodePayloadMissing(t *testing.T) {
+	var out deployPayload
+	if err := DecodePayload(PushMessage{Body: "just text"}, &out); err != ErrNoPayload {
+		t.Error("Expected ErrNoPayload, got:", err)
+	}
+}
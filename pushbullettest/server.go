@@ -0,0 +1,524 @@
+//Package pushbullettest provides a fake Pushbullet API for testing code built on the client, with configurable
+//latency and faults so retry, backoff and circuit breaker settings can be exercised deterministically.
+//
+//	srv := pushbullettest.NewServer()
+//	defer srv.Close()
+//	srv.SetFaults(pushbullettest.Faults{ErrorRate: 0.2, RateLimitEvery: 10, RateLimitBurst: 3, Seed: 1})
+//	client := srv.Client()
+//
+//Besides the user, device and push routes it accepts file uploads and serves a realtime stream that tickles on every
+//push change, so most of the client can be tested against it. Intercept stands in for whatever it does not model.
+package pushbullettest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//APIKey is the key the server accepts; requests with any other key are refused with 401.
+const APIKey = "pushbullettest"
+
+//Faults configures what goes wrong. The zero Faults answers every request promptly and correctly. Faults are
+//decided per request in arrival order from Seed, so a sequential test sees the same faults on every run.
+type Faults struct {
+	Latency time.Duration // added to every response
+	Jitter  time.Duration // up to this much more latency, at random
+	// ErrorRate is the fraction of requests answered with a 500 error.
+	ErrorRate float64
+	// RateLimitEvery starts a burst of RateLimitBurst 429 responses at every RateLimitEvery-th request, with the
+	// limit resetting RateLimitReset later (a second when zero).
+	RateLimitEvery int
+	RateLimitBurst int
+	RateLimitReset time.Duration
+	// MalformedRate is the fraction of successful requests answered with a truncated JSON body.
+	MalformedRate float64
+	Seed          int64
+}
+
+//Server is a fake Pushbullet API holding pushes, devices and uploaded files in memory.
+type Server struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	faults     Faults
+	rng        *rand.Rand
+	requests   int
+	burst      int // rate limited responses left in the current burst
+	calls      []string
+	intercept  []func(w http.ResponseWriter, r *http.Request) bool
+	user       pushbullet.User
+	devices    []pushbullet.Device
+	pushes     []pushbullet.PushMessage
+	ephemerals []json.RawMessage
+	files      map[string][]byte
+	streams    map[chan struct{}]bool
+	nextID     int
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+//NewServer starts a fake API without faults. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		rng:     rand.New(rand.NewSource(0)),
+		user:    pushbullet.User{ID: "user", Email: "user@example.com", Name: "Test User", Active: true, MaxUploadSize: 25 << 20},
+		files:   make(map[string][]byte),
+		streams: make(map[chan struct{}]bool),
+		closed:  make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+//Close ends any open streams and shuts the server down.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+	s.Server.Close()
+}
+
+//Client returns a client for the server and its stream.
+func (s *Server) Client() *pushbullet.Client {
+	return pushbullet.ClientWithKey(APIKey, pushbullet.WithAPI(s.URL+"/", ""), pushbullet.WithStreamURL(s.StreamURL()))
+}
+
+//StreamURL is the server's realtime stream, to which the API key is appended.
+func (s *Server) StreamURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/websocket/"
+}
+
+//Intercept lets f answer authorized API requests before the server does, e.g. to fail a particular call or serve a
+//route the server does not model. f returns false to leave the request to the server. Interceptors run in the order
+//added, after faults are decided but before they are applied.
+func (s *Server) Intercept(f func(w http.ResponseWriter, r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.intercept = append(s.intercept, f)
+}
+
+//SetUser replaces the signed in user, e.g. to lower its upload limit.
+func (s *Server) SetUser(u pushbullet.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = u
+}
+
+//SetFaults replaces the faults, restarting their random sequence from the Seed.
+func (s *Server) SetFaults(f Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = f
+	s.rng = rand.New(rand.NewSource(f.Seed))
+	s.requests = 0
+	s.burst = 0
+}
+
+//Requests returns the number of requests received since the faults were last set.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+//AddDevice registers a device, giving it an iden when it has none.
+func (s *Server) AddDevice(d pushbullet.Device) pushbullet.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(d.ID) == 0 {
+		d.ID = s.newIDLocked("device")
+	}
+	d.Active = true
+	s.devices = append(s.devices, d)
+	return d
+}
+
+//Devices returns the registered devices, oldest first.
+func (s *Server) Devices() []pushbullet.Device {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]pushbullet.Device(nil), s.devices...)
+}
+
+//Pushes returns the pushes sent to the server and not deleted, oldest first.
+func (s *Server) Pushes() []pushbullet.PushMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]pushbullet.PushMessage(nil), s.pushes...)
+}
+
+//Ephemerals returns the ephemerals sent to the server as they were posted, oldest first.
+func (s *Server) Ephemerals() []json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]json.RawMessage(nil), s.ephemerals...)
+}
+
+//File returns the content of an uploaded file by name.
+func (s *Server) File(name string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[name]
+	return data, ok
+}
+
+//Files returns the names of the uploaded files.
+func (s *Server) Files() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Calls returns every request received as "METHOD /path", oldest first.
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+//Called reports whether a request was received, given as "METHOD /path".
+func (s *Server) Called(call string) bool {
+	for _, c := range s.Calls() {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+//fault is what happens to a request
+type fault int
+
+const (
+	faultNone fault = iota
+	faultRateLimit
+	faultError
+	faultMalformed
+)
+
+//decide counts the request and picks its latency and fault
+func (s *Server) decide(r *http.Request) (time.Duration, fault, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.faults
+	s.requests++
+	s.calls = append(s.calls, r.Method+" "+r.URL.Path)
+	delay := f.Latency
+	if f.Jitter > 0 {
+		delay += time.Duration(s.rng.Int63n(int64(f.Jitter)))
+	}
+	reset := f.RateLimitReset
+	if reset <= 0 {
+		reset = time.Second
+	}
+	if f.RateLimitEvery > 0 && s.requests%f.RateLimitEvery == 0 {
+		s.burst = f.RateLimitBurst
+	}
+	// draw both numbers every time so one fault's rate does not shift the other's sequence
+	errorDraw, malformedDraw := s.rng.Float64(), s.rng.Float64()
+	switch {
+	case s.burst > 0:
+		s.burst--
+		return delay, faultRateLimit, reset
+	case errorDraw < f.ErrorRate:
+		return delay, faultError, reset
+	case malformedDraw < f.MalformedRate:
+		return delay, faultMalformed, reset
+	}
+	return delay, faultNone, reset
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	delay, fault, reset := s.decide(r)
+	if delay > 0 {
+		t := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+	// uploads and files are on another host in the real API, which takes no API key
+	switch {
+	case r.URL.Path == "/upload" && r.Method == "POST":
+		s.upload(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/files/") && r.Method == "GET":
+		data, ok := s.File(strings.TrimPrefix(r.URL.Path, "/files/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+		return
+	case strings.HasPrefix(r.URL.Path, "/websocket/"):
+		s.stream(w, r)
+		return
+	}
+	if user, _, ok := r.BasicAuth(); !ok || user != APIKey {
+		writeError(w, http.StatusUnauthorized, "invalid_request", "Access token is missing or invalid.")
+		return
+	}
+	s.mu.Lock()
+	intercept := s.intercept
+	s.mu.Unlock()
+	for _, f := range intercept {
+		if f(w, r) {
+			return
+		}
+	}
+	switch fault {
+	case faultRateLimit:
+		w.Header().Set("X-Ratelimit-Limit", "16384")
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(reset).Unix(), 10))
+		writeError(w, http.StatusTooManyRequests, "invalid_request", "You have been ratelimited for making too many requests to the server.")
+		return
+	case faultError:
+		writeError(w, http.StatusInternalServerError, "server", "Internal server error.")
+		return
+	}
+
+	status, body, tickle := s.route(r)
+	if tickle {
+		s.tickle()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if fault == faultMalformed && status < 300 {
+		body = body[:len(body)/2]
+	}
+	w.Write(body)
+}
+
+//route answers the request from the server's state, reporting whether it changed a push
+func (s *Server) route(r *http.Request) (int, []byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.Trim(r.URL.Path, "/")
+	now := float64(time.Now().UnixNano()) / 1e9
+	switch {
+	case path == "users/me" && r.Method == "GET":
+		return answer(encode(s.user))
+	case path == "devices" && r.Method == "GET":
+		return answer(encode(pushbullet.DeviceList{Devices: s.devices}))
+	case path == "devices" && r.Method == "POST":
+		var d pushbullet.Device
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil || len(d.Nickname) == 0 {
+			return answer(errorBody(http.StatusBadRequest, "invalid_request", "A device nickname is required."))
+		}
+		d.ID, d.Active, d.Pushable, d.Created, d.Modified = s.newIDLocked("device"), true, true, now, now
+		s.devices = append(s.devices, d)
+		return answer(encode(d))
+	case (path == "chats" || path == "subscriptions") && r.Method == "GET":
+		return answer(encode(map[string][]struct{}{path: {}}))
+	case path == "pushes" && r.Method == "GET":
+		var after float64
+		if v := r.URL.Query().Get("modified_after"); len(v) > 0 {
+			after, _ = strconv.ParseFloat(v, 64)
+		}
+		l := pushbullet.PushList{Pushes: []pushbullet.PushMessage{}}
+		for _, p := range s.pushes {
+			if p.Modified > after {
+				l.Pushes = append(l.Pushes, p)
+			}
+		}
+		return answer(encode(l))
+	case path == "pushes" && r.Method == "POST":
+		var p pushbullet.PushMessage
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			return answer(errorBody(http.StatusBadRequest, "invalid_request", "Invalid JSON body."))
+		}
+		p.ID, p.Active, p.Created, p.Modified = s.newIDLocked("push"), true, now, now
+		p.SenderEmail, p.SenderName = s.user.Email, s.user.Name
+		s.pushes = append(s.pushes, p)
+		status, body := encode(p)
+		return status, body, true
+	case strings.HasPrefix(path, "pushes/") && (r.Method == "POST" || r.Method == "DELETE"):
+		id := strings.TrimPrefix(path, "pushes/")
+		for i, p := range s.pushes {
+			if p.ID != id {
+				continue
+			}
+			if r.Method == "DELETE" {
+				s.pushes = append(s.pushes[:i], s.pushes[i+1:]...)
+				status, body := encode(struct{}{})
+				return status, body, true
+			}
+			// an update sets the fields it names, so decode it over the push
+			if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+				return answer(errorBody(http.StatusBadRequest, "invalid_request", "Invalid JSON body."))
+			}
+			p.ID, p.Modified = id, now
+			s.pushes[i] = p
+			status, body := encode(p)
+			return status, body, true
+		}
+	case path == "ephemerals" && r.Method == "POST":
+		var e json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			return answer(errorBody(http.StatusBadRequest, "invalid_request", "Invalid JSON body."))
+		}
+		s.ephemerals = append(s.ephemerals, e)
+		return answer(encode(struct{}{}))
+	case path == "upload-request" && r.Method == "POST":
+		var req struct {
+			FileName string `json:"file_name"`
+			FileType string `json:"file_type"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.FileName) == 0 {
+			return answer(errorBody(http.StatusBadRequest, "invalid_request", "A file name is required."))
+		}
+		return answer(encode(pushbullet.Authorization{FileName: req.FileName, FileType: req.FileType,
+			FileURL: s.URL + "/files/" + req.FileName, UploadURL: s.URL + "/upload"}))
+	}
+	return answer(errorBody(http.StatusNotFound, "invalid_request", "Object not found."))
+}
+
+//upload stores a file posted to an upload URL, refusing one larger than the user's upload limit
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	f, h, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "A file is required.")
+		return
+	}
+	defer f.Close()
+	s.mu.Lock()
+	limit := s.user.MaxUploadSize
+	s.mu.Unlock()
+	data, err := ioutil.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil || int64(len(data)) > limit {
+		writeError(w, http.StatusRequestEntityTooLarge, "invalid_request", "File too large.")
+		return
+	}
+	s.mu.Lock()
+	s.files[h.Filename] = data
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//stream serves the realtime event stream, announcing itself with a nop and tickling on every push change
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimPrefix(r.URL.Path, "/websocket/") != APIKey {
+		writeError(w, http.StatusUnauthorized, "invalid_request", "Access token is missing or invalid.")
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || len(key) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Expected a websocket upgrade.")
+		return
+	}
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tickles := make(chan struct{}, 16)
+	s.mu.Lock()
+	s.streams[tickles] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, tickles)
+		s.mu.Unlock()
+	}()
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+	writeFrame(rw, `{"type": "nop"}`)
+	// the client's frames are not needed, only whether it hung up
+	gone := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, rw)
+		close(gone)
+	}()
+	for {
+		select {
+		case <-tickles:
+			if writeFrame(rw, `{"type": "tickle", "subtype": "push"}`) != nil {
+				return
+			}
+		case <-gone:
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+//tickle tells the open streams that the pushes changed
+func (s *Server) tickle() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.streams {
+		select {
+		case c <- struct{}{}:
+		default: // a tickle is already pending
+		}
+	}
+}
+
+func (s *Server) newIDLocked(kind string) string {
+	s.nextID++
+	return fmt.Sprintf("%s%d", kind, s.nextID)
+}
+
+//websocketGUID is appended to the client's key to accept a websocket handshake
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+//writeFrame sends an unmasked text frame, as servers do
+func writeFrame(rw *bufio.ReadWriter, payload string) error {
+	rw.WriteByte(0x81)
+	if len(payload) < 126 {
+		rw.WriteByte(byte(len(payload)))
+	} else {
+		rw.Write([]byte{126, byte(len(payload) >> 8), byte(len(payload))})
+	}
+	rw.WriteString(payload)
+	return rw.Flush()
+}
+
+//answer is a response that leaves the pushes unchanged
+func answer(status int, body []byte) (int, []byte, bool) {
+	return status, body, false
+}
+
+func encode(v interface{}) (int, []byte) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorBody(http.StatusInternalServerError, "server", err.Error())
+	}
+	return http.StatusOK, data
+}
+
+func errorBody(status int, kind, message string) (int, []byte) {
+	data, _ := json.Marshal(map[string]map[string]string{"error": {"type": kind, "message": message, "cat": "~(=^‥^)"}})
+	return status, data
+}
+
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	status, body := errorBody(status, kind, message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,156 @@
+package pushbullettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+// outcomes sends n notes, describing how each went
+func outcomes(c *pushbullet.Client, n int) []string {
+	var l []string
+	for i := 0; i < n; i++ {
+		err := c.SendNote("title", "body")
+		var rl *pushbullet.RateLimitError
+		var status *pushbullet.StatusError
+		switch {
+		case err == nil:
+			l = append(l, "ok")
+		case errors.As(err, &rl):
+			l = append(l, "429")
+		case errors.As(err, &status):
+			l = append(l, "500")
+		default:
+			l = append(l, "malformed")
+		}
+	}
+	return l
+}
+
+func TestFaultsAreDeterministic(t *testing.T) {
+	faults := Faults{ErrorRate: 0.3, MalformedRate: 0.3, RateLimitEvery: 5, RateLimitBurst: 2, Seed: 42}
+	var runs [2][]string
+	for i := range runs {
+		srv := NewServer()
+		srv.SetFaults(faults)
+		runs[i] = outcomes(srv.Client(), 20)
+		srv.Close()
+	}
+	seen := make(map[string]bool)
+	for i := range runs[0] {
+		if runs[0][i] != runs[1][i] {
+			t.Fatalf("Runs differ at request %d: %v, %v", i, runs[0], runs[1])
+		}
+		seen[runs[0][i]] = true
+	}
+	for _, o := range []string{"ok", "429", "500", "malformed"} {
+		if !seen[o] {
+			t.Errorf("Expected a %s outcome: %v", o, runs[0])
+		}
+	}
+	if runs[0][4] != "429" || runs[0][5] != "429" || runs[0][9] != "429" {
+		t.Error("Expected rate limit bursts at every 5th request:", runs[0])
+	}
+}
+
+func TestRetriesRecover(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetFaults(Faults{ErrorRate: 0.5, Seed: 7})
+	c := srv.Client()
+	pushbullet.WithBackoff(pushbullet.ConstantBackoff{Interval: time.Millisecond, Retries: 20})(c)
+
+	for i := 0; i < 5; i++ {
+		if err := c.SendNote("title", "body"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(srv.Pushes()); n != 5 {
+		t.Error("Expected the retried pushes to be deduplicated:", n)
+	}
+	if srv.Requests() <= 5 {
+		t.Error("Expected some requests to be retried:", srv.Requests())
+	}
+}
+
+func TestLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetFaults(Faults{Latency: 200 * time.Millisecond})
+	srv.AddDevice(pushbullet.Device{Nickname: "Phone"})
+	c := srv.Client()
+
+	start := time.Now()
+	devices, err := c.GetDevices()
+	if err != nil || len(devices.Devices) != 1 || devices.Devices[0].Nickname != "Phone" {
+		t.Fatal("Unexpected devices:", devices, err)
+	}
+	if time.Since(start) < 200*time.Millisecond {
+		t.Error("Expected the latency to be applied")
+	}
+	c.HTTPClient.Timeout = 20 * time.Millisecond
+	if _, err := c.GetUser(); err == nil {
+		t.Error("Expected the slow request to time out")
+	}
+}
+
+func TestUploadsAndUpdates(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	auth, err := c.Upload(context.Background(), strings.NewReader("all good"), "report.txt", "text/plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Get(auth.FileURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(data) != "all good" || fmt.Sprint(srv.Files()) != "[report.txt]" {
+		t.Errorf("Unexpected upload: %q, %v", data, srv.Files())
+	}
+
+	if err := c.SendNote("title", "body"); err != nil {
+		t.Fatal(err)
+	}
+	id := srv.Pushes()[0].ID
+	if err := c.DismissPush(id); err != nil {
+		t.Fatal(err)
+	}
+	if p := srv.Pushes()[0]; !p.Dismissed || p.Title != "title" {
+		t.Errorf("Unexpected dismissed push: %+v", p)
+	}
+	if err := c.DeletePush(id); err != nil || len(srv.Pushes()) != 0 || !srv.Called("DELETE /pushes/"+id) {
+		t.Error("Push not deleted:", err, srv.Calls())
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != "/users/me" {
+			return false
+		}
+		w.Write([]byte(`{"iden": "intercepted"}`))
+		return true
+	})
+	c := srv.Client()
+
+	if u, err := c.GetUser(); err != nil || u.ID != "intercepted" {
+		t.Error("Expected the intercepted user:", u, err)
+	}
+	if err := c.SendNote("title", "body"); err != nil || len(srv.Pushes()) != 1 {
+		t.Error("Expected other requests to reach the server:", err)
+	}
+}
@@ -0,0 +1,612 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	mrand "math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//ErrQueueClosed is returned for a push given to a Queue after Shutdown.
+var ErrQueueClosed = errors.New("Queue is shut down")
+
+//Priority orders the pushes held by a Queue.
+type Priority int
+
+//Priorities from lowest to highest. Low priority pushes are the first to be shed when throughput is constrained.
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+//QuietHours is a daily window during which a Queue sends only high priority pushes.
+type QuietHours struct {
+	Start    time.Duration  // offset from midnight the window opens at
+	End      time.Duration  // offset from midnight the window closes at, before Start when it spans midnight
+	Location *time.Location // time.Local when nil
+}
+
+//Contains reports whether t falls within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	loc := q.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if q.Start <= q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
+
+//QueueStats counts what a Queue did with the pushes given to it.
+type QueueStats struct {
+	Sent     int
+	Failed   int
+	Pending  int
+	Shed     map[Priority]int // pushes dropped or digested, by priority
+	Digested int              // shed pushes handed to the Digester
+	Rejected int              // enqueued after Shutdown
+	Offline  bool             // the API was unreachable, sends are held until a probe succeeds
+}
+
+//QueuedPush is a push held by a Queue, as saved to a QueueStore.
+type QueuedPush struct {
+	Push     PushMessage `json:"push"`
+	Priority Priority    `json:"priority"`
+}
+
+//QueueStore persists the pushes a Queue still holds when it shuts down.
+type QueueStore interface {
+	Load() ([]QueuedPush, error)
+	Save([]QueuedPush) error
+}
+
+//Queue holds pushes and sends them in priority order, first in first out within a priority. When rate limits,
+//quiet hours or a full queue constrain throughput, high priority pushes go first and low priority ones are shed:
+//handed to the Digester when one is set, dropped otherwise.
+//
+//With Weights set, a rate limit no longer sheds low priority pushes. Until the backlog has drained, sends are
+//shared between the priorities in proportion to their weights, each priority spending a bucket of tokens refilled
+//to its weight once no waiting priority has any left. Urgent alerts then wait for at most a few lower priority
+//sends after an outage, and a backlog of low priority pushes still drains rather than being lost.
+//
+//	q := pushbullet.NewQueue(client)
+//	q.Digester = pushbullet.NewDigester(client)
+//	go q.Run(ctx)
+//	q.Enqueue(p, pushbullet.PriorityHigh)
+//
+//On shutdown, Shutdown sends what it can before a deadline and saves the rest to the Store for Restore to pick up.
+//
+//A send failing with a network error pauses the queue, keeping the push, until a probe of the API succeeds, so an
+//offline laptop neither burns through its pushes nor retries them in a tight loop. Pause and Resume do the same on
+//demand.
+type Queue struct {
+	Client     *Client
+	Capacity   int           // pushes held at most, unlimited when zero; the lowest priority is shed when full
+	Interval   time.Duration // minimum spacing between sends
+	QuietHours *QuietHours   // low priority pushes are shed and normal ones held during quiet hours
+	Digester   *Digester     // receives shed pushes instead of them being dropped
+	Store      QueueStore    // receives the pushes Shutdown could not send
+	OnError    func(PushMessage, error)
+	// Weights share sends between priorities after a rate limit, e.g. {PriorityHigh: 6, PriorityNormal: 3,
+	// PriorityLow: 1}. A priority without a positive weight has a weight of 1.
+	Weights map[Priority]int
+	// ProbeInterval spaces the checks made while the API is unreachable, DefaultProbeInterval when zero.
+	ProbeInterval time.Duration
+	// RampUp delays the first send once the API is reachable again by a random duration up to this long, so many
+	// queues coming back online together do not all send at once.
+	RampUp time.Duration
+
+	mu       sync.Mutex
+	pending  [numPriorities][]PushMessage
+	stats    QueueStats
+	failures []DeliveryFailure
+	// recovering is set from a rate limit until the backlog drains, while Weights apply
+	recovering bool
+	tokens     [numPriorities]int
+	// blockedUntil holds back every send until a rate limit resets
+	blockedUntil time.Time
+	wake         chan struct{}
+	closed       bool
+	paused       bool
+	// offline is set from a network error until a probe succeeds, with the next probe due at nextProbe
+	offline   bool
+	nextProbe time.Time
+	now       func() time.Time
+}
+
+//DefaultProbeInterval spaces a Queue's checks of whether the API is reachable again.
+const DefaultProbeInterval = 30 * time.Second
+
+//NewQueue returns a Queue sending through c.
+func NewQueue(c *Client) *Queue {
+	return &Queue{Client: c}
+}
+
+//Enqueue holds the push for sending, shedding a lower priority push instead when the queue is full. After Shutdown
+//the push is rejected and counted as such.
+func (q *Queue) Enqueue(p PushMessage, priority Priority) {
+	q.enqueue(p, priority)
+}
+
+func (q *Queue) enqueue(p PushMessage, priority Priority) error {
+	priority = clampPriority(priority)
+	q.mu.Lock()
+	if q.closed {
+		q.stats.Rejected++
+		q.mu.Unlock()
+		return ErrQueueClosed
+	}
+	var shed []PushMessage
+	if priority == PriorityLow && q.quiet() {
+		shed = q.shedLocked(priority, p)
+	} else {
+		q.pending[priority] = append(q.pending[priority], p)
+		if q.Capacity > 0 && q.countLocked() > q.Capacity {
+			for lowest := PriorityLow; lowest < numPriorities; lowest++ {
+				if len(q.pending[lowest]) > 0 {
+					dropped := q.pending[lowest][len(q.pending[lowest])-1]
+					q.pending[lowest] = q.pending[lowest][:len(q.pending[lowest])-1]
+					shed = q.shedLocked(lowest, dropped)
+					break
+				}
+			}
+		}
+	}
+	q.mu.Unlock()
+	q.digest(shed)
+	q.signal()
+	return nil
+}
+
+//Notify enqueues the notification at normal priority, so a Queue can stand in for a Client as a Notifier. It
+//returns ErrQueueClosed after Shutdown.
+func (q *Queue) Notify(ctx context.Context, title, body string, opts ...NotifyOption) error {
+	return q.enqueue(NotifyPush(title, body, opts...), PriorityNormal)
+}
+
+//Shutdown stops the queue accepting pushes and sends those it holds until none may go or the context is done, the
+//deadline bounding how long a deploy waits. What is left is moved to the Store, saving none when everything was
+//sent. Without a Store the pushes stay queued, and Shutdown reports how many were not sent.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	if q.Client == nil {
+		return errors.New("Queue has no client")
+	}
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal() // stops Run
+	for ctx.Err() == nil {
+		sent, wait := q.sendNext(ctx)
+		if sent {
+			wait = q.Interval
+		} else if wait == 0 {
+			break // sent everything, or only pushes held for quiet hours remain
+		}
+		if sleepContext(ctx, wait) != nil {
+			break
+		}
+	}
+
+	q.mu.Lock()
+	var rest []QueuedPush
+	for priority := numPriorities - 1; priority >= PriorityLow; priority-- {
+		for _, p := range q.pending[priority] {
+			rest = append(rest, QueuedPush{Push: p, Priority: priority})
+		}
+	}
+	if q.Store == nil {
+		q.mu.Unlock()
+		if len(rest) > 0 {
+			return fmt.Errorf("Queue shut down with %d pushes unsent", len(rest))
+		}
+		return nil
+	}
+	held := q.pending
+	q.pending = [numPriorities][]PushMessage{}
+	q.mu.Unlock()
+	if err := q.Store.Save(rest); err != nil {
+		q.mu.Lock()
+		q.pending = held
+		q.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+//Restore holds the pushes saved by an earlier Shutdown, for Run to send. They stay in the Store until the next
+//Shutdown saves over them, so a crash in between sends them twice rather than not at all.
+func (q *Queue) Restore() error {
+	if q.Store == nil {
+		return nil
+	}
+	l, err := q.Store.Load()
+	if err != nil {
+		return err
+	}
+	for _, held := range l {
+		q.Enqueue(held.Push, held.Priority)
+	}
+	return nil
+}
+
+//Pause stops sends until Resume, holding the pushes enqueued meanwhile.
+func (q *Queue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.paused = true
+}
+
+//Resume lets a paused queue send again.
+func (q *Queue) Resume() {
+	q.mu.Lock()
+	q.paused = false
+	q.mu.Unlock()
+	q.signal()
+}
+
+//Paused reports whether the queue is holding its pushes, because of Pause or because the API is unreachable.
+func (q *Queue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused || q.offline
+}
+
+//Stats returns the counts so far.
+func (q *Queue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s := q.stats
+	s.Pending = q.countLocked()
+	s.Offline = q.offline
+	s.Shed = make(map[Priority]int, len(q.stats.Shed))
+	for p, n := range q.stats.Shed {
+		s.Shed[p] = n
+	}
+	return s
+}
+
+//Run sends held pushes until the context is done or the queue is shut down, returning ErrQueueClosed then. Pushes
+//still held when it returns stay in the queue, as does one whose send the context's end interrupted. After a
+//rate limit nothing is sent until it resets, however many pushes are enqueued meanwhile.
+func (q *Queue) Run(ctx context.Context) error {
+	if q.Client == nil {
+		return errors.New("Queue has no client")
+	}
+	for {
+		if q.isClosed() {
+			return ErrQueueClosed // Shutdown sends the rest
+		}
+		sent, wait := q.sendNext(ctx)
+		if !sent {
+			if wait == 0 {
+				wait = time.Minute // recheck quiet hours
+			}
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-q.signalChan():
+			case <-t.C:
+			}
+			t.Stop()
+			continue
+		}
+		if q.Interval > 0 {
+			if err := sleepContext(ctx, q.Interval); err != nil {
+				return err
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+//sendNext sends the highest priority push which may go now. It reports whether a push was attempted, and
+//otherwise how long to wait before trying again, zero meaning until something changes.
+func (q *Queue) sendNext(ctx context.Context) (sent bool, wait time.Duration) {
+	q.mu.Lock()
+	paused, offline := q.paused, q.offline
+	blocked := q.blockedUntil.Sub(q.clock())
+	probe := q.nextProbe.Sub(q.clock())
+	q.mu.Unlock()
+	switch {
+	case paused:
+		return false, 0
+	case offline && probe > 0:
+		return false, probe
+	case offline:
+		if wait := q.probe(ctx); wait > 0 {
+			return false, wait
+		}
+	case blocked > 0:
+		return false, blocked
+	}
+	p, priority, ok := q.pop()
+	if !ok {
+		return false, 0
+	}
+	_, err := q.Client.sendPush(ctx, p)
+	var rl *RateLimitError
+	switch {
+	case err == nil:
+		q.count(func(s *QueueStats) { s.Sent++ })
+	case ctx.Err() != nil:
+		// Run is stopping, not the push failing: keep it at the front of its priority
+		q.mu.Lock()
+		q.pending[priority] = append([]PushMessage{p}, q.pending[priority]...)
+		if q.recovering {
+			q.tokens[priority]++
+		}
+		q.mu.Unlock()
+		return false, 0
+	case KindOf(err) == KindNetwork:
+		// the API is unreachable rather than the push at fault: keep it and wait for the network to return
+		q.mu.Lock()
+		q.pending[priority] = append([]PushMessage{p}, q.pending[priority]...)
+		if q.recovering {
+			q.tokens[priority]++
+		}
+		q.offline = true
+		wait = q.probeInterval()
+		q.nextProbe = q.clock().Add(wait)
+		q.mu.Unlock()
+		return false, wait
+	case errors.As(err, &rl):
+		// under rate pressure keep the push for later and make room for more important ones
+		q.mu.Lock()
+		q.pending[priority] = append([]PushMessage{p}, q.pending[priority]...)
+		var shed []PushMessage
+		if q.Weights != nil {
+			if q.recovering {
+				q.tokens[priority]++ // the push was not sent, so give its token back
+			}
+			q.recovering = true
+		} else {
+			for _, low := range q.pending[PriorityLow] {
+				shed = append(shed, q.shedLocked(PriorityLow, low)...)
+			}
+			q.pending[PriorityLow] = nil
+		}
+		wait = rl.RetryAfter()
+		if wait < time.Second {
+			wait = time.Second
+		}
+		q.blockedUntil = q.clock().Add(wait)
+		q.mu.Unlock()
+		q.digest(shed)
+		return false, wait
+	default:
+		q.mu.Lock()
+		q.stats.Failed++
+		q.recordFailureLocked(p, priority, err, q.clock())
+		q.mu.Unlock()
+		if q.OnError != nil {
+			q.OnError(p, err)
+		}
+	}
+	return true, 0
+}
+
+//probe checks whether the API is reachable again, returning how long to wait before the next send: until the
+//next probe when it is not, a random part of RampUp when it is
+func (q *Queue) probe(ctx context.Context) time.Duration {
+	_, err := q.Client.GetUserContext(ctx)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if KindOf(err) == KindNetwork || ctx.Err() != nil {
+		wait := q.probeInterval()
+		q.nextProbe = q.clock().Add(wait)
+		return wait
+	}
+	q.offline = false
+	var wait time.Duration
+	if q.RampUp > 0 {
+		wait = time.Duration(mrand.Int63n(int64(q.RampUp)))
+	}
+	q.blockedUntil = q.clock().Add(wait)
+	return wait
+}
+
+func (q *Queue) probeInterval() time.Duration {
+	if q.ProbeInterval > 0 {
+		return q.ProbeInterval
+	}
+	return DefaultProbeInterval
+}
+
+//pop takes the highest priority push which may be sent now, shedding low priority pushes during quiet hours
+func (q *Queue) pop() (PushMessage, Priority, bool) {
+	q.mu.Lock()
+	var shed []PushMessage
+	defer func() { q.digest(shed) }()
+	defer q.mu.Unlock()
+	quiet := q.quiet()
+	if quiet {
+		for _, low := range q.pending[PriorityLow] {
+			shed = append(shed, q.shedLocked(PriorityLow, low)...)
+		}
+		q.pending[PriorityLow] = nil
+	}
+	if q.countLocked() == 0 {
+		q.recovering, q.tokens = false, [numPriorities]int{}
+		return PushMessage{}, 0, false
+	}
+	if q.recovering && q.Weights != nil {
+		return q.popWeightedLocked(quiet)
+	}
+	for priority := numPriorities - 1; priority >= PriorityLow; priority-- {
+		if quiet && priority < PriorityHigh {
+			break
+		}
+		if len(q.pending[priority]) > 0 {
+			p := q.pending[priority][0]
+			q.pending[priority] = q.pending[priority][1:]
+			return p, priority, true
+		}
+	}
+	return PushMessage{}, 0, false
+}
+
+//popWeightedLocked takes the first push of the highest priority with a token left, refilling every bucket to its
+//weight when no waiting priority has one
+func (q *Queue) popWeightedLocked(quiet bool) (PushMessage, Priority, bool) {
+	for refilled := false; ; refilled = true {
+		for priority := numPriorities - 1; priority >= PriorityLow; priority-- {
+			if quiet && priority < PriorityHigh {
+				break
+			}
+			if len(q.pending[priority]) > 0 && q.tokens[priority] > 0 {
+				q.tokens[priority]--
+				p := q.pending[priority][0]
+				q.pending[priority] = q.pending[priority][1:]
+				return p, priority, true
+			}
+		}
+		if refilled {
+			// only held normal priority pushes remain during quiet hours
+			return PushMessage{}, 0, false
+		}
+		for priority := range q.tokens {
+			q.tokens[priority] = q.weight(Priority(priority))
+		}
+	}
+}
+
+//weight is the share of sends given to the priority while recovering from a rate limit
+func (q *Queue) weight(priority Priority) int {
+	if w := q.Weights[priority]; w > 0 {
+		return w
+	}
+	return 1
+}
+
+//shedLocked records a shed push, returning it when it should be digested
+func (q *Queue) shedLocked(priority Priority, p PushMessage) []PushMessage {
+	if q.stats.Shed == nil {
+		q.stats.Shed = make(map[Priority]int)
+	}
+	q.stats.Shed[priority]++
+	if q.Digester == nil {
+		return nil
+	}
+	q.stats.Digested++
+	return []PushMessage{p}
+}
+
+//digest hands shed pushes to the Digester, outside the queue's lock
+func (q *Queue) digest(shed []PushMessage) {
+	for _, p := range shed {
+		q.Digester.AddPush(p)
+	}
+}
+
+func (q *Queue) count(f func(*QueueStats)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	f(&q.stats)
+}
+
+func (q *Queue) countLocked() int {
+	n := 0
+	for _, held := range q.pending {
+		n += len(held)
+	}
+	return n
+}
+
+func (q *Queue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+func (q *Queue) quiet() bool {
+	if q.QuietHours == nil {
+		return false
+	}
+	return q.QuietHours.Contains(q.clock())
+}
+
+func (q *Queue) clock() time.Time {
+	if q.now != nil {
+		return q.now()
+	}
+	return time.Now()
+}
+
+func (q *Queue) signalChan() chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.wake == nil {
+		q.wake = make(chan struct{}, 1)
+	}
+	return q.wake
+}
+
+//signal wakes Run when it is waiting for pushes
+func (q *Queue) signal() {
+	select {
+	case q.signalChan() <- struct{}{}:
+	default:
+	}
+}
+
+func clampPriority(p Priority) Priority {
+	if p < PriorityLow {
+		return PriorityLow
+	}
+	if p >= numPriorities {
+		return PriorityHigh
+	}
+	return p
+}
+
+//QueueFile is a QueueStore persisted as a JSON document at the path, rewritten atomically on every save.
+type QueueFile string
+
+//Load reads the pushes, returning none when the file does not exist yet.
+func (f QueueFile) Load() ([]QueuedPush, error) {
+	var l []QueuedPush
+	data, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, json.Unmarshal(data, &l)
+}
+
+//Save replaces the stored pushes.
+func (f QueueFile) Save(l []QueuedPush) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(string(f), data)
+}
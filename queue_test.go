@@ -0,0 +1,419 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// titleServer records the titles of the pushes it receives, answering with status
+func titleServer(titles *[]string, status *int) (*httptest.Server, *Client) {
+	return mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p PushMessage
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &p)
+		if *status == 429 {
+			w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+		}
+		w.WriteHeader(*status)
+		if *status == 200 {
+			*titles = append(*titles, p.Title)
+			w.Write([]byte("{}"))
+		}
+	})
+}
+
+func TestQueuePriorityOrder(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+
+	q.Enqueue(PushMessage{Type: "note", Title: "low"}, PriorityLow)
+	q.Enqueue(PushMessage{Type: "note", Title: "normal 1"}, PriorityNormal)
+	q.Enqueue(PushMessage{Type: "note", Title: "high"}, PriorityHigh)
+	q.Enqueue(PushMessage{Type: "note", Title: "normal 2"}, PriorityNormal)
+	for sent := true; sent; {
+		sent, _ = q.sendNext(context.Background())
+	}
+	want := "[high normal 1 normal 2 low]"
+	if got := fmt.Sprint(titles); got != want {
+		t.Error("Unexpected send order:", got)
+	}
+	if s := q.Stats(); s.Sent != 4 || s.Pending != 0 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+}
+
+func TestQueueCapacitySheds(t *testing.T) {
+	rec := &RecordingNotifier{}
+	q := NewQueue(&Client{})
+	q.Capacity = 2
+	q.Digester = NewDigester(rec)
+
+	q.Enqueue(PushMessage{Type: "link", Title: "low", URL: "https://example.com/low"}, PriorityLow)
+	q.Enqueue(PushMessage{Title: "normal"}, PriorityNormal)
+	q.Enqueue(PushMessage{Title: "high"}, PriorityHigh)
+	s := q.Stats()
+	if s.Pending != 2 || s.Shed[PriorityLow] != 1 || s.Digested != 1 {
+		t.Errorf("Expected the low priority push to be shed: %+v", s)
+	}
+	q.Digester.Flush(context.Background())
+	if pushes := rec.Pushes(); len(pushes) != 1 || !strings.HasPrefix(pushes[0].Body, "low https://example.com/low\n") {
+		t.Error("Shed push not digested with its link:", pushes)
+	}
+}
+
+func TestQueueQuietHours(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+	q.QuietHours = &QuietHours{Start: 22 * time.Hour, End: 7 * time.Hour, Location: time.UTC}
+	now := time.Date(2015, 4, 25, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	q.Enqueue(PushMessage{Type: "note", Title: "low"}, PriorityLow)
+	q.Enqueue(PushMessage{Type: "note", Title: "normal"}, PriorityNormal)
+	q.Enqueue(PushMessage{Type: "note", Title: "high"}, PriorityHigh)
+	for sent := true; sent; {
+		sent, _ = q.sendNext(context.Background())
+	}
+	if fmt.Sprint(titles) != "[high]" || q.Stats().Shed[PriorityLow] != 1 {
+		t.Error("Unexpected sends during quiet hours:", titles, q.Stats())
+	}
+
+	now = now.Add(8 * time.Hour)
+	q.sendNext(context.Background())
+	if fmt.Sprint(titles) != "[high normal]" {
+		t.Error("Held push not sent after quiet hours:", titles)
+	}
+}
+
+func TestQueueRateLimitSheds(t *testing.T) {
+	var titles []string
+	status := 429
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+
+	q.Enqueue(PushMessage{Type: "note", Title: "high"}, PriorityHigh)
+	q.Enqueue(PushMessage{Type: "note", Title: "low"}, PriorityLow)
+	sent, wait := q.sendNext(context.Background())
+	if sent || wait < 50*time.Second {
+		t.Error("Expected the queue to pause until the reset:", sent, wait)
+	}
+	s := q.Stats()
+	if s.Pending != 1 || s.Shed[PriorityLow] != 1 {
+		t.Errorf("Expected the high priority push kept and the low one shed: %+v", s)
+	}
+}
+
+func TestQueueWeightedFairnessAfterRateLimit(t *testing.T) {
+	var titles []string
+	status := 429
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+	q.Weights = map[Priority]int{PriorityHigh: 2}
+
+	for i := 1; i <= 4; i++ {
+		q.Enqueue(PushMessage{Type: "note", Title: fmt.Sprint("digest ", i)}, PriorityLow)
+	}
+	for i := 1; i <= 4; i++ {
+		q.Enqueue(PushMessage{Type: "note", Title: fmt.Sprint("alert ", i)}, PriorityHigh)
+	}
+	if sent, _ := q.sendNext(context.Background()); sent {
+		t.Fatal("Expected the rate limit to hold the push")
+	}
+	if s := q.Stats(); s.Pending != 8 || len(s.Shed) != 0 {
+		t.Fatalf("Expected every push kept: %+v", s)
+	}
+
+	status = 200
+	q.now = func() time.Time { return time.Now().Add(2 * time.Minute) } // past the reset
+	for sent := true; sent; {
+		sent, _ = q.sendNext(context.Background())
+	}
+	want := "[alert 1 alert 2 digest 1 alert 3 alert 4 digest 2 digest 3 digest 4]"
+	if got := fmt.Sprint(titles); got != want {
+		t.Error("Unexpected send order:", got)
+	}
+
+	// with the backlog drained, strict priority order applies again
+	titles = nil
+	q.Enqueue(PushMessage{Type: "note", Title: "digest 5"}, PriorityLow)
+	q.Enqueue(PushMessage{Type: "note", Title: "alert 5"}, PriorityHigh)
+	q.Enqueue(PushMessage{Type: "note", Title: "alert 6"}, PriorityHigh)
+	q.Enqueue(PushMessage{Type: "note", Title: "alert 7"}, PriorityHigh)
+	for sent := true; sent; {
+		sent, _ = q.sendNext(context.Background())
+	}
+	if got := fmt.Sprint(titles); got != "[alert 5 alert 6 alert 7 digest 5]" {
+		t.Error("Unexpected send order after recovering:", got)
+	}
+}
+
+func TestQueueShutdown(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	dir, _ := ioutil.TempDir("", "queue")
+	defer os.RemoveAll(dir)
+	store := QueueFile(filepath.Join(dir, "queue.json"))
+	q := NewQueue(c)
+	q.Store = store
+	// the normal priority push is held for quiet hours, so it cannot be sent before the deadline
+	q.QuietHours = &QuietHours{Start: 0, End: 24 * time.Hour}
+
+	q.Enqueue(PushMessage{Type: "note", Title: "normal"}, PriorityNormal)
+	q.Enqueue(PushMessage{Type: "note", Title: "high"}, PriorityHigh)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(titles) != "[high]" {
+		t.Error("Unexpected sends on shutdown:", titles)
+	}
+	if err := q.Notify(ctx, "late", ""); err != ErrQueueClosed {
+		t.Error("Expected a push after shutdown to be rejected, got:", err)
+	}
+	if err := q.Run(ctx); err != ErrQueueClosed {
+		t.Error("Expected Run to stop after shutdown, got:", err)
+	}
+	if s := q.Stats(); s.Pending != 0 || s.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", s)
+	}
+
+	// the next process picks the held push up and sends it
+	restarted := NewQueue(q.Client)
+	restarted.Store = store
+	if err := restarted.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(titles) != "[high normal]" {
+		t.Error("Restored push not sent:", titles)
+	}
+	if saved, err := store.Load(); err != nil || len(saved) != 0 {
+		t.Error("Sent push still stored:", saved, err)
+	}
+}
+
+func TestQueueShutdownWithoutStore(t *testing.T) {
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+	q := NewQueue(c)
+	for i := 0; i < 10; i++ {
+		q.Enqueue(PushMessage{Type: "note", Title: fmt.Sprint(i)}, PriorityNormal)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+	err := q.Shutdown(ctx)
+	if s := q.Stats(); err == nil || s.Sent == 0 || s.Pending == 0 || s.Sent+s.Pending != 10 {
+		t.Errorf("Expected the deadline to leave pushes queued and reported: %v %+v", err, s)
+	}
+}
+
+// flakyTransport fails every request with a network error while down is set
+type flakyTransport struct {
+	mu       sync.Mutex
+	down     bool
+	requests []string
+}
+
+func (t *flakyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, r.Method+" "+r.URL.Path)
+	down := t.down
+	t.mu.Unlock()
+	if down {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("network is unreachable")}
+	}
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func (t *flakyTransport) set(down bool) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.down = down
+	return t.requests
+}
+
+func TestQueuePausesWhileOffline(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	transport := &flakyTransport{down: true}
+	c.HTTPClient.Transport = transport
+	q := NewQueue(c)
+	q.ProbeInterval, q.RampUp = time.Minute, 10*time.Second
+	now := time.Now()
+	q.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	q.Enqueue(PushMessage{Type: "note", Title: "offline"}, PriorityNormal)
+	if sent, wait := q.sendNext(ctx); sent || wait != time.Minute || !q.Paused() {
+		t.Fatalf("Expected a network error to pause the queue: %v %v", sent, wait)
+	}
+	if s := q.Stats(); s.Failed != 0 || s.Pending != 1 {
+		t.Errorf("Expected the push kept: %+v", s)
+	}
+	// no probe is made before it is due
+	if sent, wait := q.sendNext(ctx); sent || wait != time.Minute || len(transport.set(false)) != 1 {
+		t.Error("Probed early:", wait, transport.set(false))
+	}
+
+	now = now.Add(time.Minute)
+	sent, wait := q.sendNext(ctx)
+	if requests := transport.set(false); sent || wait >= 10*time.Second || q.Paused() || requests[1] != "GET /users/me" {
+		t.Fatalf("Expected a successful probe to resume after a ramp-up delay: %v %v %v", sent, wait, requests)
+	}
+	now = now.Add(wait)
+	// the probe reaches the server too, as a push without a title
+	if sent, _ := q.sendNext(ctx); !sent || len(titles) != 2 || titles[1] != "offline" {
+		t.Error("Held push not sent after resuming:", titles)
+	}
+}
+
+func TestQueuePauseResume(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+
+	q.Pause()
+	q.Enqueue(PushMessage{Type: "note", Title: "held"}, PriorityHigh)
+	if sent, _ := q.sendNext(context.Background()); sent || len(titles) != 0 || !q.Paused() {
+		t.Error("Sent while paused:", titles)
+	}
+	q.Resume()
+	if sent, _ := q.sendNext(context.Background()); !sent || fmt.Sprint(titles) != "[held]" {
+		t.Error("Not sent after resuming:", titles)
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	day := QuietHours{Start: 12 * time.Hour, End: 13 * time.Hour, Location: time.UTC}
+	if !day.Contains(time.Date(2015, 4, 25, 12, 30, 0, 0, time.UTC)) || day.Contains(time.Date(2015, 4, 25, 13, 0, 0, 0, time.UTC)) {
+		t.Error("Unexpected daytime window")
+	}
+}
+
+func TestQueueRun(t *testing.T) {
+	sent := make(chan string, 1)
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		w.Write([]byte("{}"))
+		sent <- p.Title
+	})
+	defer server.Close()
+	q := NewQueue(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	q.Notify(ctx, "Deploy", "done")
+	select {
+	case title := <-sent:
+		if title != "Deploy" {
+			t.Error("Unexpected push:", title)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Queued push was not sent")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("Unexpected Run result:", err)
+	}
+}
+
+func TestQueueRunKeepsInFlightPushOnCancel(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	defer server.Close()
+	defer close(release)
+	q := NewQueue(c)
+	var failed []error
+	q.OnError = func(p PushMessage, err error) { failed = append(failed, err) }
+	q.Enqueue(PushMessage{Type: "note", Title: "first"}, PriorityNormal)
+	q.Enqueue(PushMessage{Type: "note", Title: "second"}, PriorityNormal)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	<-started
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Error("Unexpected Run result:", err)
+	}
+	if s := q.Stats(); s.Failed != 0 || s.Pending != 2 || len(failed) != 0 {
+		t.Errorf("Expected the push in flight to stay queued: %+v %v", s, failed)
+	}
+	if q.pending[PriorityNormal][0].Title != "first" {
+		t.Error("Push in flight not put back at the front:", q.pending[PriorityNormal])
+	}
+}
+
+func TestQueueRunWaitsOutRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(429)
+	})
+	defer server.Close()
+	q := NewQueue(c)
+	q.Weights = map[Priority]int{PriorityHigh: 2}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- q.Run(ctx) }()
+
+	q.Enqueue(PushMessage{Type: "note", Title: "first"}, PriorityHigh)
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		q.Enqueue(PushMessage{Type: "note", Title: fmt.Sprint("during wait ", i)}, PriorityHigh)
+	}
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 1 {
+		t.Errorf("Expected one request before the rate limit resets, made %d", requests)
+	}
+	if s := q.Stats(); s.Pending != 6 {
+		t.Errorf("Expected every push held, got: %+v", s)
+	}
+}
@@ -0,0 +1,80 @@
+//Package quick sends pushes in a single call for throwaway scripts, with sane timeouts and retries built in. Programs
+//which send more than the odd push should build a pushbullet.Client and use contexts and options instead.
+//
+//	if err := quick.Note(os.Getenv("PUSHBULLET_TOKEN"), "Backup done", summary); err != nil {
+//		log.Fatal(err)
+//	}
+package quick
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Timeout bounds each call, including its retries and, for File, both the upload and the push.
+var Timeout = time.Minute
+
+//retries spaces out retries of temporary failures
+var retries = pushbullet.ExponentialBackoff{Base: 500 * time.Millisecond, Max: 10 * time.Second, Retries: 4}
+
+//apiRoot is replaced in tests
+var apiRoot = pushbullet.DefaultAPIRoot + pushbullet.DefaultAPIVersion
+
+//client returns a client for the token, falling back to the PUSHBULLET_TOKEN environment variable when it is empty
+func client(token string) (*pushbullet.Client, error) {
+	if len(token) == 0 {
+		token = os.Getenv("PUSHBULLET_TOKEN")
+	}
+	if len(token) == 0 {
+		return nil, errors.New("No Pushbullet token given or set in PUSHBULLET_TOKEN")
+	}
+	return pushbullet.ClientWithKey(token, pushbullet.WithAPI(apiRoot, ""), pushbullet.WithBackoff(retries)), nil
+}
+
+//Note pushes a note to all of the user's devices.
+func Note(token, title, body string) error {
+	c, err := client(token)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return c.SendNoteContext(ctx, title, body)
+}
+
+//Link pushes a link to all of the user's devices.
+func Link(token, title, url string) error {
+	c, err := client(token)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return c.SendLinkContext(ctx, title, "", url)
+}
+
+//File uploads the file at path and pushes it to all of the user's devices.
+func File(token, path string) error {
+	c, err := client(token)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	auth, err := c.Upload(ctx, f, filepath.Base(path), "")
+	if err != nil {
+		return err
+	}
+	_, err = c.SendPushContext(ctx, pushbullet.PushRequest{Type: "file", FileName: auth.FileName, FileType: auth.FileType, FileURL: auth.FileURL})
+	return err
+}
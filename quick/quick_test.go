@@ -0,0 +1,91 @@
+package quick
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func TestQuick(t *testing.T) {
+	server := pushbullettest.NewServer()
+	// the first push fails, to be retried
+	failed := false
+	server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != "/pushes" || failed {
+			return false
+		}
+		failed = true
+		w.WriteHeader(503)
+		return true
+	})
+	defer server.Close()
+	apiRoot = server.URL
+
+	if err := Note(pushbullettest.APIKey, "Backup done", "3 GB"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Link(pushbullettest.APIKey, "Docs", "https://example.com"); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "quick")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "report.txt")
+	ioutil.WriteFile(path, []byte("all good"), 0600)
+	if err := File(pushbullettest.APIKey, path); err != nil {
+		t.Fatal(err)
+	}
+	pushes := server.Pushes()
+	if len(pushes) != 3 || pushes[0].Title != "Backup done" || pushes[1].URL != "https://example.com" || pushes[2].FileURL != server.URL+"/files/report.txt" {
+		t.Errorf("Unexpected pushes: %+v", pushes)
+	}
+	if data, _ := server.File("report.txt"); string(data) != "all good" {
+		t.Errorf("Unexpected upload: %q", data)
+	}
+	attempts := 0
+	for _, call := range server.Calls() {
+		if call == "POST /pushes" {
+			attempts++
+		}
+	}
+	if attempts != 4 {
+		t.Error("Expected the failed note to be retried:", attempts)
+	}
+
+	os.Setenv("PUSHBULLET_TOKEN", "")
+	if err := Note("", "title", "body"); err == nil {
+		t.Error("Expected an error without a token")
+	}
+	os.Setenv("PUSHBULLET_TOKEN", pushbullettest.APIKey)
+	defer os.Unsetenv("PUSHBULLET_TOKEN")
+	if err := Note("", "title", "body"); err != nil {
+		t.Error("Expected the token from the environment:", err)
+	}
+}
+
+func TestTimeoutCoversRetries(t *testing.T) {
+	server := pushbullettest.NewServer()
+	server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(503)
+		return true
+	})
+	defer server.Close()
+	apiRoot = server.URL
+	defer func(d time.Duration) { Timeout = d }(Timeout)
+	Timeout = 200 * time.Millisecond
+
+	start := time.Now()
+	if err := Note(pushbullettest.APIKey, "title", "body"); err == nil {
+		t.Fatal("Expected the note to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("Retries ran past the timeout:", elapsed)
+	}
+}
@@ -0,0 +1,107 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// rotatingToken hands out a new key every few calls, as a credential store being rotated underneath a client would
+type rotatingToken struct {
+	calls int64
+}
+
+func (r *rotatingToken) Token() (string, error) {
+	return fmt.Sprintf("key-%d", atomic.AddInt64(&r.calls, 1)/25), nil
+}
+
+// TestSharedClientStress hammers one Client from many goroutines. Run it under -race: it codifies that a configured
+// Client is safe for concurrent use, including its device and user caches, statistics and rotating keys.
+func TestSharedClientStress(t *testing.T) {
+	var pushes int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, _, ok := r.BasicAuth(); !ok || !strings.HasPrefix(key, "key-") {
+			w.WriteHeader(401)
+			return
+		}
+		switch {
+		case r.URL.Path == "/pushes" && r.Method == "POST":
+			var p PushMessage
+			json.NewDecoder(r.Body).Decode(&p)
+			p.ID = fmt.Sprintf("push%d", atomic.AddInt64(&pushes, 1))
+			json.NewEncoder(w).Encode(p)
+		case r.URL.Path == "/pushes":
+			fmt.Fprint(w, `{"pushes": [{"iden": "p1", "type": "note", "title": "hi"}]}`)
+		case r.URL.Path == "/devices":
+			fmt.Fprint(w, `{"devices": [{"iden": "d1", "nickname": "Phone", "active": true}, {"iden": "d2", "nickname": "Laptop", "active": true}]}`)
+		case r.URL.Path == "/users/me":
+			fmt.Fprint(w, `{"iden": "u1", "email": "elon@teslamotors.com", "active": true}`)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+	c := ClientWithTokenSource(&rotatingToken{}, WithAPI(server.URL, ""), WithExpvar("race_test"))
+
+	workers := 200
+	if testing.Short() {
+		workers = 20
+	}
+	calls := []func() error{
+		func() error { return c.SendNote("title", "body") },
+		func() error { _, err := c.GetDevices(); return err },
+		func() error { _, err := c.GetPushHistory(0); return err },
+		func() error { _, err := c.GetDeviceByNickname("phone"); return err },
+		func() error { _, err := c.ResolveTarget("lap"); return err },
+		func() error { c.InvalidateDevices(); return nil },
+		func() error { _, err := c.CurrentUser(); return err },
+		func() error { return c.SendToSelf("title", "body") },
+		func() error { _ = c.Stats(); _ = c.String(); return nil },
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*len(calls))
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			for j := range calls {
+				if err := calls[(i+j)%len(calls)](); err != nil {
+					errs <- err
+				}
+			}
+		}(i)
+	}
+	close(start)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("Calls deadlocked")
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := atomic.LoadInt64(&pushes); n != int64(2*workers) {
+		t.Errorf("Expected %d pushes, got %d", 2*workers, n)
+	}
+	want := 2 * workers
+	if want > latencyWindow {
+		want = latencyWindow
+	}
+	if s := c.Stats()["POST pushes"]; s.Count != want || s.Errors != 0 {
+		t.Errorf("Expected every push counted: %+v", s)
+	}
+}
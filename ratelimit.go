@@ -0,0 +1,67 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//RateLimitError is returned when the API answers 429 Too Many Requests. It carries the budget reported in the
+//response headers so callers can back off politely.
+type RateLimitError struct {
+	Limit     int       // requests allowed per window, -1 when not reported
+	Remaining int       // requests left in the window, -1 when not reported
+	Reset     time.Time // when the budget resets, zero when not reported
+	APIError  *Error    // the error body, when the response had one
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return "Rate limited"
+	}
+	return fmt.Sprintf("Rate limited until %s", e.Reset.Format(time.RFC3339))
+}
+
+//RetryAfter returns how long to wait before the budget resets, or zero when the reset has passed or is unknown.
+func (e *RateLimitError) RetryAfter() time.Duration {
+	if e.Reset.IsZero() {
+		return 0
+	}
+	if d := time.Until(e.Reset); d > 0 {
+		return d
+	}
+	return 0
+}
+
+//Wait sleeps until the budget resets, returning early with the context's error if it is done first.
+func (e *RateLimitError) Wait(ctx context.Context) error {
+	d := e.RetryAfter()
+	if d == 0 {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, d)
+}
+
+//rateLimitError reads the budget from the X-Ratelimit headers of a 429 response, falling back to Retry-After for
+//the reset time
+func rateLimitError(h http.Header, apiError *Error, now time.Time) *RateLimitError {
+	e := &RateLimitError{Limit: headerInt(h, "X-Ratelimit-Limit"), Remaining: headerInt(h, "X-Ratelimit-Remaining"), APIError: apiError}
+	if reset, err := strconv.ParseInt(h.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+		e.Reset = time.Unix(reset, 0)
+	} else if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		e.Reset = now.Add(time.Duration(secs) * time.Second)
+	} else if at, err := http.ParseTime(h.Get("Retry-After")); err == nil {
+		e.Reset = at
+	}
+	return e
+}
+
+func headerInt(h http.Header, name string) int {
+	n, err := strconv.Atoi(h.Get(name))
+	if err != nil {
+		return -1
+	}
+	return n
+}
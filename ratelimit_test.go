@@ -0,0 +1,54 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitError(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "16384")
+		w.Header().Set("X-Ratelimit-Remaining", "0")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(429)
+		w.Write([]byte(`{"error": {"type": "invalid_request", "message": "Too many requests"}}`))
+	})
+	defer server.Close()
+
+	_, err := c.GetDevices()
+	var rl *RateLimitError
+	if !errors.As(err, &rl) {
+		t.Fatal("Expected a RateLimitError, got:", err)
+	}
+	if rl.Limit != 16384 || rl.Remaining != 0 || rl.Reset.Unix() != reset || rl.APIError == nil {
+		t.Errorf("Unexpected rate limit: %+v", rl)
+	}
+	if d := rl.RetryAfter(); d <= 59*time.Minute || d > time.Hour {
+		t.Error("Unexpected retry delay:", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rl.Wait(ctx); err != context.DeadlineExceeded {
+		t.Error("Expected Wait to stop with the context:", err)
+	}
+}
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	now := time.Now()
+	h := http.Header{"Retry-After": {"2"}}
+	e := rateLimitError(h, nil, now)
+	if !e.Reset.Equal(now.Add(2*time.Second)) || e.Limit != -1 || e.Remaining != -1 {
+		t.Errorf("Unexpected rate limit from Retry-After: %+v", e)
+	}
+
+	past := &RateLimitError{Reset: now.Add(-time.Minute)}
+	if past.RetryAfter() != 0 || past.Wait(context.Background()) != nil {
+		t.Error("Expected a passed reset to need no wait")
+	}
+}
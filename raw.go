@@ -0,0 +1,145 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+//WithRawFields keeps the JSON each push, device and user was decoded from in its Raw field, so fields the library
+//does not know about yet can be read with UnknownFields and are sent back when the value is re-encoded. It costs a
+//second parse of every response, with encoding/json whatever the codec, so it is off by default.
+func WithRawFields() Option {
+	return func(c *Client) {
+		c.KeepRaw = true
+	}
+}
+
+//rawHolder is implemented by decoded types which keep the JSON they were decoded from
+type rawHolder interface {
+	setRaw(raw json.RawMessage)
+}
+
+func (p *PushMessage) setRaw(raw json.RawMessage) { p.Raw = raw }
+func (d *Device) setRaw(raw json.RawMessage)      { d.Raw = raw }
+func (u *User) setRaw(raw json.RawMessage)        { u.Raw = raw }
+
+//UnknownFields returns the fields of the decoded push which the library has no typed field for, when the client
+//keeps raw fields.
+func (p PushMessage) UnknownFields() map[string]json.RawMessage {
+	return unknownFields(p.Raw, typedPushFields)
+}
+
+//UnknownFields returns the fields of the decoded device which the library has no typed field for.
+func (d Device) UnknownFields() map[string]json.RawMessage {
+	return unknownFields(d.Raw, typedDeviceFields)
+}
+
+//UnknownFields returns the fields of the decoded user which the library has no typed field for.
+func (u User) UnknownFields() map[string]json.RawMessage {
+	return unknownFields(u.Raw, typedUserFields)
+}
+
+func unknownFields(raw json.RawMessage, typed map[string]bool) map[string]json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return nil
+	}
+	for k := range fields {
+		if typed[k] {
+			delete(fields, k)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+var rawHolderType = reflect.TypeOf((*rawHolder)(nil)).Elem()
+
+//holdsRaw caches whether values of a type can contain a rawHolder
+var holdsRaw sync.Map
+
+//captureRaw stores the JSON each rawHolder reachable from v was decoded from. Keeping this out of the decoding
+//itself leaves strict decoding and pluggable codecs working unchanged.
+func captureRaw(v reflect.Value, data []byte) {
+	if len(data) == 0 || !containsRaw(v.Type()) {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			captureRaw(v.Elem(), data)
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return
+		}
+		for i := 0; i < v.Len() && i < len(items); i++ {
+			captureRaw(v.Index(i), items[i])
+		}
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().Type().Implements(rawHolderType) {
+			v.Addr().Interface().(rawHolder).setRaw(append(json.RawMessage(nil), data...))
+		}
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(data, &fields) != nil {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if raw, ok := fields[name]; ok && len(name) > 0 && v.Field(i).CanSet() {
+				captureRaw(v.Field(i), raw)
+			}
+		}
+	}
+}
+
+//containsRaw reports whether values of t are or may contain a rawHolder
+func containsRaw(t reflect.Type) bool {
+	found, _ := containsRawAt(t, map[reflect.Type]int{}, 0)
+	return found
+}
+
+//containsRawAt is containsRaw for a type reached at the given depth of the search, with the types being searched
+//in visiting by depth. A type met again while it is being searched contributes false for now; low is the shallowest
+//such type met, and an answer is only cached once no type searched above t was involved, so that a provisional
+//answer is never published.
+func containsRawAt(t reflect.Type, visiting map[reflect.Type]int, depth int) (found bool, low int) {
+	if known, ok := holdsRaw.Load(t); ok {
+		return known.(bool), depth
+	}
+	if d, ok := visiting[t]; ok {
+		return false, d
+	}
+	visiting[t] = depth
+	defer delete(visiting, t)
+	low = depth
+	search := func(elem reflect.Type) {
+		f, l := containsRawAt(elem, visiting, depth+1)
+		found = found || f
+		if l < low {
+			low = l
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		search(t.Elem())
+	case reflect.Struct:
+		found = reflect.PtrTo(t).Implements(rawHolderType)
+		for i := 0; i < t.NumField() && !found; i++ {
+			search(t.Field(i).Type)
+		}
+	}
+	if found || low >= depth {
+		holdsRaw.Store(t, found)
+	}
+	return found, low
+}
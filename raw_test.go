@@ -0,0 +1,75 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFieldsPreserved(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"pushes": [{"iden": "p1", "type": "note", "title": "Hi", "awake_app_guids": "web-1"}]}`)
+	defer mockServer.Close()
+	WithRawFields()(c)
+
+	pushes, err := c.GetPushHistory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := pushes[0].UnknownFields()
+	if len(unknown) != 1 || string(unknown["awake_app_guids"]) != `"web-1"` {
+		t.Fatal("Unexpected unknown fields:", unknown)
+	}
+
+	// the decoded push round-trips with its unknown fields, and edited typed fields win
+	p := pushes[0]
+	p.Title = "Edited"
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+	if out["awake_app_guids"] != "web-1" || out["title"] != "Edited" {
+		t.Error("Unexpected round trip:", string(data))
+	}
+}
+
+func TestUnknownFieldsOnUser(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"iden": "u1", "email": "a@example.com", "beta": true}`)
+	defer mockServer.Close()
+
+	u, err := c.GetUser()
+	if err != nil || u.Raw != nil {
+		t.Fatal("Raw kept without WithRawFields:", err, string(u.Raw))
+	}
+	c.KeepRaw = true
+	u, err = c.GetUser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(u.UnknownFields()["beta"]) != "true" {
+		t.Error("Unknown user field not kept:", string(u.Raw))
+	}
+	if len((Device{}).UnknownFields()) != 0 {
+		t.Error("Expected no unknown fields on a device which was not decoded")
+	}
+}
+
+// mutually recursive types, each reaching a PushMessage only through the other
+type rawCycleA struct {
+	B    *rawCycleB
+	Push PushMessage
+}
+
+type rawCycleB struct {
+	A *rawCycleA
+}
+
+func TestContainsRawMutualRecursion(t *testing.T) {
+	if !containsRaw(reflect.TypeOf(rawCycleA{})) {
+		t.Error("Expected A to contain a raw holder")
+	}
+	if !containsRaw(reflect.TypeOf(rawCycleB{})) {
+		t.Error("B was cached with the provisional answer given while A was searched")
+	}
+}
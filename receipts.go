@@ -0,0 +1,59 @@
+package pushbullet
+
+//pushReceipt holds the callbacks for a tracked push
+type pushReceipt struct {
+	onDismissed func(PushMessage)
+	onDeleted   func(PushMessage)
+	dismissed   bool
+}
+
+//TrackPush calls onDismissed when the push is dismissed and onDeleted when it is deleted, as the Sync learns of
+//it, so a sender can act on pushes which go unacknowledged. Either callback may be nil. Tracking stops after the
+//deletion or when the returned function is called.
+//
+//	untrack := s.TrackPush(sent.ID, func(PushMessage) { acked <- true }, nil)
+//	defer untrack()
+func (s *Sync) TrackPush(iden string, onDismissed, onDeleted func(PushMessage)) (untrack func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tracked == nil {
+		s.tracked = make(map[string]*pushReceipt)
+	}
+	r := &pushReceipt{onDismissed: onDismissed, onDeleted: onDeleted}
+	s.tracked[iden] = r
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.tracked[iden] == r {
+			delete(s.tracked, iden)
+		}
+	}
+}
+
+//receipts returns the tracking callbacks due for a page of pushes. Pushes are checked whether or not the Sync
+//reported them, as a push sent and deleted between two refreshes is never loaded.
+func (s *Sync) receipts(pushes []PushMessage) []func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []func()
+	for _, p := range pushes {
+		r, ok := s.tracked[p.ID]
+		if !ok {
+			continue
+		}
+		push := p
+		if p.Dismissed && !r.dismissed {
+			r.dismissed = true
+			if r.onDismissed != nil {
+				due = append(due, func() { r.onDismissed(push) })
+			}
+		}
+		if !p.Active {
+			delete(s.tracked, p.ID)
+			if r.onDeleted != nil {
+				due = append(due, func() { r.onDeleted(push) })
+			}
+		}
+	}
+	return due
+}
@@ -0,0 +1,48 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestTrackPush(t *testing.T) {
+	routes := map[string]string{
+		"/pushes":        `{"pushes": [{"iden": "p1", "active": true, "modified": 1}]}`,
+		"/devices":       `{}`,
+		"/chats":         `{}`,
+		"/subscriptions": `{}`,
+	}
+	server, c := mockRoutes(routes)
+	defer server.Close()
+	s := NewSync(c, nil)
+	ctx := context.Background()
+
+	var dismissed, deleted []string
+	s.TrackPush("p1", func(p PushMessage) { dismissed = append(dismissed, p.ID) }, func(p PushMessage) { deleted = append(deleted, p.ID) })
+	s.TrackPush("p2", nil, func(p PushMessage) { deleted = append(deleted, p.ID) })
+	s.Refresh(ctx)
+	if len(dismissed) != 0 || len(deleted) != 0 {
+		t.Fatal("Receipt for an untouched push:", dismissed, deleted)
+	}
+
+	routes["/pushes"] = `{"pushes": [{"iden": "p1", "active": true, "dismissed": true, "modified": 2}]}`
+	s.Refresh(ctx)
+	routes["/pushes"] = `{"pushes": [{"iden": "p1", "active": false, "dismissed": true, "modified": 3}, {"iden": "p2", "active": false, "modified": 3}]}`
+	s.Refresh(ctx)
+	if fmt.Sprint(dismissed) != "[p1]" || fmt.Sprint(deleted) != "[p1 p2]" {
+		t.Error("Unexpected receipts:", dismissed, deleted)
+	}
+	if len(s.tracked) != 0 {
+		t.Error("Deleted pushes still tracked:", s.tracked)
+	}
+}
+
+func TestUntrackPush(t *testing.T) {
+	s := NewSync(&Client{}, nil)
+	untrack := s.TrackPush("p1", func(PushMessage) { t.Error("Untracked push reported") }, nil)
+	untrack()
+	for _, receipt := range s.receipts([]PushMessage{{ID: "p1", Active: true, Dismissed: true}}) {
+		receipt()
+	}
+}
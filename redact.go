@@ -0,0 +1,95 @@
+package pushbullet
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//redacted replaces API keys in logs and errors
+const redacted = "[REDACTED]"
+
+//keyMemo remembers the last key obtained from a TokenSource so it can be redacted too
+type keyMemo struct {
+	mu  sync.Mutex
+	key string
+}
+
+func (m *keyMemo) set(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.key = key
+}
+
+func (m *keyMemo) get() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.key
+}
+
+//redact replaces the client's API keys, and the Basic credentials made from them, in s
+func (c *Client) redact(s string) string {
+	for _, key := range []string{c.APIKey, c.lastKey.get()} {
+		if len(key) > 0 {
+			s = strings.ReplaceAll(s, base64.StdEncoding.EncodeToString([]byte(key+":")), redacted)
+			s = strings.ReplaceAll(s, key, redacted)
+		}
+	}
+	return s
+}
+
+//Redact replaces the client's API key in s, for text such as a command line which leaves the process. Other
+//secrets in s are kept.
+func (c *Client) Redact(s string) string {
+	return c.redact(s)
+}
+
+//redactedError is an error whose message had an API key removed
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+//redactError returns err, or an error with a redacted message when its message holds an API key
+func (c *Client) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if msg := c.redact(err.Error()); msg != err.Error() {
+		return &redactedError{msg: msg, err: err}
+	}
+	return err
+}
+
+//String describes the client without its API key.
+func (c *Client) String() string {
+	key := ""
+	if len(c.APIKey) > 0 {
+		key = redacted
+	}
+	return fmt.Sprintf("pushbullet.Client{BaseURL: %q, APIKey: %q}", c.BaseURL, key)
+}
+
+//GoString describes the client for %#v without its API key.
+func (c *Client) GoString() string {
+	return c.String()
+}
+
+//String hides the API key.
+func (t StaticToken) String() string {
+	return redacted
+}
+
+//GoString hides the API key from %#v.
+func (t StaticToken) GoString() string {
+	return redacted
+}
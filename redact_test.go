@@ -0,0 +1,54 @@
+package pushbullet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKeyNeverLogged(t *testing.T) {
+	const key = "o.Zx9SecretKeyValue"
+	// a misbehaving server echoing the credentials back in its error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+		fmt.Fprintf(w, `{"error": {"type": "invalid_request", "message": "bad key %s (%s)"}}`, key, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	for _, c := range []*Client{
+		ClientWithKey(key, WithLogLevel(LogDebug), WithLogger(log.New(&buf, "", 0))),
+		ClientWithTokenSource(StaticToken(key), WithLogLevel(LogDebug), WithLogger(log.New(&buf, "", 0))),
+	} {
+		c.BaseURL = server.URL + "/"
+		_, err := c.GetDevices()
+		if err == nil {
+			t.Fatal("Expected the request to fail")
+		}
+		fmt.Fprintln(&buf, err)
+		fmt.Fprintf(&buf, "%v %+v %#v %s\n", c, c, c, c.TokenSource)
+		fmt.Fprintf(&buf, "%v %#v\n", StaticToken(key), StaticToken(key))
+	}
+	out := buf.String()
+	if strings.Contains(out, key) || strings.Contains(out, base64.StdEncoding.EncodeToString([]byte(key+":"))) || !strings.Contains(out, redacted) {
+		t.Error("Key found in output:\n", out)
+	}
+}
+
+func TestRedactedErrorUnwraps(t *testing.T) {
+	c := &Client{APIKey: "o.secret"}
+	inner := errors.New("failed with o.secret")
+	err := c.redactError(inner)
+	if err.Error() != "failed with [REDACTED]" || !errors.Is(err, inner) {
+		t.Error("Unexpected redacted error:", err)
+	}
+	if c.redactError(errors.New("plain")).Error() != "plain" {
+		t.Error("Error without a key was changed")
+	}
+}
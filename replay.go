@@ -0,0 +1,44 @@
+package pushbullet
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+//Replay re-dispatches the locally held pushes created from from up to to as Added events, oldest first, so that a
+//new handler can be backfilled against past activity. A zero from or to leaves that end of the range open. The
+//events are marked Replayed and sent to handler, or to the Sync's Handler when handler is nil, returning the number
+//replayed. Only pushes held by the Sync are replayed: those loaded since it started, limited by PushesSince or a
+//checkpoint.
+func (s *Sync) Replay(from, to time.Time, handler func(ChangeEvent)) int {
+	if handler == nil {
+		handler = s.Handler
+	}
+	start, end := 0.0, math.Inf(1)
+	if !from.IsZero() {
+		start = timestamp(from)
+	}
+	if !to.IsZero() {
+		end = timestamp(to)
+	}
+	var pushes []PushMessage
+	for _, p := range s.Pushes() {
+		if p.Created >= start && p.Created < end {
+			pushes = append(pushes, p)
+		}
+	}
+	sort.Slice(pushes, func(i, j int) bool { return pushes[i].Created < pushes[j].Created })
+	if handler == nil {
+		return 0
+	}
+	for i := range pushes {
+		handler(ChangeEvent{Type: Added, Resource: ResourcePushes, Replayed: true, Push: &pushes[i]})
+	}
+	return len(pushes)
+}
+
+//timestamp converts a time to the fractional Unix seconds used by the API
+func timestamp(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
@@ -0,0 +1,38 @@
+package pushbullet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{"pushes": [
+		{"iden": "p3", "active": true, "created": 300, "modified": 300},
+		{"iden": "p2", "active": true, "created": 200, "modified": 200},
+		{"iden": "p1", "active": true, "created": 100, "modified": 100}]}`)
+	defer mockServer.Close()
+
+	live := 0
+	s := NewSync(c, func(ChangeEvent) { live++ })
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var replayed []string
+	n := s.Replay(time.Unix(150, 0), time.Time{}, func(e ChangeEvent) {
+		if !e.Replayed || e.Type != Added || e.Initial {
+			t.Errorf("Unexpected replayed event: %+v", e)
+		}
+		replayed = append(replayed, e.Push.ID)
+	})
+	if n != 2 || len(replayed) != 2 || replayed[0] != "p2" || replayed[1] != "p3" {
+		t.Error("Expected p2 and p3 oldest first, got:", replayed)
+	}
+
+	// without a handler the Sync's own handler receives the events
+	before := live
+	if n := s.Replay(time.Time{}, time.Unix(200, 0), nil); n != 1 || live != before+1 {
+		t.Error("Expected one event replayed to the Sync handler:", n, live-before)
+	}
+}
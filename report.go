@@ -0,0 +1,80 @@
+package pushbullet
+
+import (
+	"fmt"
+	"time"
+)
+
+//maxReportFailures bounds the failures a Queue keeps for its report; the counts stay exact past it
+const maxReportFailures = 1000
+
+//DeliveryFailure is a push a Queue failed to send.
+type DeliveryFailure struct {
+	At       time.Time `json:"at"`
+	Title    string    `json:"title,omitempty"`
+	Target   Target    `json:"target"`
+	Priority string    `json:"priority"`
+	Reason   string    `json:"reason"`
+}
+
+//DeliveryReport summarizes what a Queue delivered, for batch jobs to attach to their own logs. It marshals to
+//JSON as is.
+type DeliveryReport struct {
+	Attempted int               `json:"attempted"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Dropped   int               `json:"dropped"`  // shed without a Digester
+	Digested  int               `json:"digested"` // shed to the Digester
+	Pending   int               `json:"pending"`
+	Failures  []DeliveryFailure `json:"failures,omitempty"` // the most recent, oldest first
+}
+
+func (r DeliveryReport) String() string {
+	return fmt.Sprintf("%d attempted, %d succeeded, %d failed, %d dropped, %d digested, %d pending",
+		r.Attempted, r.Succeeded, r.Failed, r.Dropped, r.Digested, r.Pending)
+}
+
+//Report summarizes the deliveries so far, with the reason for each failure.
+func (q *Queue) Report() DeliveryReport {
+	s := q.Stats()
+	shed := 0
+	for _, n := range s.Shed {
+		shed += n
+	}
+	q.mu.Lock()
+	failures := append([]DeliveryFailure(nil), q.failures...)
+	q.mu.Unlock()
+	return DeliveryReport{
+		Attempted: s.Sent + s.Failed,
+		Succeeded: s.Sent,
+		Failed:    s.Failed,
+		Dropped:   shed - s.Digested,
+		Digested:  s.Digested,
+		Pending:   s.Pending,
+		Failures:  failures,
+	}
+}
+
+//recordFailureLocked keeps the failure for the report, forgetting the oldest past maxReportFailures
+func (q *Queue) recordFailureLocked(p PushMessage, priority Priority, err error, at time.Time) {
+	q.failures = append(q.failures, DeliveryFailure{At: at, Title: p.Title, Target: pushTarget(p),
+		Priority: priority.String(), Reason: err.Error()})
+	if len(q.failures) > maxReportFailures {
+		q.failures = q.failures[len(q.failures)-maxReportFailures:]
+	}
+}
+
+//pushTarget returns where the push is addressed
+func pushTarget(p PushMessage) Target {
+	switch {
+	case len(p.DeviceID) > 0:
+		return DeviceTarget(p.DeviceID)
+	case len(p.Email) > 0:
+		return EmailTarget(p.Email)
+	case len(p.ChannelTag) > 0:
+		return ChannelTarget(p.ChannelTag)
+	case len(p.ClientID) > 0:
+		return ClientTarget(p.ClientID)
+	}
+	return Target{}
+}
@@ -0,0 +1,48 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestQueueReport(t *testing.T) {
+	var titles []string
+	status := 200
+	server, c := titleServer(&titles, &status)
+	defer server.Close()
+	q := NewQueue(c)
+	q.Capacity = 2
+	at := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return at }
+
+	q.Enqueue(PushMessage{Type: "note", Title: "backup done"}, PriorityNormal)
+	q.Enqueue(PushMessage{Type: "note", Title: "report"}, PriorityHigh)
+	q.Enqueue(PushMessage{Type: "note", Title: "noise"}, PriorityLow)
+	q.sendNext(context.Background())
+	status = 400
+	q.Enqueue(PushMessage{Type: "note", Title: "vacuum done", DeviceID: "phone"}, PriorityNormal)
+	q.sendNext(context.Background())
+
+	r := q.Report()
+	want := DeliveryFailure{At: at, Title: "backup done", Priority: "normal", Reason: "Status code: 400"}
+	if r.Attempted != 2 || r.Succeeded != 1 || r.Failed != 1 || r.Dropped != 1 || r.Pending != 1 ||
+		len(r.Failures) != 1 || r.Failures[0] != want {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+	if r.String() != "2 attempted, 1 succeeded, 1 failed, 1 dropped, 0 digested, 1 pending" {
+		t.Error("Unexpected summary:", r)
+	}
+	b, _ := json.Marshal(r)
+	var decoded DeliveryReport
+	if err := json.Unmarshal(b, &decoded); err != nil || decoded.Failures[0].Reason != want.Reason || decoded.Dropped != 1 {
+		t.Errorf("Report did not round trip through JSON: %s", b)
+	}
+
+	q.Enqueue(PushMessage{Type: "note", Title: "last", DeviceID: "phone"}, PriorityHigh)
+	q.sendNext(context.Background())
+	if f := q.Report().Failures; len(f) != 2 || f[1].Target != DeviceTarget("phone") {
+		t.Errorf("Expected the failure's target: %+v", f)
+	}
+}
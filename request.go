@@ -0,0 +1,88 @@
+package pushbullet
+
+import "reflect"
+
+//PushRequest holds the fields of a push which are sent to create it, as taken by SendPush. Pushes read back from
+//Pushbullet decode into PushMessage, whose response-only fields such as SenderEmail or Dismissed are never posted.
+type PushRequest struct {
+	Type  string   `json:"type"`
+	Title string   `json:"title,omitempty"`
+	Body  string   `json:"body,omitempty"`
+	URL   string   `json:"url,omitempty"`
+	Items []string `json:"items,omitempty"`
+	// Name and Address are only used by the deprecated address type
+	Name     string `json:"name,omitempty"`
+	Address  string `json:"address,omitempty"`
+	FileName string `json:"file_name,omitempty"`
+	FileType string `json:"file_type,omitempty"`
+	FileURL  string `json:"file_url,omitempty"`
+
+	// At most one target may be set; with none the push goes to all of the user's devices
+	DeviceID   string `json:"device_iden,omitempty"`
+	Email      string `json:"email,omitempty"`
+	ChannelTag string `json:"channel_tag,omitempty"`
+	ClientID   string `json:"client_iden,omitempty"`
+
+	SourceDeviceID string `json:"source_device_iden,omitempty"`
+	GUID           string `json:"guid,omitempty"`
+
+	// Extra holds additional fields to send, which never replace the typed fields above.
+	Extra map[string]interface{} `json:"-"`
+}
+
+//requestFields is PushRequest without its methods, so it encodes with the default rules
+type requestFields PushRequest
+
+//typedRequestFields are the JSON names of the fields of PushRequest
+var typedRequestFields = jsonFieldNames(reflect.TypeOf(PushRequest{}))
+
+//Request returns the fields of the push which are sent to create it, sharing its Items and Extra.
+func (p PushMessage) Request() PushRequest {
+	return PushRequest{
+		Type:           p.Type,
+		Title:          p.Title,
+		Body:           p.Body,
+		URL:            p.URL,
+		Items:          p.Items,
+		Name:           p.Name,
+		Address:        p.Address,
+		FileName:       p.FileName,
+		FileType:       p.FileType,
+		FileURL:        p.FileURL,
+		DeviceID:       p.DeviceID,
+		Email:          p.Email,
+		ChannelTag:     p.ChannelTag,
+		ClientID:       p.ClientID,
+		SourceDeviceID: p.SourceDeviceID,
+		GUID:           p.GUID,
+		Extra:          p.Extra,
+	}
+}
+
+//Message returns the request as a PushMessage, for the helpers taking one such as Validate.
+func (r PushRequest) Message() PushMessage {
+	return PushMessage{
+		Type:           r.Type,
+		Title:          r.Title,
+		Body:           r.Body,
+		URL:            r.URL,
+		Items:          r.Items,
+		Name:           r.Name,
+		Address:        r.Address,
+		FileName:       r.FileName,
+		FileType:       r.FileType,
+		FileURL:        r.FileURL,
+		DeviceID:       r.DeviceID,
+		Email:          r.Email,
+		ChannelTag:     r.ChannelTag,
+		ClientID:       r.ClientID,
+		SourceDeviceID: r.SourceDeviceID,
+		GUID:           r.GUID,
+		Extra:          r.Extra,
+	}
+}
+
+//MarshalJSON encodes the request, merging in any Extra fields which are not typed fields.
+func (r PushRequest) MarshalJSON() ([]byte, error) {
+	return mergeFields(requestFields(r), typedRequestFields, r.Extra, nil)
+}
@@ -0,0 +1,46 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSendPostsOnlyRequestFields(t *testing.T) {
+	var sent map[string]interface{}
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte(`{"iden": "p1", "type": "note", "title": "Resend", "sender_email": "me@example.com", "dismissed": true}`))
+	})
+	defer server.Close()
+
+	// resending a push read back from the history
+	var old PushMessage
+	json.Unmarshal([]byte(`{"iden": "p0", "type": "note", "title": "Resend", "device_iden": "d1", "sender_email": "me@example.com", "dismissed": true, "created": 1}`), &old)
+	created, err := c.SendPush(old.Request())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"type": "note", "title": "Resend", "device_iden": "d1"}
+	if !reflect.DeepEqual(sent, want) {
+		t.Error("Unexpected request body:", sent)
+	}
+	if created.ID != "p1" || created.SenderEmail != "me@example.com" || !created.Dismissed {
+		t.Errorf("Response fields not decoded: %+v", created)
+	}
+}
+
+func TestPushRequestConversion(t *testing.T) {
+	r := PushRequest{Type: "checklist", Title: "Groceries", Items: []string{"milk"}, ChannelTag: "home", GUID: "g1",
+		Extra: map[string]interface{}{"image_width": 64, "type": "clobbered"}}
+	if back := r.Message().Request(); !reflect.DeepEqual(back, r) {
+		t.Errorf("Conversion lost fields: %+v", back)
+	}
+	data, _ := json.Marshal(r)
+	if string(data) != `{"channel_tag":"home","guid":"g1","image_width":64,"items":["milk"],"title":"Groceries","type":"checklist"}` {
+		t.Error("Unexpected encoding:", string(data))
+	}
+}
@@ -0,0 +1,47 @@
+package pushbullet
+
+import (
+	"bytes"
+	"reflect"
+)
+
+//DecodeError is returned when a successful response carried a body which could not be decoded,
+//distinguishing malformed responses from empty ones (which decode to zero values).
+type DecodeError struct {
+	Body []byte
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return "Malformed response: " + e.Err.Error()
+}
+
+//decode unmarshals a response body into v, leaving v untouched when the body is empty (e.g. 204 No Content)
+func (c *Client) decode(body []byte, v interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var err error
+	if strict, ok := c.codec().(StrictCodec); ok && c.StrictDecoding {
+		err = strict.UnmarshalStrict(body, v)
+	} else if c.StrictDecoding {
+		err = StdCodec{}.UnmarshalStrict(body, v)
+	} else {
+		err = c.codec().Unmarshal(body, v)
+	}
+	if err != nil {
+		return &DecodeError{Body: body, Err: err}
+	}
+	if c.KeepRaw {
+		captureRaw(reflect.ValueOf(v), body)
+	}
+	return nil
+}
+
+//WithStrictDecoding rejects responses containing fields the library does not know about, surfacing schema drift
+//during development. Decoding is lenient by default.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.StrictDecoding = true
+	}
+}
@@ -0,0 +1,179 @@
+package pushbullet
+
+import (
+	"context"
+	"strings"
+)
+
+//Event is a notification with the metadata a Router matches on.
+type Event struct {
+	Title    string
+	Body     string
+	Severity string            // e.g. "critical", "warning"
+	Source   string            // the service or check which raised the event
+	Labels   map[string]string // any further metadata
+}
+
+//Target is where a push is sent. The zero Target is all of the user's devices.
+type Target struct {
+	Kind string `json:"kind"` // device, email, channel or client
+	ID   string `json:"id"`   // device iden, email, channel tag or client iden
+}
+
+//DeviceTarget targets a single device.
+func DeviceTarget(deviceID string) Target { return Target{Kind: "device", ID: deviceID} }
+
+//EmailTarget targets a user by email.
+func EmailTarget(email string) Target { return Target{Kind: "email", ID: email} }
+
+//ChannelTarget targets the subscribers of an owned channel.
+func ChannelTarget(tag string) Target { return Target{Kind: "channel", ID: tag} }
+
+//ClientTarget targets the users of an OAuth client.
+func ClientTarget(clientID string) Target { return Target{Kind: "client", ID: clientID} }
+
+//NotifyOption addresses a notification to the target.
+func (t Target) NotifyOption() NotifyOption {
+	switch t.Kind {
+	case "device":
+		return ToDevice(t.ID)
+	case "email":
+		return ToEmail(t.ID)
+	case "channel":
+		return ToChannel(t.ID)
+	case "client":
+		return ToClient(t.ID)
+	}
+	return func(*PushMessage) {}
+}
+
+//Rule selects targets for the events it matches. Each non-empty condition must hold; a list condition holds when
+//any of its values does. Matching is case insensitive.
+type Rule struct {
+	Name     string            `json:"name"`
+	Severity []string          `json:"severity,omitempty"`
+	Source   []string          `json:"source,omitempty"`
+	Keywords []string          `json:"keywords,omitempty"` // found in the title or body
+	Labels   map[string]string `json:"labels,omitempty"`
+	Targets  []Target          `json:"targets"`
+	// TitlePrefix is prepended to the title of the routed pushes, e.g. "[CRIT] ".
+	TitlePrefix string `json:"title_prefix,omitempty"`
+	// Transforms adjust the routed pushes, after the target is set.
+	Transforms []NotifyOption `json:"-"`
+	// Continue evaluates later rules after this one matched; routing stops at the first match otherwise.
+	Continue bool `json:"continue,omitempty"`
+}
+
+//Matches reports whether the event meets all of the rule's conditions.
+func (r Rule) Matches(e Event) bool {
+	if !matchAny(r.Severity, func(s string) bool { return strings.EqualFold(s, e.Severity) }) ||
+		!matchAny(r.Source, func(s string) bool { return strings.EqualFold(s, e.Source) }) {
+		return false
+	}
+	text := strings.ToLower(e.Title + "\n" + e.Body)
+	if !matchAny(r.Keywords, func(k string) bool { return strings.Contains(text, strings.ToLower(k)) }) {
+		return false
+	}
+	for k, v := range r.Labels {
+		if !strings.EqualFold(e.Labels[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+//Router sends each event to the targets of the rules it matches, centralizing decisions like "criticals go to my
+//phone, warnings go to the team channel".
+//
+//	r := &pushbullet.Router{Notifier: client, Rules: []pushbullet.Rule{
+//		{Severity: []string{"critical"}, Targets: []pushbullet.Target{pushbullet.DeviceTarget(phone)}},
+//		{Severity: []string{"warning"}, Targets: []pushbullet.Target{pushbullet.ChannelTarget("team")}},
+//	}}
+type Router struct {
+	Notifier Notifier
+	Rules    []Rule
+	Default  []Target // used when no rule matches; unmatched events are dropped when empty
+	// Maintenance, when set, silences the pushes its active windows match.
+	Maintenance *Maintenance
+}
+
+//Route returns the pushes the event is sent as, one per distinct target not silenced by a maintenance window.
+func (r *Router) Route(e Event) []PushMessage {
+	var targets []Target
+	rules := make(map[Target]*Rule)
+	add := func(ts []Target, rule *Rule) {
+		for _, t := range ts {
+			if _, seen := rules[t]; seen {
+				continue
+			}
+			rules[t] = rule
+			targets = append(targets, t)
+		}
+	}
+	matched := false
+	for i := range r.Rules {
+		if !r.Rules[i].Matches(e) {
+			continue
+		}
+		matched = true
+		add(r.Rules[i].Targets, &r.Rules[i])
+		if !r.Rules[i].Continue {
+			break
+		}
+	}
+	if !matched {
+		add(r.Default, nil)
+	}
+
+	// the event is audited and digested once, however many of its targets are silenced
+	silenced := make(map[Target]bool)
+	if r.Maintenance != nil {
+		for _, t := range r.Maintenance.Suppress(e, targets...) {
+			silenced[t] = true
+		}
+	}
+	var pushes []PushMessage
+	for _, t := range targets {
+		if silenced[t] {
+			continue
+		}
+		opts := []NotifyOption{t.NotifyOption()}
+		if rule := rules[t]; rule != nil {
+			if len(rule.TitlePrefix) > 0 {
+				prefix := rule.TitlePrefix
+				opts = append(opts, func(p *PushMessage) { p.Title = prefix + p.Title })
+			}
+			opts = append(opts, rule.Transforms...)
+		}
+		pushes = append(pushes, NotifyPush(e.Title, e.Body, opts...))
+	}
+	return pushes
+}
+
+//Send routes the event and notifies each target, returning a NotifyErrors listing the deliveries which failed.
+func (r *Router) Send(ctx context.Context, e Event) error {
+	var errs NotifyErrors
+	for _, p := range r.Route(e) {
+		push := p
+		err := r.Notifier.Notify(ctx, p.Title, p.Body, func(m *PushMessage) { *m = push })
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	rec := &RecordingNotifier{}
+	r := &Router{
+		Notifier: rec,
+		Rules: []Rule{
+			{Name: "critical", Severity: []string{"critical"}, Targets: []Target{DeviceTarget("phone")}, TitlePrefix: "[CRIT] ", Continue: true},
+			{Name: "database", Keywords: []string{"postgres"}, Targets: []Target{DeviceTarget("phone"), EmailTarget("dba@example.com")}},
+			{Name: "warnings", Severity: []string{"warning", "critical"}, Labels: map[string]string{"team": "ops"}, Targets: []Target{ChannelTarget("ops")}},
+		},
+		Default: []Target{{}},
+	}
+	ctx := context.Background()
+
+	if err := r.Send(ctx, Event{Title: "Postgres down", Severity: "Critical"}); err != nil {
+		t.Fatal(err)
+	}
+	pushes := rec.Pushes()
+	if len(pushes) != 2 || pushes[0].DeviceID != "phone" || pushes[0].Title != "[CRIT] Postgres down" ||
+		pushes[1].Email != "dba@example.com" || pushes[1].Title != "Postgres down" {
+		t.Fatalf("Unexpected routing of a critical database event: %+v", pushes)
+	}
+
+	got := r.Route(Event{Title: "Disk 80%", Severity: "warning", Labels: map[string]string{"team": "ops"}})
+	if len(got) != 1 || got[0].ChannelTag != "ops" {
+		t.Errorf("Unexpected routing of a warning: %+v", got)
+	}
+	got = r.Route(Event{Title: "Hello", Severity: "info"})
+	if len(got) != 1 || got[0].DeviceID != "" || got[0].ChannelTag != "" {
+		t.Errorf("Expected the default target for an unmatched event: %+v", got)
+	}
+}
+
+func TestRulesFromJSON(t *testing.T) {
+	var rules []Rule
+	err := json.Unmarshal([]byte(`[{"name": "crit", "severity": ["critical"], "targets": [{"kind": "device", "id": "phone"}]}]`), &rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := (&Router{Rules: rules}).Route(Event{Title: "Down", Severity: "critical"})
+	if len(got) != 1 || got[0].DeviceID != "phone" {
+		t.Errorf("Unexpected routing from decoded rules: %+v", got)
+	}
+}
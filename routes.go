@@ -0,0 +1,73 @@
+package pushbullet
+
+import (
+	"net/url"
+	"strings"
+)
+
+//Location of the Pushbullet API used by ClientWithKey
+const (
+	DefaultAPIRoot    = "https://api.pushbullet.com/"
+	DefaultAPIVersion = "v2"
+)
+
+//Routes of the API endpoints, relative to the versioned BaseURL
+const (
+	routeUser          = "users/me"
+	routeDevices       = "devices"
+	routeContacts      = "contacts"
+	routeChats         = "chats"
+	routeSubscriptions = "subscriptions"
+	routeChannelInfo   = "channel-info"
+	routePushes        = "pushes"
+	routeUploadRequest = "upload-request"
+	routeEphemerals    = "ephemerals"
+)
+
+//route builds the path of a record under a route, escaping each parameter so that idens can never add path
+//segments or a query
+func route(base string, params ...string) string {
+	for _, p := range params {
+		base += "/" + url.PathEscape(p)
+	}
+	return base
+}
+
+//query appends the encoded query to a route, leaving the route alone when there are no parameters
+func query(route string, q url.Values) string {
+	if len(q) == 0 {
+		return route
+	}
+	return route + "?" + q.Encode()
+}
+
+//pushRoute is the route of one push
+func pushRoute(pushID string) string {
+	return route(routePushes, pushID)
+}
+
+//contactRoute is the route of one contact
+func contactRoute(contactID string) string {
+	return route(routeContacts, contactID)
+}
+
+//subscriptionRoute is the route of one subscription
+func subscriptionRoute(subscriptionID string) string {
+	return route(routeSubscriptions, subscriptionID)
+}
+
+//WithAPI points the client at the API under root with the given version, for example a future "v3", or a mock
+//server mounted under another prefix. An empty version uses root as it is.
+func WithAPI(root, version string) Option {
+	return func(c *Client) {
+		c.BaseURL = strings.TrimSuffix(root, "/") + "/"
+		if len(version) > 0 {
+			c.BaseURL += strings.Trim(version, "/") + "/"
+		}
+	}
+}
+
+//endpoint returns the URL of a call, which is a route with any path parameters and query
+func (c *Client) endpoint(call string) string {
+	return strings.TrimSuffix(c.BaseURL, "/") + "/" + strings.TrimPrefix(call, "/")
+}
@@ -0,0 +1,86 @@
+package pushbullet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithAPI(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	for _, c := range []*Client{
+		ClientWithKey("apikey", WithAPI(server.URL+"/mock", "v3")),
+		ClientWithKey("apikey", WithAPI(server.URL+"/mock/", "/v3/")),
+		{APIKey: "apikey", BaseURL: server.URL + "/mock/v3", HTTPClient: &http.Client{}},
+	} {
+		if _, err := c.GetUser(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range paths {
+		if p != "/mock/v3/users/me" {
+			t.Error("Unexpected path:", paths)
+		}
+	}
+
+	if c := ClientWithKey("apikey"); c.BaseURL != "https://api.pushbullet.com/v2/" {
+		t.Error("Unexpected default BaseURL:", c.BaseURL)
+	}
+	if c := ClientWithKey("apikey", WithAPI(server.URL, "")); c.endpoint(routePushes) != server.URL+"/pushes" {
+		t.Error("Unexpected unversioned endpoint:", c.endpoint(routePushes))
+	}
+}
+
+func TestRoutesEscapeIdens(t *testing.T) {
+	var got []string
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.EscapedPath()+" "+r.URL.RawQuery)
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	c.DeletePush("../devices?x=1")
+	c.UnsubscribeChannel("a/b")
+	c.DeleteContact("c#1")
+	want := []string{"DELETE /pushes/..%2Fdevices%3Fx=1 ", "DELETE /subscriptions/a%2Fb ", "DELETE /contacts/c%231 "}
+	if len(got) != len(want) {
+		t.Fatal("Unexpected requests:", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], got[i])
+		}
+	}
+}
+
+func TestQueryEncoding(t *testing.T) {
+	cases := []struct {
+		q    url.Values
+		want string
+	}{
+		{nil, "pushes"},
+		{url.Values{}, "pushes"},
+		{url.Values{"tag": {"a&b=c"}}, "pushes?tag=a%26b%3Dc"},
+		{url.Values{"tag": {"two words+plus"}}, "pushes?tag=two+words%2Bplus"},
+		{url.Values{"tag": {"café/#"}}, "pushes?tag=caf%C3%A9%2F%23"},
+		{url.Values{"tag": {""}}, "pushes?tag="},
+		{url.Values{"modified_after": {"1.5"}, "active": {"true"}, "cursor": {"x?y"}}, "pushes?active=true&cursor=x%3Fy&modified_after=1.5"},
+	}
+	for _, tc := range cases {
+		if got := query(routePushes, tc.q); got != tc.want {
+			t.Errorf("Expected %q, got %q", tc.want, got)
+		}
+		if parsed, err := url.ParseQuery(strings.TrimPrefix(strings.TrimPrefix(query(routePushes, tc.q), routePushes), "?")); err != nil || (len(tc.q) > 0 && !reflect.DeepEqual(parsed, tc.q)) {
+			t.Errorf("Query %v did not round trip: %v, %v", tc.q, parsed, err)
+		}
+	}
+}
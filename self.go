@@ -0,0 +1,49 @@
+package pushbullet
+
+import (
+	"context"
+	"sync"
+)
+
+//userCache holds the authenticated user once fetched
+type userCache struct {
+	mu    sync.Mutex
+	valid bool
+	user  User
+}
+
+//CurrentUser returns the authenticated user, fetched once and cached for the life of the client.
+func (c *Client) CurrentUser() (User, error) {
+	return c.CurrentUserContext(context.Background())
+}
+
+//CurrentUserContext is CurrentUser bound to a context which may cancel its requests
+func (c *Client) CurrentUserContext(ctx context.Context) (User, error) {
+	c.user.mu.Lock()
+	defer c.user.mu.Unlock()
+	if c.user.valid {
+		return c.user.user, nil
+	}
+	u, err := c.GetUserContext(ctx)
+	if err != nil {
+		return u, err
+	}
+	c.user.valid, c.user.user = true, u
+	return u, nil
+}
+
+//SendToSelf sends a note to the authenticated user's own email, reaching all of their devices without the address
+//being configured.
+func (c *Client) SendToSelf(title, body string) error {
+	return c.SendToSelfContext(context.Background(), title, body)
+}
+
+//SendToSelfContext is SendToSelf bound to a context which may cancel its requests
+func (c *Client) SendToSelfContext(ctx context.Context, title, body string) error {
+	u, err := c.CurrentUserContext(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.sendPush(ctx, PushMessage{Type: "note", Title: title, Body: body, Email: u.Email})
+	return err
+}
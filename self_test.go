@@ -0,0 +1,36 @@
+package pushbullet
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSendToSelf(t *testing.T) {
+	userRequests := 0
+	var sent []PushMessage
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/me" {
+			userRequests++
+			w.Write([]byte(`{"iden": "u1", "email": "me@example.com"}`))
+			return
+		}
+		var p PushMessage
+		json.NewDecoder(r.Body).Decode(&p)
+		sent = append(sent, p)
+		w.Write([]byte("{}"))
+	})
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := c.SendToSelf("Reminder", "buy milk"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if userRequests != 1 {
+		t.Error("Expected the user to be fetched once, got:", userRequests)
+	}
+	if len(sent) != 2 || sent[0].Email != "me@example.com" || sent[0].Type != "note" || sent[0].Body != "buy milk" {
+		t.Errorf("Unexpected pushes: %+v", sent)
+	}
+}
@@ -0,0 +1,175 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ErrSkipped marks a self-test stage which was not run.
+var ErrSkipped = errors.New("skipped")
+
+//SelfTestStage is the outcome of one stage of a self-test.
+type SelfTestStage struct {
+	Name     string
+	Duration time.Duration
+	Err      error // nil when the stage passed, wrapping ErrSkipped when it was not run
+}
+
+//SelfTestReport is the outcome of a self-test, stage by stage.
+type SelfTestReport struct {
+	PushID string
+	Stages []SelfTestStage
+}
+
+//OK reports whether every stage which ran passed.
+func (r SelfTestReport) OK() bool {
+	for _, s := range r.Stages {
+		if s.Err != nil && !errors.Is(s.Err, ErrSkipped) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r SelfTestReport) String() string {
+	lines := make([]string, len(r.Stages))
+	for i, s := range r.Stages {
+		switch {
+		case s.Err == nil:
+			lines[i] = fmt.Sprintf("ok    %-8s %v", s.Name, s.Duration.Round(time.Millisecond))
+		case errors.Is(s.Err, ErrSkipped):
+			lines[i] = fmt.Sprintf("skip  %-8s %v", s.Name, s.Err)
+		default:
+			lines[i] = fmt.Sprintf("FAIL  %-8s %v (%v)", s.Name, s.Err, s.Duration.Round(time.Millisecond))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+//selfTestStreamWait bounds the wait for the test push to be announced on the stream
+var selfTestStreamWait = 10 * time.Second
+
+//tickleResult is when a push tickle arrived, or why none did
+type tickleResult struct {
+	err error
+	at  time.Time
+}
+
+//awaitPushTickle reads the stream until a push tickle arrives
+func awaitPushTickle(ws *wsConn, result chan<- tickleResult) {
+	ws.SetReadDeadline(time.Now().Add(selfTestStreamWait))
+	for {
+		message, err := ws.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("No push tickle received within %v", selfTestStreamWait)
+			}
+			result <- tickleResult{err: err, at: time.Now()}
+			return
+		}
+		var e StreamEvent
+		if json.Unmarshal(message, &e) == nil && e.Type == StreamTickle && e.Subtype == "push" {
+			result <- tickleResult{at: time.Now()}
+			return
+		}
+	}
+}
+
+//selfTestHistoryAttempts bounds the history lookups made while waiting for the test push to appear
+var selfTestHistoryAttempts = 5
+
+//SelfTest exercises the full pipeline end to end: it sends a test note with a guid to the user's own account,
+//confirms it appears in the push history, dismisses it and deletes it, timing each stage. The test push is
+//deleted whenever it was sent, even when an earlier stage failed. The event stream is connected before sending, and
+//the stream stage times the push tickle announcing the test push.
+func (c *Client) SelfTest(ctx context.Context) SelfTestReport {
+	var report SelfTestReport
+	stage := func(name string, run func() error) error {
+		start := time.Now()
+		err := run()
+		report.Stages = append(report.Stages, SelfTestStage{Name: name, Duration: time.Since(start), Err: err})
+		return err
+	}
+	skip := func(name, reason string) {
+		report.Stages = append(report.Stages, SelfTestStage{Name: name, Err: fmt.Errorf("%w: %s", ErrSkipped, reason)})
+	}
+
+	ws, streamErr := c.dialStream(ctx, false)
+	if ws != nil {
+		defer ws.Close()
+	}
+
+	guid := "selftest:" + newGUID()
+	var sent PushMessage
+	err := stage("send", func() error {
+		var err error
+		sent, err = c.sendPush(ctx, PushMessage{Type: "note", Title: "Pushbullet self-test",
+			Body: "Sent by a self-test, deleted once it completes.", GUID: guid})
+		if err == nil && len(sent.ID) == 0 {
+			err = errors.New("The created push has no iden")
+		}
+		return err
+	})
+	if err != nil {
+		for _, name := range []string{"history", "stream", "dismiss", "delete"} {
+			skip(name, "the test push was not sent")
+		}
+		return report
+	}
+	report.PushID = sent.ID
+	sentAt := time.Now()
+	tickled := make(chan tickleResult, 1)
+	if ws != nil {
+		go awaitPushTickle(ws, tickled)
+	}
+
+	historyErr := stage("history", func() error {
+		q := url.Values{"modified_after": {strconv.FormatFloat(sent.Modified-1, 'f', -1, 64)}}
+		for attempt := 0; ; attempt++ {
+			var l PushList
+			if err := c.getContext(ctx, query(routePushes, q), &l); err != nil {
+				return err
+			}
+			for _, p := range l.Pushes {
+				if p.ID == sent.ID {
+					if p.GUID != guid {
+						return fmt.Errorf("The test push came back with guid %q", p.GUID)
+					}
+					return nil
+				}
+			}
+			if attempt+1 >= selfTestHistoryAttempts {
+				return errors.New("The test push did not appear in the history")
+			}
+			if err := sleepContext(ctx, time.Duration(attempt+1)*200*time.Millisecond); err != nil {
+				return err
+			}
+		}
+	})
+	if ws == nil {
+		report.Stages = append(report.Stages, SelfTestStage{Name: "stream", Err: streamErr})
+	} else {
+		var r tickleResult
+		select {
+		case r = <-tickled:
+		case <-ctx.Done():
+			r = tickleResult{err: ctx.Err(), at: time.Now()}
+		}
+		report.Stages = append(report.Stages, SelfTestStage{Name: "stream", Duration: r.at.Sub(sentAt), Err: r.err})
+	}
+	if historyErr != nil {
+		skip("dismiss", "the test push was not found")
+	} else {
+		stage("dismiss", func() error { return c.DismissPushContext(ctx, sent.ID) })
+	}
+	stage("delete", func() error { return c.DeletePushContext(ctx, sent.ID) })
+	return report
+}
@@ -0,0 +1,69 @@
+package pushbullet_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func TestSelfTest(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+
+	report := c.SelfTest(context.Background())
+	if !report.OK() || len(report.PushID) == 0 {
+		t.Fatalf("self-test failed:\n%v", report)
+	}
+	var names []string
+	for _, s := range report.Stages {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, " "); got != "send history stream dismiss delete" {
+		t.Errorf("stages = %q", got)
+	}
+	calls := server.Calls()
+	want := []string{"POST /pushes/" + report.PushID, "DELETE /pushes/" + report.PushID}
+	tail := calls[len(calls)-2:]
+	for i := range want {
+		if tail[i] != want[i] {
+			t.Errorf("call %d = %q, want %q", i, tail[i], want[i])
+		}
+	}
+	if pushes := server.Pushes(); len(pushes) != 0 {
+		t.Errorf("test push left behind: %+v", pushes)
+	}
+}
+
+func TestSelfTestMissingFromHistory(t *testing.T) {
+	defer pushbullet.SetSelfTestLimits(1, 50*time.Millisecond)()
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	// the push is created, but never shows up in history
+	server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != "GET" || r.URL.Path != "/pushes" {
+			return false
+		}
+		json.NewEncoder(w).Encode(pushbullet.PushList{})
+		return true
+	})
+	c := server.Client()
+
+	report := c.SelfTest(context.Background())
+	if report.OK() {
+		t.Fatalf("self-test passed with the push missing from history:\n%v", report)
+	}
+	if !errors.Is(report.Stages[3].Err, pushbullet.ErrSkipped) {
+		t.Errorf("dismiss stage = %v, want skipped", report.Stages[3].Err)
+	}
+	if calls := server.Calls(); calls[len(calls)-1] != "DELETE /pushes/"+report.PushID {
+		t.Errorf("test push not cleaned up, last call %q", calls[len(calls)-1])
+	}
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/checkpoint"
+)
+
+//Health configures the GET /healthz route, which reports the state of a process receiving from Pushbullet so that
+//container orchestrators can probe it. The route needs no token, as probes seldom carry one, and reveals no
+//Pushbullet data. Unset fields are left out of the report.
+type Health struct {
+	Listener    *pushbullet.Listener
+	Queue       *pushbullet.Queue
+	Checkpoints checkpoint.Store
+	Resources   []string // checkpoint keys reported, by default the resources saved by a pushbullet.Sync
+
+	MaxEventAge      time.Duration // longest silence on a connected stream, by default pushbullet.DefaultStreamTimeout
+	MaxCheckpointLag time.Duration // longest time since a checkpoint's newest change, 0 for no limit
+}
+
+//HealthReport is the JSON body of the health route, served with 200 when Problems is empty and 503 otherwise.
+//Ages are in seconds.
+type HealthReport struct {
+	Status      string                      `json:"status"` // "ok" or "unavailable"
+	Stream      *StreamHealth               `json:"stream,omitempty"`
+	Queue       *QueueHealth                `json:"queue,omitempty"`
+	Checkpoints map[string]CheckpointHealth `json:"checkpoints,omitempty"`
+	Problems    []string                    `json:"problems,omitempty"`
+}
+
+//StreamHealth is the state of the realtime event stream.
+type StreamHealth struct {
+	Connected    bool    `json:"connected"`
+	LastEventAge float64 `json:"last_event_age"` // since the last event, or the connection when none arrived since
+}
+
+//QueueHealth is the state of the push queue.
+type QueueHealth struct {
+	Depth   int  `json:"depth"`
+	Paused  bool `json:"paused"`
+	Offline bool `json:"offline"`
+}
+
+//CheckpointHealth is how far a resource's checkpoint lags behind now.
+type CheckpointHealth struct {
+	Lag float64 `json:"lag"`
+}
+
+//Report returns the current health.
+func (h *Health) Report() (HealthReport, error) {
+	now := time.Now()
+	var report HealthReport
+	if h.Listener != nil {
+		status := h.Listener.Status()
+		last := status.Since
+		if status.LastEvent.After(last) {
+			last = status.LastEvent
+		}
+		age := now.Sub(last)
+		report.Stream = &StreamHealth{Connected: status.Connected, LastEventAge: age.Seconds()}
+		maxAge := h.MaxEventAge
+		if maxAge <= 0 {
+			maxAge = pushbullet.DefaultStreamTimeout
+		}
+		switch {
+		case !status.Connected:
+			report.Problems = append(report.Problems, "stream disconnected")
+		case age > maxAge:
+			report.Problems = append(report.Problems, fmt.Sprintf("no stream event for %s", age.Round(time.Second)))
+		}
+	}
+	if h.Queue != nil {
+		stats := h.Queue.Stats()
+		report.Queue = &QueueHealth{Depth: stats.Pending, Paused: h.Queue.Paused(), Offline: stats.Offline}
+		if stats.Offline {
+			report.Problems = append(report.Problems, "queue offline")
+		}
+	}
+	if h.Checkpoints != nil {
+		resources := h.Resources
+		if len(resources) == 0 {
+			resources = []string{pushbullet.ResourceDevices, pushbullet.ResourceChats, pushbullet.ResourceSubscriptions, pushbullet.ResourcePushes}
+		}
+		report.Checkpoints = make(map[string]CheckpointHealth)
+		for _, resource := range resources {
+			cp, ok, err := h.Checkpoints.Load(resource)
+			if err != nil {
+				return HealthReport{}, err
+			}
+			if !ok {
+				continue
+			}
+			lag := now.Sub(time.Unix(0, int64(cp.Modified*float64(time.Second))))
+			report.Checkpoints[resource] = CheckpointHealth{Lag: lag.Seconds()}
+			if h.MaxCheckpointLag > 0 && lag > h.MaxCheckpointLag {
+				report.Problems = append(report.Problems, fmt.Sprintf("%s checkpoint lags by %s", resource, lag.Round(time.Second)))
+			}
+		}
+	}
+	report.Status = "ok"
+	if len(report.Problems) > 0 {
+		report.Status = "unavailable"
+	}
+	return report, nil
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	if s.Health == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "GET" && r.Method != "HEAD" {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	report, err := s.Health.Report()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
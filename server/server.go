@@ -0,0 +1,201 @@
+//Package server exposes a pushbullet.Client over a small authenticated HTTP API so that services written in
+//other languages can share one Pushbullet connection, API key, and rate budget.
+//
+//Routes, all requiring an "Authorization: Bearer <token>" header:
+//
+//	POST /v1/pushes         send the JSON encoded push in the request body
+//	GET  /v1/pushes         push history, optionally filtered with ?modified_after=<timestamp>
+//	GET  /v1/devices        registered devices
+//	GET  /v1/chats          chats
+//	GET  /v1/subscriptions  channel subscriptions
+//	GET  /v1/events         server-sent events for every change published to the server
+//
+//An X-Request-Id header is attached to the calls made for the request as a pushbullet.AnnotationRequestID
+//annotation, tying them back to the caller's request in the client's logs and request hook.
+//
+//Events are published by wiring the server into a pushbullet.Sync, e.g. sync.Handler = srv.Publish.
+//
+//When the Health field is set, GET /healthz reports the stream, queue and checkpoint state without a token.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//maxPushBody is the largest push accepted in a request body, in bytes
+const maxPushBody = 1 << 20
+
+//Server serves the HTTP API.
+type Server struct {
+	Client    *pushbullet.Client
+	AuthToken string
+	Health    *Health // served on /healthz when set
+
+	mux         *http.ServeMux
+	mu          sync.Mutex
+	subscribers map[chan pushbullet.ChangeEvent]struct{}
+}
+
+//New returns a Server for the client which accepts requests bearing authToken.
+func New(c *pushbullet.Client, authToken string) *Server {
+	s := &Server{
+		Client:      c,
+		AuthToken:   authToken,
+		mux:         http.NewServeMux(),
+		subscribers: make(map[chan pushbullet.ChangeEvent]struct{}),
+	}
+	s.mux.HandleFunc("/v1/pushes", s.pushes)
+	s.mux.HandleFunc("/v1/devices", s.list(func(ctx context.Context) (interface{}, error) { return s.Client.GetDevicesContext(ctx) }))
+	s.mux.HandleFunc("/v1/chats", s.list(func(ctx context.Context) (interface{}, error) { return s.Client.GetChatsContext(ctx) }))
+	s.mux.HandleFunc("/v1/subscriptions", s.list(func(ctx context.Context) (interface{}, error) { return s.Client.ListSubscriptionsContext(ctx) }))
+	s.mux.HandleFunc("/v1/events", s.events)
+	s.mux.HandleFunc("/healthz", s.health)
+	return s
+}
+
+//ServeHTTP authenticates the request and routes it. The health route is served without authentication.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+	want := "Bearer " + s.AuthToken
+	if len(s.AuthToken) == 0 || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+	if id := r.Header.Get("X-Request-Id"); len(id) > 0 {
+		r = r.WithContext(pushbullet.ContextWithRequestID(r.Context(), id))
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+//Publish forwards a change to every connected event stream. Slow subscribers miss events rather than block the caller.
+func (s *Server) Publish(e pushbullet.ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (s *Server) pushes(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		modifiedAfter, _ := strconv.ParseFloat(r.URL.Query().Get("modified_after"), 64)
+		pushes, err := s.Client.GetPushHistoryContext(r.Context(), modifiedAfter)
+		respond(w, pushbullet.PushList{Pushes: pushes}, err)
+	case "POST":
+		var p pushbullet.PushRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPushBody)).Decode(&p); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		created, err := s.Client.SendPushContext(r.Context(), p)
+		respond(w, created, err)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) list(get func(ctx context.Context) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		v, err := get(r.Context())
+		respond(w, v, err)
+	}
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+	// subscribe before sending the headers, so a client which has received them will not miss a published event
+	ch := make(chan pushbullet.ChangeEvent, 64)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Resource, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func respond(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		var rl *pushbullet.RateLimitError
+		if errors.As(err, &rl) {
+			// round up, so callers honouring the header do not come back before the budget resets
+			w.Header().Set("Retry-After", strconv.Itoa(int((rl.RetryAfter()+time.Second-1)/time.Second)))
+		}
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+//errorStatus is the status reporting err: 400 for an invalid request, 429 when the shared rate budget is spent, the
+//status of a client error from Pushbullet, and 502 for upstream and network failures. A 401 or 403 from Pushbullet
+//concerns the server's own token, not the caller's, so it is reported as 502.
+func errorStatus(err error) int {
+	var (
+		invalid  *pushbullet.ValidationError
+		invalids pushbullet.ValidationErrors
+		limited  *pushbullet.RateLimitError
+		status   *pushbullet.StatusError
+	)
+	switch {
+	case errors.As(err, &invalid), errors.As(err, &invalids):
+		return http.StatusBadRequest
+	case errors.As(err, &limited):
+		return http.StatusTooManyRequests
+	case errors.As(err, &status) && status.StatusCode >= 400 && status.StatusCode < 500 &&
+		status.StatusCode != http.StatusUnauthorized && status.StatusCode != http.StatusForbidden:
+		return status.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]map[string]string{"error": {"message": message}})
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/checkpoint"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func newTestServer() (*pushbullettest.Server, *httptest.Server, *Server) {
+	upstream := pushbullettest.NewServer()
+	upstream.AddDevice(pushbullet.Device{ID: "d1", Nickname: "Phone"})
+	// the push history echoes the modified_after it received as the push title
+	upstream.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method != "GET" || r.URL.Path != "/pushes" {
+			return false
+		}
+		fmt.Fprintf(w, `{"pushes": [{"iden": "p1", "title": %q}]}`, r.URL.Query().Get("modified_after"))
+		return true
+	})
+	srv := New(upstream.Client(), "letmein")
+	return upstream, httptest.NewServer(srv), srv
+}
+
+func request(method, url, token, body string) (*http.Response, error) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return http.DefaultClient.Do(req)
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	upstream, ts, _ := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+
+	res, err := request("GET", ts.URL+"/v1/devices", "wrong", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Error("Expected 401, got:", res.Status)
+	}
+}
+
+func TestServerSendAndList(t *testing.T) {
+	upstream, ts, _ := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+
+	res, err := request("POST", ts.URL+"/v1/pushes", "letmein", `{"type": "note", "title": "hi"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	if res.StatusCode != 200 || !strings.Contains(string(body), `"iden":"push1"`) {
+		t.Error("Unexpected send response:", res.Status, string(body))
+	}
+
+	res, err = request("GET", ts.URL+"/v1/devices", "letmein", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(res.Body)
+	if !strings.Contains(string(body), `"nickname":"Phone"`) {
+		t.Error("Unexpected device list:", string(body))
+	}
+}
+
+func TestServerErrorStatus(t *testing.T) {
+	upstream := pushbullettest.NewServer()
+	upstream.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		switch r.URL.Path {
+		case "/devices":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "Not found"}}`)
+		case "/chats":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "Invalid access token"}}`)
+		case "/subscriptions":
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "Too many requests"}}`)
+		case "/pushes":
+			if r.Method == "POST" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprintln(w, `{"error": {"type": "invalid_request", "message": "Forbidden"}}`)
+				return true
+			}
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return true
+	})
+	defer upstream.Close()
+	ts := httptest.NewServer(New(upstream.Client(), "letmein"))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/v1/pushes", `{"type": "link"}`, http.StatusBadRequest},
+		{"POST", "/v1/pushes", `{"type": "note", "body": "` + strings.Repeat("x", maxPushBody) + `"}`, http.StatusBadRequest},
+		{"POST", "/v1/pushes", `{"type": "note", "body": "hi"}`, http.StatusBadGateway},
+		{"GET", "/v1/devices", "", http.StatusNotFound},
+		{"GET", "/v1/chats", "", http.StatusBadGateway},
+		{"GET", "/v1/subscriptions", "", http.StatusTooManyRequests},
+		{"GET", "/v1/pushes", "", http.StatusBadGateway},
+	} {
+		res, err := request(tc.method, ts.URL+tc.path, "letmein", tc.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tc.want {
+			t.Errorf("%s %s = %d, want %d", tc.method, tc.path, res.StatusCode, tc.want)
+		}
+		if tc.want == http.StatusTooManyRequests && res.Header.Get("Retry-After") != "30" {
+			t.Error("Expected Retry-After to be passed on, got:", res.Header.Get("Retry-After"))
+		}
+	}
+}
+
+func TestServerEvents(t *testing.T) {
+	upstream, ts, srv := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+
+	res, err := request("GET", ts.URL+"/v1/events", "letmein", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// the handler subscribes before flushing its headers, so the stream is registered once the response arrives
+	srv.Publish(pushbullet.ChangeEvent{Type: pushbullet.Added, Resource: pushbullet.ResourcePushes, Push: &pushbullet.PushMessage{ID: "p1"}})
+	r := bufio.NewReader(res.Body)
+	line, _ := r.ReadString('\n')
+	if line != "event: pushes\n" {
+		t.Fatal("Unexpected event line:", line)
+	}
+	line, _ = r.ReadString('\n')
+	if !strings.Contains(line, `"Type":"added"`) || !strings.Contains(line, `"iden":"p1"`) {
+		t.Error("Unexpected event data:", line)
+	}
+}
+
+func TestServerPushHistoryPrecision(t *testing.T) {
+	upstream, ts, _ := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+
+	res, err := request("GET", ts.URL+"/v1/pushes?modified_after=1412047948.579031", "letmein", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	// the upstream mock echoes the modified_after it received as the push title
+	if !strings.Contains(string(body), `"title":"1412047948.579031"`) {
+		t.Error("modified_after not passed through at full precision:", string(body))
+	}
+}
+
+func TestServerAnnotatesRequestID(t *testing.T) {
+	upstream, ts, srv := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+	var ids []string
+	srv.Client.OnRequest = func(e pushbullet.RequestEvent) {
+		ids = append(ids, e.Annotations[pushbullet.AnnotationRequestID])
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/v1/devices", nil)
+	req.Header.Set("Authorization", "Bearer letmein")
+	req.Header.Set("X-Request-Id", "req-42")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if len(ids) != 1 || ids[0] != "req-42" {
+		t.Errorf("Expected the upstream call tagged with the request ID, got: %q", ids)
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	upstream, ts, srv := newTestServer()
+	defer upstream.Close()
+	defer ts.Close()
+
+	res, err := request("GET", ts.URL+"/healthz", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusNotFound {
+		t.Error("Expected 404 without a Health, got:", res.Status)
+	}
+
+	checkpoints := checkpoint.NewMemory()
+	checkpoints.Save(pushbullet.ResourcePushes, checkpoint.Checkpoint{Modified: float64(time.Now().Add(-10 * time.Second).Unix())})
+	queue := pushbullet.NewQueue(srv.Client)
+	queue.Enqueue(pushbullet.PushMessage{Type: "note", Title: "held"}, pushbullet.PriorityNormal)
+	srv.Health = &Health{Queue: queue, Checkpoints: checkpoints, MaxCheckpointLag: time.Minute}
+	var report HealthReport
+	res, err = request("GET", ts.URL+"/healthz", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(res.Body).Decode(&report)
+	if res.StatusCode != http.StatusOK || report.Status != "ok" {
+		t.Errorf("Expected a healthy report, got %s: %+v", res.Status, report)
+	}
+	if report.Queue == nil || report.Queue.Depth != 1 || report.Queue.Offline {
+		t.Errorf("Unexpected queue health: %+v", report.Queue)
+	}
+	if lag := report.Checkpoints[pushbullet.ResourcePushes].Lag; lag < 9 || lag > 60 {
+		t.Error("Unexpected checkpoint lag:", lag)
+	}
+	if _, ok := report.Checkpoints[pushbullet.ResourceDevices]; ok || report.Stream != nil {
+		t.Errorf("Unexpected entries in the report: %+v", report)
+	}
+
+	// a listener which is not running has no connection
+	srv.Health.Listener = srv.Client.NewListener()
+	srv.Health.MaxCheckpointLag = time.Second
+	report = HealthReport{}
+	res, err = request("GET", ts.URL+"/healthz", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(res.Body).Decode(&report)
+	if res.StatusCode != http.StatusServiceUnavailable || report.Status != "unavailable" || len(report.Problems) != 2 {
+		t.Errorf("Expected an unhealthy report, got %s: %+v", res.Status, report)
+	}
+	if report.Stream == nil || report.Stream.Connected {
+		t.Errorf("Unexpected stream health: %+v", report.Stream)
+	}
+}
@@ -0,0 +1,191 @@
+package setup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kariudo/gopushbullet/credstore"
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//configIterations is the PBKDF2 work factor for keys derived from a Secret
+const configIterations = 100000
+
+//ErrEncrypted is returned by Load for a configuration written by SaveEncrypted.
+var ErrEncrypted = errors.New("setup: the configuration is encrypted")
+
+//ErrWrongSecret is returned when an encrypted configuration cannot be decrypted with the secret given.
+var ErrWrongSecret = errors.New("setup: the configuration cannot be decrypted with this secret")
+
+//Secret returns the secret from which the key encrypting a configuration's token and end-to-end password is derived.
+type Secret func() ([]byte, error)
+
+//Passphrase is a Secret entered by the user.
+func Passphrase(passphrase string) Secret {
+	return func() ([]byte, error) {
+		return []byte(passphrase), nil
+	}
+}
+
+//StoredSecret is a random Secret kept in an OS credential store, e.g. credstore.Default("pb", "config-key"). It is
+//created on first use.
+func StoredSecret(store credstore.Backend) Secret {
+	return func() ([]byte, error) {
+		encoded, err := store.Token()
+		if err == credstore.ErrNotFound || (err == nil && len(encoded) == 0) {
+			secret := make([]byte, 32)
+			if _, err = rand.Read(secret); err != nil {
+				return nil, err
+			}
+			return secret, store.SetToken(base64.StdEncoding.EncodeToString(secret))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+}
+
+//sealed is the encrypted part of a configuration file
+type sealed struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+//configFile is a configuration as written to disk, with its secrets either in Config or sealed
+type configFile struct {
+	Config
+	Sealed *sealed `json:"sealed,omitempty"`
+}
+
+//secrets are the fields of a Config which SaveEncrypted seals
+type secrets struct {
+	Token       string `json:"token,omitempty"`
+	E2EPassword string `json:"e2e_password,omitempty"`
+}
+
+//SaveEncrypted writes the configuration like Save, with the token and end-to-end password encrypted (AES-256-GCM, with
+//the key derived from the secret by PBKDF2-HMAC-SHA256).
+func SaveEncrypted(path string, cfg Config, secret Secret) error {
+	key, err := secret()
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(secrets{Token: cfg.Token, E2EPassword: cfg.E2EPassword})
+	if err != nil {
+		return err
+	}
+	s := &sealed{KDF: "pbkdf2-sha256", Iterations: configIterations, Salt: make([]byte, 16)}
+	if _, err = rand.Read(s.Salt); err != nil {
+		return err
+	}
+	aead, err := newAEAD(key, s)
+	if err != nil {
+		return err
+	}
+	s.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(s.Nonce); err != nil {
+		return err
+	}
+	s.Data = aead.Seal(nil, s.Nonce, plain, nil)
+
+	cfg.Token, cfg.E2EPassword = "", ""
+	data, err := json.MarshalIndent(configFile{Config: cfg, Sealed: s}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data)
+}
+
+//LoadEncrypted reads a configuration written by SaveEncrypted. A configuration written in plaintext by Save is
+//migrated: it is returned as read and rewritten encrypted.
+func LoadEncrypted(path string, secret Secret) (Config, error) {
+	var f configFile
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return f.Config, err
+	}
+	if err = json.Unmarshal(data, &f); err != nil {
+		return f.Config, err
+	}
+	if f.Sealed == nil {
+		if len(f.Token) > 0 || len(f.E2EPassword) > 0 {
+			err = SaveEncrypted(path, f.Config, secret)
+		}
+		return f.Config, err
+	}
+	key, err := secret()
+	if err != nil {
+		return f.Config, err
+	}
+	if f.Sealed.KDF != "pbkdf2-sha256" || f.Sealed.Iterations <= 0 {
+		return f.Config, errors.New("setup: unsupported key derivation " + f.Sealed.KDF)
+	}
+	aead, err := newAEAD(key, f.Sealed)
+	if err != nil {
+		return f.Config, err
+	}
+	if len(f.Sealed.Nonce) != aead.NonceSize() {
+		return f.Config, ErrWrongSecret
+	}
+	plain, err := aead.Open(nil, f.Sealed.Nonce, f.Sealed.Data, nil)
+	if err != nil {
+		return f.Config, ErrWrongSecret
+	}
+	var s secrets
+	if err = json.Unmarshal(plain, &s); err != nil {
+		return f.Config, err
+	}
+	f.Token, f.E2EPassword = s.Token, s.E2EPassword
+	return f.Config, nil
+}
+
+//newAEAD returns the cipher for the key derived from the secret with the parameters in s
+func newAEAD(secret []byte, s *sealed) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2(secret, s.Salt, s.Iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//pbkdf2 derives a key of keyLen bytes from the secret with PBKDF2-HMAC-SHA256 (RFC 8018)
+func pbkdf2(secret, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, secret)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], block)
+		prf.Write(index[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
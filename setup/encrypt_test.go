@@ -0,0 +1,113 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914 section 11
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got := hex.EncodeToString(pbkdf2([]byte("passwd"), []byte("salt"), 1, 64)); got != want {
+		t.Errorf("Derived %s, want %s", got, want)
+	}
+}
+
+func TestSaveEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pb", "config.json")
+
+	cfg := Config{Token: "o.secret", DefaultDevice: "d1", E2EPassword: "hunter2"}
+	if err := SaveEncrypted(path, cfg, Passphrase("correct horse")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if bytes.Contains(data, []byte("o.secret")) || bytes.Contains(data, []byte("hunter2")) || !bytes.Contains(data, []byte("d1")) {
+		t.Errorf("Expected only the secrets encrypted:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Error("Expected the configuration to be private:", info.Mode(), err)
+	}
+	if loaded, err := LoadEncrypted(path, Passphrase("correct horse")); err != nil || loaded != cfg {
+		t.Error("Unexpected decrypted configuration:", loaded, err)
+	}
+	if _, err := LoadEncrypted(path, Passphrase("battery staple")); err != ErrWrongSecret {
+		t.Error("Expected the wrong passphrase refused:", err)
+	}
+	if loaded, err := Load(path); err != ErrEncrypted || len(loaded.Token) > 0 {
+		t.Error("Expected Load to refuse an encrypted configuration:", loaded, err)
+	}
+}
+
+func TestLoadEncryptedMigrates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+
+	store := &memoryStore{}
+	secret := StoredSecret(store)
+	cfg := Config{Token: "o.secret", HostDevice: "d2"}
+	if err := Save(path, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := LoadEncrypted(path, secret); err != nil || loaded != cfg {
+		t.Error("Unexpected migrated configuration:", loaded, err)
+	}
+	if data, _ := ioutil.ReadFile(path); bytes.Contains(data, []byte("o.secret")) {
+		t.Errorf("Expected the plaintext configuration rewritten encrypted:\n%s", data)
+	}
+	if len(store.token) == 0 {
+		t.Error("Expected a secret created in the store")
+	}
+	if loaded, err := LoadEncrypted(path, StoredSecret(store)); err != nil || loaded != cfg {
+		t.Error("Unexpected configuration with the stored secret:", loaded, err)
+	}
+}
+
+func TestWizardEncrypted(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	server.AddDevice(pushbullet.Device{ID: "d1", Nickname: "Phone", KeyFingerprint: "fp"})
+	dir, err := ioutil.TempDir("", "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+
+	var out bytes.Buffer
+	w := &Wizard{
+		In:           strings.NewReader(pushbullettest.APIKey + "\nhunter2\nn\nn\n"),
+		Out:          &out,
+		ConfigPath:   path,
+		Secret:       Passphrase("correct horse"),
+		HostNickname: "nas",
+		Options:      []pushbullet.Option{pushbullet.WithAPI(server.URL, "")},
+	}
+	if _, err := w.Run(context.Background()); err != nil {
+		t.Fatal(err, out.String())
+	}
+	cfg, err := LoadEncrypted(path, w.Secret)
+	if err != nil || cfg.Token != pushbullettest.APIKey || cfg.E2EPassword != "hunter2" {
+		t.Error("Unexpected encrypted configuration:", cfg, err)
+	}
+	if strings.Contains(out.String(), "unencrypted") {
+		t.Error("Unexpected plaintext warning:", out.String())
+	}
+}
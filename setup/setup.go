@@ -0,0 +1,254 @@
+//Package setup walks a user through first-run configuration of a command line tool: it validates the access token,
+//lets the user pick a default device, optionally registers the computer as a device, sends a test push, and writes
+//the configuration.
+//
+//	w := &setup.Wizard{In: os.Stdin, Out: os.Stdout, ConfigPath: path, Store: credstore.Default("pb", "default")}
+//	cfg, err := w.Run(ctx)
+//
+//Where no credential store holds the token, the configuration file is encrypted with a Secret instead, either a
+//passphrase or a random key kept by the OS; LoadEncrypted reads it back, migrating a plaintext file:
+//
+//	w.Secret = setup.StoredSecret(credstore.Default("pb", "config-key"))
+//	cfg, err := setup.LoadEncrypted(path, w.Secret)
+package setup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/credstore"
+	"github.com/kariudo/gopushbullet/internal/atomicfile"
+)
+
+//Config is what the wizard writes.
+type Config struct {
+	// Token is the access token, left empty when it is kept in a credential store.
+	Token string `json:"token,omitempty"`
+	// DefaultDevice is the iden of the device pushes go to by default, empty for all devices.
+	DefaultDevice string `json:"default_device,omitempty"`
+	// HostDevice is the iden of the device registered for this computer, if any.
+	HostDevice string `json:"host_device,omitempty"`
+	// E2EPassword is the end-to-end encryption password shared by the user's devices, if any.
+	E2EPassword string `json:"e2e_password,omitempty"`
+}
+
+//Load reads a configuration written by Save, returning ErrEncrypted for one written by SaveEncrypted.
+func Load(path string) (Config, error) {
+	var f configFile
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return f.Config, err
+	}
+	if err = json.Unmarshal(data, &f); err == nil && f.Sealed != nil {
+		err = ErrEncrypted
+	}
+	return f.Config, err
+}
+
+//Save writes the configuration readable by the user only, replacing the file atomically. The token and end-to-end
+//password are written in plaintext; SaveEncrypted encrypts them.
+func Save(path string, cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return atomicfile.Write(path, data)
+}
+
+//Wizard asks its questions on Out and reads the answers from In, one per line.
+type Wizard struct {
+	In         io.Reader
+	Out        io.Writer
+	ConfigPath string
+	// Store keeps the token instead of the configuration file.
+	Store credstore.Backend
+	// Secret encrypts the configuration file, which then keeps the token when there is no Store.
+	Secret Secret
+	// Plaintext writes the token to the configuration file unencrypted when there is neither a Store nor a Secret,
+	// which Run refuses otherwise.
+	Plaintext bool
+	// HostNickname is offered as the nickname of this computer's device, the host name when empty.
+	HostNickname string
+	// Options configure the clients the wizard makes, e.g. pushbullet.WithAPI for a test server.
+	Options []pushbullet.Option
+	// Attempts limits how often the token is asked for, three times when zero.
+	Attempts int
+
+	lines *bufio.Scanner
+}
+
+//ErrNoInput is returned when the input ends before the wizard is done.
+var ErrNoInput = errors.New("setup: input ended before setup was complete")
+
+//ErrNoStore is returned by a wizard with neither a Store nor a Secret for the token, nor Plaintext set.
+var ErrNoStore = errors.New("setup: no credential store or secret for the token, and plaintext storage not allowed")
+
+//Run asks the questions, writes the configuration, and returns it.
+func (w *Wizard) Run(ctx context.Context) (Config, error) {
+	w.lines = bufio.NewScanner(w.In)
+	var cfg Config
+	if w.Store == nil && w.Secret == nil && !w.Plaintext {
+		return cfg, ErrNoStore
+	}
+
+	c, caps, err := w.token(ctx)
+	if err != nil {
+		return cfg, err
+	}
+	w.printf("Signed in as %s.\n", caps.User.Email)
+	for _, problem := range caps.Problems {
+		w.printf("Warning: %s\n", problem)
+	}
+
+	if len(caps.Pushable) > 0 {
+		w.printf("\nDevices:\n  0) all devices\n")
+		for i, d := range caps.Pushable {
+			w.printf("  %d) %s\n", i+1, d)
+		}
+		for {
+			answer, err := w.ask("Default device [0]: ")
+			if err != nil {
+				return cfg, err
+			}
+			n, err := strconv.Atoi(answer)
+			if len(answer) == 0 {
+				n, err = 0, nil
+			}
+			if err == nil && n >= 0 && n <= len(caps.Pushable) {
+				if n > 0 {
+					cfg.DefaultDevice = caps.Pushable[n-1].ID
+				}
+				break
+			}
+			w.printf("Please enter a number from 0 to %d.\n", len(caps.Pushable))
+		}
+	}
+
+	// the password is only kept where the token could be
+	if len(caps.E2E) > 0 && (w.Secret != nil || w.Plaintext) {
+		w.printf("\nEnd-to-end encryption is enabled on %d of your devices.\n", len(caps.E2E))
+		if cfg.E2EPassword, err = w.ask("Encryption password (blank to skip): "); err != nil {
+			return cfg, err
+		}
+	}
+
+	nickname := w.HostNickname
+	if len(nickname) == 0 {
+		nickname, _ = os.Hostname()
+	}
+	if len(nickname) > 0 {
+		register, err := w.confirm(fmt.Sprintf("Register this computer as %q to receive pushes? [y/N]: ", nickname), false)
+		if err != nil {
+			return cfg, err
+		}
+		if register {
+			d, err := c.CreateDevice(ctx, pushbullet.Device{Nickname: nickname, Icon: "desktop"})
+			if err != nil {
+				return cfg, err
+			}
+			cfg.HostDevice = d.ID
+			w.printf("Registered %s.\n", d)
+		}
+	}
+
+	test, err := w.confirm("Send a test push now? [Y/n]: ", true)
+	if err != nil {
+		return cfg, err
+	}
+	if test {
+		_, err := c.SendPushContext(ctx, pushbullet.PushRequest{Type: "note", DeviceID: cfg.DefaultDevice,
+			Title: "Pushbullet is set up", Body: "This push was sent by the setup wizard."})
+		if err != nil {
+			w.printf("The test push failed: %s\n", c.Describe(err))
+		} else {
+			w.printf("Test push sent. If it does not arrive, check that the device is signed in to %s.\n", caps.User.Email)
+		}
+	}
+
+	if w.Store != nil {
+		if err := w.Store.SetToken(c.APIKey); err != nil {
+			return cfg, err
+		}
+	} else {
+		cfg.Token = c.APIKey
+	}
+	if w.Secret != nil {
+		err = SaveEncrypted(w.ConfigPath, cfg, w.Secret)
+	} else {
+		err = Save(w.ConfigPath, cfg)
+	}
+	if err != nil {
+		return cfg, err
+	}
+	w.printf("Configuration written to %s.\n", w.ConfigPath)
+	if w.Secret == nil && (len(cfg.Token) > 0 || len(cfg.E2EPassword) > 0) {
+		w.printf("Warning: secrets are stored unencrypted in it.\n")
+	}
+	return cfg, nil
+}
+
+//token asks for the access token until a valid one is given or the attempts run out
+func (w *Wizard) token(ctx context.Context) (*pushbullet.Client, pushbullet.Capabilities, error) {
+	attempts := w.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	for i := 0; ; i++ {
+		token, err := w.ask("Access token (from https://www.pushbullet.com/#settings/account): ")
+		if err != nil {
+			return nil, pushbullet.Capabilities{}, err
+		}
+		if len(token) > 0 {
+			c := pushbullet.ClientWithKey(token, w.Options...)
+			caps, err := c.Capabilities(ctx)
+			if err != nil {
+				return nil, caps, err
+			}
+			if caps.TokenValid {
+				return c, caps, nil
+			}
+			for _, problem := range caps.Problems {
+				w.printf("%s\n", problem)
+			}
+		}
+		if i+1 >= attempts {
+			return nil, pushbullet.Capabilities{}, errors.New("setup: no valid access token given")
+		}
+	}
+}
+
+func (w *Wizard) ask(prompt string) (string, error) {
+	w.printf("%s", prompt)
+	if !w.lines.Scan() {
+		if err := w.lines.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrNoInput
+	}
+	return strings.TrimSpace(w.lines.Text()), nil
+}
+
+func (w *Wizard) confirm(prompt string, byDefault bool) (bool, error) {
+	answer, err := w.ask(prompt)
+	if err != nil || len(answer) == 0 {
+		return byDefault, err
+	}
+	return strings.HasPrefix(strings.ToLower(answer), "y"), nil
+}
+
+func (w *Wizard) printf(format string, args ...interface{}) {
+	fmt.Fprintf(w.Out, format, args...)
+}
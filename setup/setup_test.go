@@ -0,0 +1,110 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+type memoryStore struct{ token string }
+
+func (m *memoryStore) Token() (string, error)      { return m.token, nil }
+func (m *memoryStore) SetToken(token string) error { m.token = token; return nil }
+func (m *memoryStore) DeleteToken() error          { m.token = ""; return nil }
+
+func TestWizard(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	server.SetUser(pushbullet.User{ID: "u1", Email: "elon@teslamotors.com", Active: true})
+	server.AddDevice(pushbullet.Device{ID: "d1", Nickname: "Phone", Pushable: true})
+	server.AddDevice(pushbullet.Device{ID: "d2", Nickname: "Laptop", Pushable: true})
+	dir, err := ioutil.TempDir("", "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pb", "config.json")
+
+	var out bytes.Buffer
+	store := &memoryStore{}
+	w := &Wizard{
+		In:           strings.NewReader("o.bad\n" + pushbullettest.APIKey + "\n7\n2\ny\n\n"),
+		Out:          &out,
+		ConfigPath:   path,
+		Store:        store,
+		HostNickname: "nas",
+		Options:      []pushbullet.Option{pushbullet.WithAPI(server.URL, "")},
+	}
+	cfg, err := w.Run(context.Background())
+	if err != nil {
+		t.Fatal(err, out.String())
+	}
+	var host string
+	if devices := server.Devices(); len(devices) == 3 && devices[2].Nickname == "nas" {
+		host = devices[2].ID
+	}
+	if cfg != (Config{DefaultDevice: "d2", HostDevice: host}) || len(host) == 0 || store.token != pushbullettest.APIKey {
+		t.Errorf("Unexpected configuration: %+v, stored token %q", cfg, store.token)
+	}
+	if loaded, err := Load(path); err != nil || loaded != cfg {
+		t.Error("Unexpected saved configuration:", loaded, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Error("Expected the configuration to be private:", info.Mode(), err)
+	}
+	if pushes := server.Pushes(); len(pushes) != 1 || pushes[0].DeviceID != "d2" {
+		t.Errorf("Expected a test push to the default device: %+v", pushes)
+	}
+	for _, want := range []string{pushbullet.ErrorCatalog[pushbullet.KindBadToken].Message, "Signed in as elon@teslamotors.com",
+		"2) device 'Laptop'", "Please enter a number from 0 to 2", "Test push sent"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the output:\n%s", want, out.String())
+		}
+	}
+
+	w = &Wizard{In: strings.NewReader(pushbullettest.APIKey + "\n"), Out: &out, ConfigPath: path, Store: store,
+		Options: []pushbullet.Option{pushbullet.WithAPI(server.URL, "")}}
+	if _, err := w.Run(context.Background()); err != ErrNoInput {
+		t.Error("Expected the wizard to stop when the input ends:", err)
+	}
+}
+
+func TestWizardPlaintext(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "setup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+
+	var out bytes.Buffer
+	w := &Wizard{In: strings.NewReader(pushbullettest.APIKey + "\nn\n"), Out: &out, ConfigPath: path, HostNickname: "nas",
+		Options: []pushbullet.Option{pushbullet.WithAPI(server.URL, "")}}
+	if _, err := w.Run(context.Background()); err != ErrNoStore || out.Len() > 0 {
+		t.Errorf("Expected the wizard to refuse to run without a store: %v\n%s", err, out.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected no configuration written:", err)
+	}
+
+	w.In = strings.NewReader(pushbullettest.APIKey + "\nn\nn\n")
+	w.Plaintext = true
+	if _, err := w.Run(context.Background()); err != nil {
+		t.Fatal(err, out.String())
+	}
+	if cfg, err := Load(path); err != nil || cfg.Token != pushbullettest.APIKey {
+		t.Error("Expected the token in the configuration:", cfg, err)
+	}
+	if !strings.Contains(out.String(), "stored unencrypted") {
+		t.Error("Expected a warning about the plaintext token:", out.String())
+	}
+}
@@ -0,0 +1,32 @@
+package pushbullet
+
+import "context"
+
+//PushSink receives pushes delivered to the account, e.g. forwarding them to another service.
+type PushSink interface {
+	HandlePush(ctx context.Context, p PushMessage) error
+}
+
+//PushSinkFunc adapts a function into a PushSink.
+type PushSinkFunc func(ctx context.Context, p PushMessage) error
+
+//HandlePush calls f(ctx, p).
+func (f PushSinkFunc) HandlePush(ctx context.Context, p PushMessage) error {
+	return f(ctx, p)
+}
+
+//SinkHandler returns a Sync handler which passes each newly received push to each of the sinks in turn. The
+//existing history loaded by an initial sync and the user's own outgoing pushes are not passed on.
+//Sink failures are reported to onError, when set, and do not stop delivery to the remaining sinks.
+func SinkHandler(ctx context.Context, onError func(PushSink, PushMessage, error), sinks ...PushSink) func(ChangeEvent) {
+	return func(e ChangeEvent) {
+		if e.Resource != ResourcePushes || e.Type != Added || e.Initial || e.Push.Direction == "outgoing" {
+			return
+		}
+		for _, s := range sinks {
+			if err := s.HandlePush(ctx, *e.Push); err != nil && onError != nil {
+				onError(s, *e.Push, err)
+			}
+		}
+	}
+}
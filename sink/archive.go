@@ -0,0 +1,159 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//ObjectStore stores objects in a bucket, such as S3.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte, contentType string) error
+}
+
+//Archiver keeps received pushes in an object store for retention beyond Pushbullet's history, as newline
+//delimited JSON partitioned by the UTC day each push was created:
+//
+//	<Prefix>/date=2024-05-01/<time>-<id>.ndjson
+//
+//Pushes are held until BatchSize have arrived or Flush is called; Run flushes them on an interval. With Files set
+//the files of file pushes are copied too, to <Prefix>/files/date=2024-05-01/<iden>/<file name>. They are fetched
+//under the same rules as a Download's, and held in memory while they are stored.
+type Archiver struct {
+	Store     ObjectStore
+	Prefix    string
+	BatchSize int // pushes held before they are written, defaults to 100
+	Files     bool
+	// Match, when set, must accept the push for it to be archived.
+	Match        func(pushbullet.PushMessage) bool
+	HTTPClient   *http.Client // used to download files
+	MaxSize      int64        // largest file copied in bytes, DefaultMaxDownloadSize when zero
+	AllowedHosts []string     // further hosts files are downloaded from over https
+	// CheckURL, when set, replaces the scheme and host check, returning an error for URLs not to be fetched.
+	CheckURL func(*url.URL) error
+
+	mu      sync.Mutex
+	pending []pushbullet.PushMessage
+}
+
+//HandlePush holds the push for the next batch, copying its file first when Files is set.
+func (a *Archiver) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if a.Match != nil && !a.Match(p) {
+		return nil
+	}
+	if a.Files && p.Type == "file" && len(p.FileURL) > 0 {
+		if err := a.archiveFile(ctx, p); err != nil {
+			return err
+		}
+	}
+	a.mu.Lock()
+	a.pending = append(a.pending, p)
+	full := len(a.pending) >= a.batchSize()
+	a.mu.Unlock()
+	if full {
+		return a.Flush(ctx)
+	}
+	return nil
+}
+
+//Flush writes the held pushes, one object per day. Pushes which could not be written are held for the next flush.
+func (a *Archiver) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	pending := a.pending
+	a.pending = nil
+	a.mu.Unlock()
+
+	var days []string
+	byDay := make(map[string][]pushbullet.PushMessage)
+	for _, p := range pending {
+		day := partition(p)
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], p)
+	}
+	var failed []pushbullet.PushMessage
+	var lastErr error
+	for _, day := range days {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		for _, p := range byDay[day] {
+			enc.Encode(p)
+		}
+		key := path.Join(a.Prefix, day, fmt.Sprintf("%d-%s.ndjson", time.Now().UnixNano(), randomID()))
+		if err := a.Store.Put(ctx, key, b.Bytes(), "application/x-ndjson"); err != nil {
+			failed = append(failed, byDay[day]...)
+			lastErr = err
+		}
+	}
+	if len(failed) > 0 {
+		a.mu.Lock()
+		a.pending = append(failed, a.pending...)
+		a.mu.Unlock()
+	}
+	return lastErr
+}
+
+//Run flushes the held pushes on every interval until the context is done, then flushes once more.
+func (a *Archiver) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid archive interval")
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// the final flush gets its own context as ctx is already done
+			a.Flush(context.Background())
+			return ctx.Err()
+		case <-t.C:
+			a.Flush(ctx)
+		}
+	}
+}
+
+func (a *Archiver) archiveFile(ctx context.Context, p pushbullet.PushMessage) error {
+	d := &Download{MaxSize: a.MaxSize, AllowedHosts: a.AllowedHosts, CheckURL: a.CheckURL, HTTPClient: a.HTTPClient}
+	res, err := d.open(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, d.maxSize()+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(body)) > d.maxSize() {
+		return errTooLarge
+	}
+	key := path.Join(a.Prefix, "files", partition(p), safeFileName(p.ID), safeFileName(p.FileName))
+	return a.Store.Put(ctx, key, body, p.FileType)
+}
+
+func (a *Archiver) batchSize() int {
+	if a.BatchSize <= 0 {
+		return 100
+	}
+	return a.BatchSize
+}
+
+//partition names the day the push was created, or today for a push without a creation time
+func partition(p pushbullet.PushMessage) string {
+	created := time.Now()
+	if p.Created > 0 {
+		created = time.Unix(0, int64(p.Created*1e9))
+	}
+	return "date=" + created.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,63 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Desktop shows each received push as a native desktop notification: notify-send (D-Bus) on Linux and other
+//Unix systems, Notification Center on macOS, and a toast on Windows. Clicking the notification of a link push
+//opens the link where the platform supports it (notify-send 0.7.9+, terminal-notifier on macOS, Windows toasts).
+type Desktop struct {
+	AppName string // shown as the notification source, "Pushbullet" when empty
+}
+
+//HandlePush shows the notification. On Linux it returns once the notification is shown and waits for a click in
+//the background.
+func (d *Desktop) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	app := d.AppName
+	if len(app) == 0 {
+		app = "Pushbullet"
+	}
+	title, body, link := notification(p)
+	return showNotification(ctx, app, title, body, link)
+}
+
+//notification composes the text of the notification for a push and the link it opens, if any
+func notification(p pushbullet.PushMessage) (title, body, link string) {
+	title, body = p.Title, p.Body
+	switch p.Type {
+	case "link":
+		link = p.URL
+		if len(title) == 0 {
+			title = p.URL
+		} else if len(body) == 0 {
+			body = p.URL
+		}
+	case "file":
+		link = p.FileURL
+		if len(title) == 0 {
+			title = p.FileName
+		}
+	}
+	if len(title) == 0 {
+		title = p.SenderName
+	}
+	if len(title) == 0 {
+		title = "Pushbullet"
+	}
+	return title, body, link
+}
+
+//runCommand executes a notification helper and returns its trimmed stdout
+var runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &out
+	err := cmd.Run()
+	return strings.TrimSpace(out.String()), err
+}
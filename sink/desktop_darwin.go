@@ -0,0 +1,25 @@
+package sink
+
+import (
+	"context"
+	"os/exec"
+)
+
+//showNotification uses terminal-notifier when installed, which opens links on click, and AppleScript otherwise.
+//The text is passed as arguments rather than spliced into a script.
+func showNotification(ctx context.Context, app, title, body, link string) error {
+	if _, err := exec.LookPath("terminal-notifier"); err == nil {
+		args := []string{"-title", title, "-subtitle", app, "-message", body}
+		if len(link) > 0 {
+			args = append(args, "-open", link)
+		}
+		_, err := runCommand(ctx, "terminal-notifier", args...)
+		return err
+	}
+	_, err := runCommand(ctx, "osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 3 of argv) with title (item 2 of argv) subtitle (item 1 of argv)",
+		"-e", "end run",
+		app, title, body)
+	return err
+}
@@ -0,0 +1,39 @@
+//go:build !darwin && !windows
+//+build !darwin,!windows
+
+package sink
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+//showNotification uses notify-send. For links it waits for the notification's action in the background and opens
+//the link with xdg-open when it is clicked. The text comes from the sender, so it follows "--" to stop it being read
+//as options, and only http and https links are opened.
+func showNotification(ctx context.Context, app, title, body, link string) error {
+	args := []string{"--app-name=" + app, "--", title, body}
+	if !openable(link) {
+		_, err := runCommand(ctx, "notify-send", args...)
+		return err
+	}
+	go func() {
+		action, err := runCommand(context.Background(), "notify-send", append([]string{"--wait", "--action=open=Open"}, args...)...)
+		if err == nil && action == "open" {
+			// xdg-open rejects "--" as an unknown option; an http(s) link cannot start with "-"
+			runCommand(context.Background(), "xdg-open", link)
+		}
+	}()
+	return nil
+}
+
+//openable reports whether the link is an http or https URL, safe to hand to xdg-open
+func openable(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && len(u.Host) > 0
+}
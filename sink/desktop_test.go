@@ -0,0 +1,70 @@
+package sink
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestNotificationText(t *testing.T) {
+	for _, tc := range []struct {
+		push              pushbullet.PushMessage
+		title, body, link string
+	}{
+		{pushbullet.PushMessage{Type: "note", Title: "Hi", Body: "there"}, "Hi", "there", ""},
+		{pushbullet.PushMessage{Type: "link", URL: "https://example.com"}, "https://example.com", "", "https://example.com"},
+		{pushbullet.PushMessage{Type: "link", Title: "Docs", URL: "https://example.com"}, "Docs", "https://example.com", "https://example.com"},
+		{pushbullet.PushMessage{Type: "file", FileName: "a.png", FileURL: "https://dl/a.png"}, "a.png", "", "https://dl/a.png"},
+		{pushbullet.PushMessage{Type: "note", Body: "hey", SenderName: "Bob"}, "Bob", "hey", ""},
+	} {
+		title, body, link := notification(tc.push)
+		if title != tc.title || body != tc.body || link != tc.link {
+			t.Errorf("Unexpected notification for %+v: %q %q %q", tc.push, title, body, link)
+		}
+	}
+}
+
+func TestDesktopNotifySend(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("notify-send is used on other platforms")
+	}
+	orig := runCommand
+	defer func() { runCommand = orig }()
+	calls := make(chan string, 3)
+	runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+		calls <- name + " " + strings.Join(args, " ")
+		if name == "notify-send" && args[0] == "--wait" {
+			return "open", nil
+		}
+		return "", nil
+	}
+
+	d := &Desktop{}
+	if err := d.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", Title: "Hi", Body: "there"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-calls; got != "notify-send --app-name=Pushbullet -- Hi there" {
+		t.Error("Unexpected command:", got)
+	}
+
+	d.HandlePush(context.Background(), pushbullet.PushMessage{Type: "link", Title: "Docs", URL: "https://example.com"})
+	if got := <-calls; !strings.HasPrefix(got, "notify-send --wait --action=open=Open") {
+		t.Error("Unexpected command:", got)
+	}
+	if got := <-calls; got != "xdg-open https://example.com" {
+		t.Error("Clicked link not opened:", got)
+	}
+
+	// sender controlled text is never parsed as options, and other schemes are not opened
+	d.HandlePush(context.Background(), pushbullet.PushMessage{Type: "link", Title: "-u critical", URL: "--help"})
+	if got := <-calls; got != "notify-send --app-name=Pushbullet -- -u critical --help" {
+		t.Error("Unexpected command:", got)
+	}
+	d.HandlePush(context.Background(), pushbullet.PushMessage{Type: "link", Title: "Run", URL: "file:///usr/bin/xterm"})
+	if got := <-calls; strings.Contains(got, "--wait") {
+		t.Error("Offered to open a non-web link:", got)
+	}
+}
@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+)
+
+//showNotification shows a toast through PowerShell. A link is set as the toast's protocol activation, so clicking
+//opens it in the default browser.
+func showNotification(ctx context.Context, app, title, body, link string) error {
+	launch := ""
+	if len(link) > 0 {
+		launch = ` activationType="protocol" launch="` + xmlEscape(link) + `"`
+	}
+	toast := `<toast` + launch + `><visual><binding template="ToastGeneric"><text>` + xmlEscape(title) +
+		`</text><text>` + xmlEscape(body) + `</text></binding></visual></toast>`
+	script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$x = New-Object Windows.Data.Xml.Dom.XmlDocument
+$x.LoadXml(` + psQuote(toast) + `)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(` + psQuote(app) + `).Show([Windows.UI.Notifications.ToastNotification]::new($x))`
+	_, err := runCommand(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	return err
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+//psQuote quotes a PowerShell string literal, in which only single quotes are special
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,99 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/internal/text"
+)
+
+//Discord relays received pushes to a Discord channel webhook, so a team channel can mirror one person's feed.
+//Notes are posted as messages; link and file pushes as embeds, with image files shown inline.
+type Discord struct {
+	WebhookURL string
+	Username   string // overrides the webhook's name when set
+	// Match, when set, selects the pushes which are relayed.
+	Match      func(pushbullet.PushMessage) bool
+	HTTPClient *http.Client
+}
+
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Content  string         `json:"content,omitempty"`
+	Embeds   []discordEmbed `json:"embeds,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string        `json:"title,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Image       *discordImage `json:"image,omitempty"`
+	Footer      *discordText  `json:"footer,omitempty"`
+	Timestamp   string        `json:"timestamp,omitempty"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+type discordText struct {
+	Text string `json:"text"`
+}
+
+//Discord limits embed titles and message contents
+const (
+	discordTitleLimit   = 256
+	discordContentLimit = 2000
+)
+
+//HandlePush posts the push to the webhook.
+func (d *Discord) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if d.Match != nil && !d.Match(p) {
+		return nil
+	}
+	data, err := json.Marshal(discordPayload(p, d.Username))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", d.WebhookURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := d.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return send(client, req)
+}
+
+//discordPayload formats the push as a message, or an embed for links and files
+func discordPayload(p pushbullet.PushMessage, username string) discordMessage {
+	m := discordMessage{Username: username}
+	title, body, link := notification(p)
+	if p.Type != "link" && p.Type != "file" {
+		content := "**" + title + "**"
+		if len(body) > 0 {
+			content += "\n" + body
+		}
+		m.Content = text.Truncate(content, discordContentLimit)
+		return m
+	}
+	e := discordEmbed{Title: text.Truncate(title, discordTitleLimit), URL: link, Description: p.Body}
+	if p.Type == "file" && strings.HasPrefix(p.FileType, "image/") {
+		e.Image = &discordImage{URL: p.FileURL}
+	}
+	if len(p.SenderName) > 0 {
+		e.Footer = &discordText{Text: p.SenderName}
+	}
+	if p.Created > 0 {
+		e.Timestamp = time.Unix(0, int64(p.Created*1e9)).UTC().Format(time.RFC3339)
+	}
+	m.Embeds = []discordEmbed{e}
+	return m
+}
@@ -0,0 +1,52 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestDiscord(t *testing.T) {
+	var got []discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m discordMessage
+		json.NewDecoder(r.Body).Decode(&m)
+		got = append(got, m)
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+	d := &Discord{WebhookURL: server.URL, Username: "Pushbullet"}
+	ctx := context.Background()
+
+	for _, p := range []pushbullet.PushMessage{
+		{Type: "note", Title: "Reminder", Body: "standup"},
+		{Type: "link", Title: "Docs", URL: "https://example.com", SenderName: "Bob", Created: 1430000000},
+		{Type: "file", FileName: "cat.jpg", FileType: "image/jpeg", FileURL: "https://dl/cat.jpg"},
+	} {
+		if err := d.HandlePush(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(got) != 3 || got[0].Content != "**Reminder**\nstandup" || got[0].Username != "Pushbullet" {
+		t.Fatalf("Unexpected messages: %+v", got)
+	}
+	link := got[1].Embeds[0]
+	if link.Title != "Docs" || link.URL != "https://example.com" || link.Footer.Text != "Bob" || link.Timestamp != "2015-04-25T22:13:20Z" {
+		t.Errorf("Unexpected link embed: %+v", link)
+	}
+	if file := got[2].Embeds[0]; file.Image == nil || file.Image.URL != "https://dl/cat.jpg" || file.Title != "cat.jpg" {
+		t.Errorf("Unexpected file embed: %+v", file)
+	}
+}
+
+func TestDiscordTruncates(t *testing.T) {
+	m := discordPayload(pushbullet.PushMessage{Type: "note", Title: "t", Body: strings.Repeat("é", 3000)}, "")
+	if n := len([]rune(m.Content)); n != discordContentLimit {
+		t.Error("Content not truncated to the limit:", n)
+	}
+}
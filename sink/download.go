@@ -0,0 +1,197 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Download saves the files of received file pushes into a directory, for dropping files from a phone onto a
+//server. Other pushes are ignored.
+//
+//Anyone able to push to the account chooses the URL fetched, so by default only https URLs on Pushbullet's upload
+//hosts are downloaded, redirects included; AllowedHosts or CheckURL opens up others.
+type Download struct {
+	Dir          string
+	MaxSize      int64    // largest file saved in bytes, DefaultMaxDownloadSize when zero
+	AllowedTypes []string // MIME types saved, such as "application/pdf" or "image/*"; every type when empty
+	AllowedHosts []string // further hosts files are downloaded from over https
+	// CheckURL, when set, replaces the scheme and host check, returning an error for URLs not to be fetched.
+	CheckURL func(*url.URL) error
+	// Match, when set, must accept the push for its file to be saved.
+	Match      func(pushbullet.PushMessage) bool
+	HTTPClient *http.Client
+	// OnSaved, when set, is called with the path of each saved file.
+	OnSaved func(p pushbullet.PushMessage, path string)
+}
+
+//DefaultMaxDownloadSize is the largest file saved when MaxSize is not set, the most Pushbullet accepts for upload
+const DefaultMaxDownloadSize = 1 << 30
+
+//uploadHost is the domain Pushbullet serves uploaded files from, such as dl3.pushbulletusercontent.com
+const uploadHost = "pushbulletusercontent.com"
+
+//errTooLarge is returned for files over MaxSize
+var errTooLarge = errors.New("sink: file exceeds maximum size")
+
+//ErrURLNotAllowed is returned for file URLs the Download is not allowed to fetch.
+var ErrURLNotAllowed = errors.New("sink: file URL not allowed")
+
+//HandlePush downloads the pushed file. An existing file is never replaced; a numbered name is chosen instead.
+func (d *Download) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if p.Type != "file" || len(p.FileURL) == 0 || !d.allowed(p.FileType) || (d.Match != nil && !d.Match(p)) {
+		return nil
+	}
+	res, err := d.open(ctx, p)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	maxSize := d.maxSize()
+	f, err := createUnique(d.Dir, safeFileName(p.FileName))
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.LimitReader(res.Body, maxSize+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && n > maxSize {
+		err = errTooLarge
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if d.OnSaved != nil {
+		d.OnSaved(p, f.Name())
+	}
+	return nil
+}
+
+//open requests the pushed file once its URL has been checked, failing for an unsuccessful status or a length over
+//the maximum size
+func (d *Download) open(ctx context.Context, p pushbullet.PushMessage) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.FileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.check(req.URL); err != nil {
+		return nil, err
+	}
+	res, err := d.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		res.Body.Close()
+		return nil, fmt.Errorf("sink: downloading %s: status code %d", p.FileName, res.StatusCode)
+	}
+	if res.ContentLength > d.maxSize() {
+		res.Body.Close()
+		return nil, errTooLarge
+	}
+	return res, nil
+}
+
+//maxSize returns the largest file saved in bytes
+func (d *Download) maxSize() int64 {
+	if d.MaxSize <= 0 {
+		return DefaultMaxDownloadSize
+	}
+	return d.MaxSize
+}
+
+//check returns ErrURLNotAllowed unless the URL may be fetched
+func (d *Download) check(u *url.URL) error {
+	if d.CheckURL != nil {
+		return d.CheckURL(u)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w: %s", ErrURLNotAllowed, u.Redacted())
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == uploadHost || strings.HasSuffix(host, "."+uploadHost) {
+		return nil
+	}
+	for _, allowed := range d.AllowedHosts {
+		if strings.EqualFold(host, allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrURLNotAllowed, u.Redacted())
+}
+
+//client returns the HTTP client to download with, checking the URL of every redirect it follows
+func (d *Download) client() *http.Client {
+	client := http.Client{}
+	if d.HTTPClient != nil {
+		client = *d.HTTPClient
+	}
+	redirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := d.check(req.URL); err != nil {
+			return err
+		}
+		if redirect != nil {
+			return redirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("sink: stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
+
+//allowed reports whether files of the MIME type are saved
+func (d *Download) allowed(fileType string) bool {
+	if len(d.AllowedTypes) == 0 {
+		return true
+	}
+	fileType = strings.ToLower(strings.TrimSpace(strings.Split(fileType, ";")[0]))
+	for _, t := range d.AllowedTypes {
+		t = strings.ToLower(t)
+		if t == fileType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(fileType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+//safeFileName reduces the sender's file name to a plain name, so it cannot point outside the directory
+func safeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" || name == ".." || len(strings.Trim(name, ".")) == 0 {
+		return "download"
+	}
+	return name
+}
+
+//createUnique creates name in dir, or "name (n).ext" for the first n which does not exist yet
+func createUnique(dir, name string) (*os.File, error) {
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 0; n < 1000; n++ {
+		candidate := name
+		if n > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, fmt.Errorf("sink: no free file name for %s", name)
+}
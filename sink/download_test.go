@@ -0,0 +1,100 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file contents"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	var saved []string
+	d := &Download{Dir: dir, AllowedTypes: []string{"image/*"}, CheckURL: allowAll, OnSaved: func(p pushbullet.PushMessage, path string) {
+		saved = append(saved, filepath.Base(path))
+	}}
+	ctx := context.Background()
+	push := pushbullet.PushMessage{Type: "file", FileName: "../../photo.png", FileType: "image/png", FileURL: server.URL + "/photo.png"}
+
+	for i := 0; i < 2; i++ {
+		if err := d.HandlePush(ctx, push); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.HandlePush(ctx, pushbullet.PushMessage{Type: "file", FileName: "a.pdf", FileType: "application/pdf", FileURL: server.URL})
+	d.HandlePush(ctx, pushbullet.PushMessage{Type: "note", Body: "not a file"})
+	if len(saved) != 2 || saved[0] != "photo.png" || saved[1] != "photo (1).png" {
+		t.Fatal("Unexpected saved files:", saved)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "photo.png"))
+	if string(data) != "file contents" {
+		t.Error("Unexpected contents:", string(data))
+	}
+}
+
+func TestDownloadMaxSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no Content-Length, so the limit applies while copying
+		w.(http.Flusher).Flush()
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	d := &Download{Dir: dir, MaxSize: 5, CheckURL: allowAll}
+
+	err := d.HandlePush(context.Background(), pushbullet.PushMessage{Type: "file", FileName: "big.bin", FileURL: server.URL})
+	if err != errTooLarge {
+		t.Error("Expected errTooLarge, got:", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Error("Partial download left behind")
+	}
+}
+
+func TestDownloadURLCheck(t *testing.T) {
+	var requests int
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("metadata"))
+	}))
+	defer internal.Close()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer server.Close()
+	host, _ := url.Parse(server.URL)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	for _, fileURL := range []string{internal.URL, "file:///etc/passwd", "https://169.254.169.254/latest/meta-data"} {
+		err := (&Download{Dir: dir}).HandlePush(ctx, pushbullet.PushMessage{Type: "file", FileName: "f", FileURL: fileURL})
+		if !errors.Is(err, ErrURLNotAllowed) {
+			t.Error("Expected", fileURL, "to be refused, got:", err)
+		}
+	}
+	// an allowed host cannot redirect somewhere else
+	d := &Download{Dir: dir, AllowedHosts: []string{host.Hostname()}, HTTPClient: server.Client()}
+	if err := d.HandlePush(ctx, pushbullet.PushMessage{Type: "file", FileName: "f", FileURL: server.URL}); !errors.Is(err, ErrURLNotAllowed) {
+		t.Error("Expected the redirect to be refused, got:", err)
+	}
+	if requests != 0 {
+		t.Error("Refused URLs were fetched:", requests)
+	}
+	if err := (&Download{}).check(&url.URL{Scheme: "https", Host: "dl3.pushbulletusercontent.com"}); err != nil {
+		t.Error("Expected Pushbullet's upload host to be allowed:", err)
+	}
+}
+
+//allowAll lets tests download from their plain http servers
+func allowAll(*url.URL) error { return nil }
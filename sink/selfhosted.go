@@ -0,0 +1,188 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//bridgeTag marks messages relayed by this package so bridging both ways does not loop
+const bridgeTag = "pushbullet"
+
+//bridgeGUIDPrefix marks pushes created from ntfy messages
+const bridgeGUIDPrefix = "ntfy:"
+
+//Ntfy publishes received pushes to a topic on an ntfy server. Subscribe relays the other way.
+type Ntfy struct {
+	Server string // e.g. "https://ntfy.sh"
+	Topic  string
+	Token  string // access token, for protected topics
+	// Match, when set, selects the pushes which are relayed.
+	Match      func(pushbullet.PushMessage) bool
+	HTTPClient *http.Client
+}
+
+//HandlePush publishes the push with its title, and links as the click action.
+func (n *Ntfy) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if strings.HasPrefix(p.GUID, bridgeGUIDPrefix) || (n.Match != nil && !n.Match(p)) {
+		return nil
+	}
+	title, body, link := notification(p)
+	req, err := http.NewRequestWithContext(ctx, "POST", n.topicURL(), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Tags", bridgeTag)
+	if len(link) > 0 {
+		req.Header.Set("Click", link)
+	}
+	return n.do(req)
+}
+
+//ntfyMessage is one line of an ntfy JSON subscription stream
+type ntfyMessage struct {
+	Event   string   `json:"event"`
+	Title   string   `json:"title"`
+	Message string   `json:"message"`
+	Click   string   `json:"click"`
+	Tags    []string `json:"tags"`
+}
+
+//Subscribe relays messages published to the topic to Pushbullet through to, until the context is done or the
+//server closes the stream. Messages published by HandlePush are skipped.
+func (n *Ntfy) Subscribe(ctx context.Context, to pushbullet.Notifier) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.topicURL()+"/json", nil)
+	if err != nil {
+		return err
+	}
+	n.authorize(req)
+	res, err := n.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var m ntfyMessage
+		if json.Unmarshal(scanner.Bytes(), &m) != nil || m.Event != "message" || hasTag(m.Tags, bridgeTag) {
+			continue
+		}
+		guid := bridgeGUIDPrefix + randomID()
+		opts := []pushbullet.NotifyOption{func(p *pushbullet.PushMessage) { p.GUID = guid }}
+		if len(m.Click) > 0 {
+			opts = append(opts, pushbullet.LinkURL(m.Click))
+		}
+		if err := to.Notify(ctx, m.Title, m.Message, opts...); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
+
+//topicURL escapes the topic, so a "/", "?" or "#" in it cannot reach another endpoint
+func (n *Ntfy) topicURL() string {
+	return strings.TrimRight(n.Server, "/") + "/" + url.PathEscape(n.Topic)
+}
+
+func (n *Ntfy) authorize(req *http.Request) {
+	if len(n.Token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+}
+
+func (n *Ntfy) client() *http.Client {
+	if n.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return n.HTTPClient
+}
+
+func (n *Ntfy) do(req *http.Request) error {
+	n.authorize(req)
+	return send(n.client(), req)
+}
+
+//Gotify posts received pushes as messages of a Gotify application.
+type Gotify struct {
+	Server   string // e.g. "https://gotify.example.com"
+	AppToken string
+	Priority int // message priority, Gotify's default when zero
+	// Match, when set, selects the pushes which are relayed.
+	Match      func(pushbullet.PushMessage) bool
+	HTTPClient *http.Client
+}
+
+//HandlePush posts the push as a Gotify message, with links as the click action.
+func (g *Gotify) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	if strings.HasPrefix(p.GUID, bridgeGUIDPrefix) || (g.Match != nil && !g.Match(p)) {
+		return nil
+	}
+	title, body, link := notification(p)
+	msg := map[string]interface{}{"title": title, "message": body}
+	if g.Priority != 0 {
+		msg["priority"] = g.Priority
+	}
+	if len(link) > 0 {
+		msg["extras"] = map[string]interface{}{"client::notification": map[string]interface{}{"click": map[string]string{"url": link}}}
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(g.Server, "/")+"/message", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// the token goes in a header to keep it out of URLs and their logs
+	req.Header.Set("X-Gotify-Key", g.AppToken)
+	client := g.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return send(client, req)
+}
+
+func send(client *http.Client, req *http.Request) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	return nil
+}
+
+//randomID returns a unique suffix for the guids of relayed pushes, which the API deduplicates by
+func randomID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
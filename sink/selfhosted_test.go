@@ -0,0 +1,93 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestNtfyPublish(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+	n := &Ntfy{Server: server.URL + "/", Topic: "alerts", Token: "tk"}
+
+	err := n.HandlePush(context.Background(), pushbullet.PushMessage{Type: "link", Title: "Docs", Body: "read me", URL: "https://example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Path != "/alerts" || got.Header.Get("Title") != "Docs" || got.Header.Get("Click") != "https://example.com" ||
+		got.Header.Get("Authorization") != "Bearer tk" || body != "read me" {
+		t.Errorf("Unexpected publish: %s %v %q", got.URL.Path, got.Header, body)
+	}
+
+	got = nil
+	n.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", GUID: "ntfy:abc"})
+	if got != nil {
+		t.Error("Relayed an ntfy message back to ntfy")
+	}
+
+	n.Topic = "a/b?c#d"
+	if err := n.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", Body: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.EscapedPath() != "/a%2Fb%3Fc%23d" || len(got.URL.RawQuery) != 0 {
+		t.Error("Topic not escaped:", got.URL.EscapedPath(), got.URL.RawQuery)
+	}
+}
+
+func TestNtfySubscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/alerts/json" {
+			t.Error("Unexpected path:", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"event": "open"}`)
+		fmt.Fprintln(w, `{"event": "message", "title": "Backup", "message": "done", "click": "https://example.com/log"}`)
+		fmt.Fprintln(w, `{"event": "message", "title": "Echo", "message": "loop", "tags": ["pushbullet"]}`)
+		fmt.Fprintln(w, `{"event": "keepalive"}`)
+	}))
+	defer server.Close()
+	rec := &pushbullet.RecordingNotifier{}
+	n := &Ntfy{Server: server.URL, Topic: "alerts"}
+
+	if err := n.Subscribe(context.Background(), rec); err != nil {
+		t.Fatal(err)
+	}
+	pushes := rec.Pushes()
+	if len(pushes) != 1 || pushes[0].Title != "Backup" || pushes[0].URL != "https://example.com/log" || !strings.HasPrefix(pushes[0].GUID, "ntfy:") {
+		t.Errorf("Unexpected relayed pushes: %+v", pushes)
+	}
+}
+
+func TestGotify(t *testing.T) {
+	var msg map[string]interface{}
+	var key string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("X-Gotify-Key")
+		json.NewDecoder(r.Body).Decode(&msg)
+	}))
+	defer server.Close()
+	g := &Gotify{Server: server.URL, AppToken: "app", Priority: 5, Match: func(p pushbullet.PushMessage) bool { return p.Type == "note" }}
+
+	g.HandlePush(context.Background(), pushbullet.PushMessage{Type: "link", URL: "https://example.com"})
+	if msg != nil {
+		t.Fatal("Unmatched push relayed")
+	}
+	if err := g.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", Title: "Disk", Body: "full"}); err != nil {
+		t.Fatal(err)
+	}
+	if key != "app" || msg["title"] != "Disk" || msg["message"] != "full" || msg["priority"] != float64(5) {
+		t.Errorf("Unexpected message: %v %v", key, msg)
+	}
+}
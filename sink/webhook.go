@@ -0,0 +1,131 @@
+//Package sink provides pushbullet.PushSink implementations which relay received pushes to other services.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Headers set on webhook deliveries
+const (
+	SignatureHeader = "X-Pushbullet-Signature"
+	TimestampHeader = "X-Pushbullet-Timestamp"
+)
+
+//Webhook POSTs each received push as JSON to one or more HTTPS endpoints.
+//
+//When Secret is set every request carries a timestamp header and a signature header of the form
+//"sha256=<hex>", the HMAC-SHA256 of "<timestamp>.<body>" keyed with Secret.
+type Webhook struct {
+	URLs       []string
+	Secret     []byte
+	HTTPClient *http.Client
+	Retries    int           // additional attempts after a failure, defaults to 3; negative disables retries
+	Backoff    time.Duration // delay before the first retry, doubled on each attempt, defaults to one second
+	// DeadLetter receives a log line with the full payload of any delivery which exhausted its retries.
+	DeadLetter *log.Logger
+	// AllowInsecure permits plain http endpoints, intended for local testing only.
+	AllowInsecure bool
+}
+
+//HandlePush delivers the push to every endpoint, returning the last failure if any endpoint could not be reached.
+func (w *Webhook) HandlePush(ctx context.Context, p pushbullet.PushMessage) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for _, u := range w.URLs {
+		if err := w.deliver(ctx, u, body); err != nil {
+			w.deadLetter(u, body, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+//Sign returns the signature header value for a body sent at the given unix timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *Webhook) deliver(ctx context.Context, endpoint string, body []byte) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && !(w.AllowInsecure && u.Scheme == "http") {
+		return errors.New("webhook endpoint must use https: " + endpoint)
+	}
+	retries, backoff := w.Retries, w.Backoff
+	if retries == 0 {
+		retries = 3
+	} else if retries < 0 {
+		retries = 0
+	}
+	if backoff == 0 {
+		backoff = time.Second
+	}
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, endpoint, body)
+		if err == nil || attempt >= retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff << uint(attempt)):
+		}
+	}
+}
+
+func (w *Webhook) post(ctx context.Context, endpoint string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.Secret) > 0 {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, ts)
+		req.Header.Set(SignatureHeader, Sign(w.Secret, ts, body))
+	}
+	client := w.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	return nil
+}
+
+func (w *Webhook) deadLetter(endpoint string, body []byte, err error) {
+	l := w.DeadLetter
+	if l == nil {
+		l = log.New(log.Writer(), "", log.LstdFlags)
+	}
+	l.Printf("webhook delivery to %s failed: %v: %s", endpoint, err, body)
+}
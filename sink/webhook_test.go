@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestWebhookSignsAndRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign([]byte("s3cret"), r.Header.Get(TimestampHeader), body) {
+			t.Error("Signature mismatch")
+		}
+		if attempts == 1 {
+			w.WriteHeader(503)
+		}
+	}))
+	defer server.Close()
+
+	w := &Webhook{URLs: []string{server.URL}, Secret: []byte("s3cret"), HTTPClient: server.Client(), Backoff: time.Millisecond}
+	err := w.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", Title: "hello"})
+	if err != nil {
+		t.Error(err)
+	}
+	if attempts != 2 {
+		t.Error("Expected one retry, got attempts:", attempts)
+	}
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	var dead bytes.Buffer
+	w := &Webhook{URLs: []string{server.URL}, HTTPClient: server.Client(), Retries: 1, Backoff: time.Millisecond, DeadLetter: log.New(&dead, "", 0)}
+	if err := w.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note", Title: "lost"}); err == nil {
+		t.Error("Expected delivery failure")
+	}
+	if !strings.Contains(dead.String(), `"title":"lost"`) {
+		t.Error("Dead letter did not record payload:", dead.String())
+	}
+}
+
+func TestWebhookRequiresHTTPS(t *testing.T) {
+	w := &Webhook{URLs: []string{"http://example.com/hook"}}
+	if err := w.HandlePush(context.Background(), pushbullet.PushMessage{}); err == nil {
+		t.Error("Expected plain http endpoint to be rejected")
+	}
+}
+
+func TestWebhookRetriesDisabled(t *testing.T) {
+	attempts := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	var dead bytes.Buffer
+	w := &Webhook{URLs: []string{server.URL}, HTTPClient: server.Client(), Retries: -1, DeadLetter: log.New(&dead, "", 0)}
+	if err := w.HandlePush(context.Background(), pushbullet.PushMessage{Type: "note"}); err == nil {
+		t.Error("Expected delivery failure")
+	}
+	if attempts != 1 {
+		t.Error("Expected a single attempt, got:", attempts)
+	}
+}
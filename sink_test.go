@@ -0,0 +1,62 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSinkHandler(t *testing.T) {
+	var delivered []string
+	var failures int
+	sink := PushSinkFunc(func(ctx context.Context, p PushMessage) error {
+		delivered = append(delivered, p.ID)
+		if p.ID == "bad" {
+			return errors.New("Unreachable")
+		}
+		return nil
+	})
+	h := SinkHandler(context.Background(), func(s PushSink, p PushMessage, err error) { failures++ }, sink)
+
+	h(ChangeEvent{Type: Added, Resource: ResourcePushes, Initial: true, Push: &PushMessage{ID: "history"}})
+	h(ChangeEvent{Type: Added, Resource: ResourcePushes, Push: &PushMessage{ID: "sent", Direction: "outgoing"}})
+	h(ChangeEvent{Type: Updated, Resource: ResourcePushes, Push: &PushMessage{ID: "dismissed"}})
+	h(ChangeEvent{Type: Added, Resource: ResourcePushes, Push: &PushMessage{ID: "received", Direction: "incoming"}})
+	h(ChangeEvent{Type: Added, Resource: ResourcePushes, Push: &PushMessage{ID: "bad", Direction: "self"}})
+
+	if len(delivered) != 2 || delivered[0] != "received" || delivered[1] != "bad" {
+		t.Error("Unexpected deliveries:", delivered)
+	}
+	if failures != 1 {
+		t.Error("Expected one failure reported, got:", failures)
+	}
+}
+
+func TestSyncMarksInitialEvents(t *testing.T) {
+	mockServer, c := mockRoutes(map[string]string{
+		"/devices":       `{}`,
+		"/chats":         `{}`,
+		"/subscriptions": `{}`,
+		"/pushes":        `{"pushes": [{"iden": "p1", "active": true, "modified": 1430000001}]}`,
+	})
+	defer mockServer.Close()
+
+	var events []ChangeEvent
+	s := NewSync(c, func(e ChangeEvent) { events = append(events, e) })
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || !events[0].Initial {
+		t.Error("History from the first refresh not marked initial:", events)
+	}
+
+	events = nil
+	s = NewSync(c, func(e ChangeEvent) { events = append(events, e) })
+	s.PushesSince = 1430000000
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Initial {
+		t.Error("Pushes after an explicit starting point marked initial:", events)
+	}
+}
@@ -0,0 +1,196 @@
+//Package slack accepts Slack incoming webhook payloads and sends them as pushes, so tools which can only notify a
+//Slack webhook URL can target Pushbullet unchanged.
+//
+//	http.Handle("/slack/", &slack.Handler{Notifier: client, Token: "long-random-token"})
+//
+//Tools are then configured with the webhook URL https://host/slack/long-random-token.
+package slack
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Payload is the subset of a Slack incoming webhook message which is converted.
+type Payload struct {
+	Text        string       `json:"text"`
+	Username    string       `json:"username"`
+	Channel     string       `json:"channel"`
+	Attachments []Attachment `json:"attachments"`
+	Blocks      []Block      `json:"blocks"`
+}
+
+//Attachment is a legacy Slack message attachment.
+type Attachment struct {
+	Fallback  string  `json:"fallback"`
+	Pretext   string  `json:"pretext"`
+	Title     string  `json:"title"`
+	TitleLink string  `json:"title_link"`
+	Text      string  `json:"text"`
+	Fields    []Field `json:"fields"`
+}
+
+//Field is a titled value within an attachment.
+type Field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+//Block is a Block Kit block; header, section, context and divider blocks are converted.
+type Block struct {
+	Type     string      `json:"type"`
+	Text     *BlockText  `json:"text"`
+	Fields   []BlockText `json:"fields"`
+	Elements []BlockText `json:"elements"`
+}
+
+//BlockText is a plain_text or mrkdwn text object.
+type BlockText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+//Handler converts webhook requests into notifications.
+type Handler struct {
+	Notifier pushbullet.Notifier
+	// Token must be the last element of the request path, as Slack webhook URLs carry their secret.
+	Token string
+	// Targets maps the Slack channel named in a payload to where it is pushed; other channels go to every device.
+	Targets map[string]pushbullet.Target
+}
+
+//ServeHTTP accepts a JSON body or a form with a payload field, as Slack does, and answers "ok".
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(h.Token) == 0 || subtle.ConstantTimeCompare([]byte(path.Base(r.URL.Path)), []byte(h.Token)) != 1 {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "invalid_method", http.StatusMethodNotAllowed)
+		return
+	}
+	var p Payload
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		err = json.Unmarshal([]byte(r.PostFormValue("payload")), &p)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&p)
+	}
+	if err != nil {
+		http.Error(w, "invalid_payload", http.StatusBadRequest)
+		return
+	}
+	push := Convert(p)
+	if len(push.Title) == 0 && len(push.Body) == 0 && len(push.URL) == 0 {
+		http.Error(w, "no_text", http.StatusBadRequest)
+		return
+	}
+	opts := []pushbullet.NotifyOption{func(m *pushbullet.PushMessage) {
+		m.Type, m.URL = push.Type, push.URL
+	}}
+	if t, ok := h.Targets[strings.TrimPrefix(p.Channel, "#")]; ok {
+		opts = append(opts, t.NotifyOption())
+	}
+	if err := h.Notifier.Notify(r.Context(), push.Title, push.Body, opts...); err != nil {
+		http.Error(w, "delivery_failed", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok"))
+}
+
+//Convert turns a payload into a note push, or a link push when a single attachment links its title.
+func Convert(p Payload) pushbullet.PushMessage {
+	push := pushbullet.PushMessage{Type: "note"}
+	var lines []string
+	add := func(s string) {
+		if s = strings.TrimSpace(mrkdwn(s)); len(s) > 0 {
+			lines = append(lines, s)
+		}
+	}
+	add(p.Text)
+	for _, b := range p.Blocks {
+		switch b.Type {
+		case "header":
+			if b.Text != nil && len(push.Title) == 0 {
+				push.Title = mrkdwn(b.Text.Text)
+				continue
+			}
+			fallthrough
+		case "section":
+			if b.Text != nil {
+				add(b.Text.Text)
+			}
+			for _, f := range b.Fields {
+				add(f.Text)
+			}
+		case "context":
+			for _, e := range b.Elements {
+				add(e.Text)
+			}
+		}
+	}
+	links := 0
+	for _, a := range p.Attachments {
+		add(a.Pretext)
+		if len(a.Title) > 0 && len(push.Title) == 0 {
+			push.Title = mrkdwn(a.Title)
+		} else {
+			add(a.Title)
+		}
+		if len(a.TitleLink) > 0 {
+			links++
+			push.URL = a.TitleLink
+		}
+		text := a.Text
+		if len(text) == 0 && len(a.Fields) == 0 {
+			text = a.Fallback
+		}
+		add(text)
+		for _, f := range a.Fields {
+			add(f.Title + ": " + f.Value)
+		}
+	}
+	if len(push.Title) == 0 {
+		push.Title = p.Username
+	}
+	push.Body = strings.Join(lines, "\n")
+	if links == 1 {
+		push.Type = "link"
+	} else {
+		push.URL = ""
+	}
+	return push
+}
+
+var mrkdwnLink = regexp.MustCompile(`<([^|>]+)(?:\|([^>]+))?>`)
+
+//mrkdwn converts Slack's link syntax and escapes to plain text
+func mrkdwn(s string) string {
+	s = mrkdwnLink.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mrkdwnLink.FindStringSubmatch(m)
+		target, label := parts[1], parts[2]
+		if strings.HasPrefix(target, "!") || strings.HasPrefix(target, "@") || strings.HasPrefix(target, "#") {
+			// mentions and special commands such as <!here>
+			if len(label) > 0 {
+				return label
+			}
+			if strings.HasPrefix(target, "!") {
+				return "@" + strings.TrimPrefix(target, "!")
+			}
+			return target
+		}
+		target = strings.TrimPrefix(target, "mailto:")
+		if len(label) == 0 || label == target {
+			return target
+		}
+		return label + " (" + target + ")"
+	})
+	return strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+}
@@ -0,0 +1,63 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestConvert(t *testing.T) {
+	p := Convert(Payload{
+		Text: "Deploy of <https://ci.example.com/42|build 42> finished &amp; passed <!here>",
+		Blocks: []Block{
+			{Type: "header", Text: &BlockText{Text: "Deploy"}},
+			{Type: "section", Fields: []BlockText{{Text: "*Env:* prod"}}},
+			{Type: "divider"},
+		},
+	})
+	want := "Deploy of build 42 (https://ci.example.com/42) finished & passed @here\n*Env:* prod"
+	if p.Type != "note" || p.Title != "Deploy" || p.Body != want {
+		t.Errorf("Unexpected conversion: %+v", p)
+	}
+
+	p = Convert(Payload{Username: "grafana", Attachments: []Attachment{{
+		Title: "CPU alert", TitleLink: "https://grafana.example.com/d/1", Text: "CPU > 90%",
+		Fields: []Field{{Title: "host", Value: "db1"}},
+	}}})
+	if p.Type != "link" || p.Title != "CPU alert" || p.URL != "https://grafana.example.com/d/1" || p.Body != "CPU > 90%\nhost: db1" {
+		t.Errorf("Unexpected attachment conversion: %+v", p)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	h := &Handler{Notifier: rec, Token: "s3cret", Targets: map[string]pushbullet.Target{"ops": pushbullet.ChannelTarget("ops-tag")}}
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/slack/s3cret", "application/json", strings.NewReader(`{"text": "hello", "channel": "#ops"}`))
+	if err != nil || res.StatusCode != 200 {
+		t.Fatal("Unexpected response:", res, err)
+	}
+	res, _ = http.PostForm(server.URL+"/slack/s3cret", url.Values{"payload": {`{"text": "form"}`}})
+	if res.StatusCode != 200 {
+		t.Error("Form payload rejected:", res.Status)
+	}
+	res, _ = http.Post(server.URL+"/slack/wrong", "application/json", strings.NewReader(`{"text": "hello"}`))
+	if res.StatusCode != http.StatusForbidden {
+		t.Error("Expected a wrong token to be rejected:", res.Status)
+	}
+	res, _ = http.Post(server.URL+"/slack/s3cret", "application/json", strings.NewReader(`{}`))
+	if res.StatusCode != http.StatusBadRequest {
+		t.Error("Expected an empty message to be rejected:", res.Status)
+	}
+
+	pushes := rec.Pushes()
+	if len(pushes) != 2 || pushes[0].Body != "hello" || pushes[0].ChannelTag != "ops-tag" || pushes[1].Body != "form" {
+		t.Errorf("Unexpected pushes: %+v", pushes)
+	}
+}
@@ -0,0 +1,75 @@
+package pushbullet
+
+import (
+	"context"
+	"sync"
+)
+
+//AccountSnapshot describes the state of an account at a point in time.
+type AccountSnapshot struct {
+	User          User           `json:"user"`
+	Devices       []Device       `json:"devices"`
+	Chats         []Chat         `json:"chats"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	Pushes        []PushMessage  `json:"pushes"` // the most recent page of push history
+}
+
+//Snapshot fetches the user, devices, chats, subscriptions, and recent pushes concurrently. Like the list methods,
+//only active records are included. The first failure cancels the remaining requests and is returned.
+func (c *Client) Snapshot(ctx context.Context) (snap AccountSnapshot, err error) {
+	var (
+		devices       DeviceList
+		chats         ChatList
+		subscriptions SubscriptionList
+		pushes        PushList
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	calls := []struct {
+		call string
+		v    interface{}
+	}{
+		{routeUser, &snap.User},
+		{listCall(routeDevices, nil, nil), &devices},
+		{listCall(routeChats, nil, nil), &chats},
+		{listCall(routeSubscriptions, nil, nil), &subscriptions},
+		{listCall(routePushes, nil, nil), &pushes},
+	}
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+	)
+	for _, call := range calls {
+		wg.Add(1)
+		go func(call string, v interface{}) {
+			defer wg.Done()
+			if callErr := c.getContext(ctx, call, v); callErr != nil {
+				once.Do(func() {
+					err = callErr
+					cancel()
+				})
+			}
+		}(call.call, call.v)
+	}
+	wg.Wait()
+	if err != nil {
+		return snap, err
+	}
+
+	snap.Devices = devices.Devices
+	snap.Chats = chats.Chats
+	snap.Subscriptions = subscriptions.Subscriptions
+	snap.Pushes = pushes.Pushes
+	return snap, nil
+}
+
+//getContext performs a GET request and decodes the response into v
+func (c *Client) getContext(ctx context.Context, call string, v interface{}) error {
+	responseBody, apiError, err := c.makeCallContext(ctx, "GET", call, nil)
+	if err != nil {
+		c.warn("Failed to get "+call+":", err, apiError.String())
+		return err
+	}
+	return c.decode(responseBody, v)
+}
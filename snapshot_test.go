@@ -0,0 +1,64 @@
+package pushbullet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	mockServer, c := mockRoutes(map[string]string{
+		"/users/me":      `{"iden": "ujpah72o0", "email": "elon@tesla.com", "name": "Elon Musk"}`,
+		"/devices":       `{"devices": [{"iden": "ujpah72o0sjAoRtnM0jc", "nickname": "Elon Musk's iPhone", "active": true}]}`,
+		"/chats":         `{"chats": [{"iden": "ujlxm0aiT6e", "with": {"type": "user", "email": "carmack@idsoftware.com"}}]}`,
+		"/subscriptions": `{"subscriptions": [{"iden": "ujpah72o0sjAoRtnM0jc", "channel": {"tag": "elonmusknews"}}]}`,
+		"/pushes":        `{"pushes": [{"iden": "ujpah72o0sjAoRtnM0jc", "type": "note", "title": "Space Travel Ideas"}]}`,
+	})
+	defer mockServer.Close()
+
+	snap, err := c.Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.User.Email != "elon@tesla.com" || len(snap.Devices) != 1 || len(snap.Chats) != 1 ||
+		len(snap.Subscriptions) != 1 || len(snap.Pushes) != 1 {
+		t.Errorf("Snapshot incomplete: %+v", snap)
+	}
+	if snap.Pushes[0].ID != "ujpah72o0sjAoRtnM0jc" {
+		t.Error("Push iden not decoded:", snap.Pushes[0].ID)
+	}
+}
+
+func TestSnapshotFailure(t *testing.T) {
+	mockServer, c := mockRoutes(map[string]string{
+		"/users/me": `{"iden": "ujpah72o0"}`,
+	})
+	defer mockServer.Close()
+
+	if _, err := c.Snapshot(context.Background()); err == nil {
+		t.Error("Expected snapshot to fail when an endpoint errors")
+	}
+}
+
+func TestSnapshotActiveOnly(t *testing.T) {
+	var mu sync.Mutex
+	queries := make(map[string]string)
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		queries[r.URL.Path] = r.URL.RawQuery
+		mu.Unlock()
+		fmt.Fprintln(w, `{}`)
+	})
+	defer server.Close()
+
+	if _, err := c.Snapshot(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/devices", "/chats", "/subscriptions", "/pushes"} {
+		if queries[path] != "active=true" {
+			t.Errorf("%s not filtered to active records: %q", path, queries[path])
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package pushbullet
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//latencyWindow is the number of recent requests per endpoint the latency statistics cover
+const latencyWindow = 256
+
+//EndpointStats describes the recent requests to one endpoint.
+type EndpointStats struct {
+	Count  int // requests in the window
+	Errors int // failed requests in the window
+	P50    time.Duration
+	P90    time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+//latencyRing keeps the outcomes of an endpoint's most recent requests
+type latencyRing struct {
+	samples []time.Duration
+	failed  []bool
+	next    int
+}
+
+//latencyStats holds the rolling statistics of every endpoint
+type latencyStats struct {
+	mu        sync.Mutex
+	endpoints map[string]*latencyRing
+}
+
+func (s *latencyStats) record(endpoint string, d time.Duration, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.endpoints == nil {
+		s.endpoints = make(map[string]*latencyRing)
+	}
+	r, ok := s.endpoints[endpoint]
+	if !ok {
+		r = &latencyRing{}
+		s.endpoints[endpoint] = r
+	}
+	if len(r.samples) < latencyWindow {
+		r.samples = append(r.samples, d)
+		r.failed = append(r.failed, failed)
+		return
+	}
+	r.samples[r.next], r.failed[r.next] = d, failed
+	r.next = (r.next + 1) % latencyWindow
+}
+
+//Stats returns latency percentiles and error counts over the most recent requests to each endpoint, keyed by
+//method and resource such as "POST pushes", so applications can notice a degraded API and switch to a fallback.
+func (c *Client) Stats() map[string]EndpointStats {
+	c.latency.mu.Lock()
+	defer c.latency.mu.Unlock()
+	stats := make(map[string]EndpointStats, len(c.latency.endpoints))
+	for endpoint, r := range c.latency.endpoints {
+		sorted := append([]time.Duration(nil), r.samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		s := EndpointStats{
+			Count: len(sorted),
+			P50:   percentile(sorted, 50),
+			P90:   percentile(sorted, 90),
+			P99:   percentile(sorted, 99),
+			Max:   sorted[len(sorted)-1],
+		}
+		for _, failed := range r.failed {
+			if failed {
+				s.Errors++
+			}
+		}
+		stats[endpoint] = s
+	}
+	return stats
+}
+
+//percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+//endpointName reduces a call to its method and resource, dropping idens and the query
+func endpointName(method, call string) string {
+	call = strings.SplitN(call, "?", 2)[0]
+	return method + " " + strings.SplitN(call, "/", 2)[0]
+}
@@ -0,0 +1,41 @@
+package pushbullet
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientStats(t *testing.T) {
+	// pushes are not routed, so deleting them fails
+	server, c := mockRoutes(map[string]string{"/devices": "{}"})
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		c.GetDevices()
+	}
+	c.DeletePush("p1")
+	c.DeletePush("p2")
+	stats := c.Stats()
+	if s := stats["GET devices"]; s.Count != 3 || s.Errors != 0 || s.P50 <= 0 || s.P99 < s.P50 || s.Max < s.P99 {
+		t.Errorf("Unexpected device stats: %+v", s)
+	}
+	if s := stats["DELETE pushes"]; s.Count != 2 || s.Errors != 2 {
+		t.Errorf("Unexpected delete stats: %+v", s)
+	}
+}
+
+func TestLatencyWindow(t *testing.T) {
+	var s latencyStats
+	for i := 1; i <= latencyWindow+100; i++ {
+		s.record("GET pushes", time.Duration(i)*time.Millisecond, false)
+	}
+	c := &Client{}
+	c.latency.endpoints = s.endpoints
+	got := c.Stats()["GET pushes"]
+	if got.Count != latencyWindow || got.Max != time.Duration(latencyWindow+100)*time.Millisecond || got.P50 != 228*time.Millisecond {
+		t.Errorf("Unexpected rolling stats: %+v", got)
+	}
+	if endpointName("GET", "pushes?active=true") != "GET pushes" || endpointName("POST", "pushes/abc") != "POST pushes" {
+		t.Error("Unexpected endpoint names")
+	}
+}
@@ -0,0 +1,288 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DefaultStreamURL is the realtime event stream, to which the API key is appended.
+const DefaultStreamURL = "wss://stream.pushbullet.com/websocket/"
+
+//Stream event types
+const (
+	StreamNop    = "nop"    // keepalive, sent every 30 seconds
+	StreamTickle = "tickle" // a resource changed, named by Subtype; fetch what changed with a Sync or the history
+	StreamPush   = "push"   // an ephemeral, such as a mirrored notification, in Push
+	// StreamReconnected is delivered by Listen after a lost connection is re-established. Changes made while
+	// disconnected were not streamed, so it should be treated like a tickle of every resource.
+	StreamReconnected = "reconnected"
+)
+
+//DefaultStreamTimeout is how long the stream may stay silent before the connection is presumed dead and
+//re-established, unless set with ListenTimeout. The server sends a nop every 30 seconds.
+const DefaultStreamTimeout = 90 * time.Second
+
+//defaultStreamBackoff spaces out reconnections to the stream unless set with ListenBackoff
+var defaultStreamBackoff Backoff = ExponentialBackoff{Base: time.Second, Max: time.Minute}
+
+//ListenOption configures Listen.
+type ListenOption func(*listenConfig)
+
+type listenConfig struct {
+	timeout  time.Duration
+	ping     time.Duration
+	compress bool
+	backoff  Backoff
+}
+
+//ListenTimeout sets how long the stream may stay silent, pongs included, before the connection is presumed dead and
+//re-established, DefaultStreamTimeout by default.
+func ListenTimeout(d time.Duration) ListenOption {
+	return func(c *listenConfig) { c.timeout = d }
+}
+
+//ListenPingInterval pings the server this often. With a ListenTimeout shorter than the 30 seconds between the
+//server's nops, a dead connection, such as a NAT mapping dropped on a mobile link, is noticed sooner. No pings are
+//sent by default.
+func ListenPingInterval(d time.Duration) ListenOption {
+	return func(c *listenConfig) { c.ping = d }
+}
+
+//ListenCompression offers permessage-deflate, compressing the events the server sends when it accepts.
+func ListenCompression() ListenOption {
+	return func(c *listenConfig) { c.compress = true }
+}
+
+//ListenBackoff spaces out reconnections, exponentially from a second up to a minute by default.
+func ListenBackoff(b Backoff) ListenOption {
+	return func(c *listenConfig) { c.backoff = b }
+}
+
+func newListenConfig(opts []ListenOption) listenConfig {
+	cfg := listenConfig{timeout: DefaultStreamTimeout, backoff: defaultStreamBackoff}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+//StreamEvent is a message received on the realtime event stream.
+type StreamEvent struct {
+	Type    string          `json:"type"`
+	Subtype string          `json:"subtype,omitempty"` // the changed resource of a tickle: "push" or "device"
+	Push    json.RawMessage `json:"push,omitempty"`    // the ephemeral of a push event, e.g. a MirrorNotification
+}
+
+//Mirror decodes the ephemeral of a push event as a mirrored notification.
+func (e StreamEvent) Mirror() (MirrorNotification, error) {
+	var n MirrorNotification
+	if e.Type != StreamPush || len(e.Push) == 0 {
+		return n, errors.New("Not a push event")
+	}
+	err := json.Unmarshal(e.Push, &n)
+	return n, err
+}
+
+//WithStreamURL sets the realtime event stream URL, to which the API key is appended, e.g. for a test server.
+func WithStreamURL(streamURL string) Option {
+	return func(c *Client) {
+		c.StreamURL = streamURL
+	}
+}
+
+//Listen connects to the realtime event stream and calls handler with every event until the context is done, so
+//apps can react to new pushes without polling. It runs a Listener; use one directly to pause the delivery of events.
+func (c *Client) Listen(ctx context.Context, handler func(StreamEvent), opts ...ListenOption) error {
+	return c.NewListener(opts...).Run(ctx, handler)
+}
+
+//Listener follows the realtime event stream. Its events can be paused without closing the connection, for apps
+//which temporarily must not process anything, such as during an interactive maintenance window.
+type Listener struct {
+	Client *Client
+	cfg    listenConfig
+	queue  *streamQueue
+
+	mu     sync.Mutex
+	status ListenerStatus
+}
+
+//ListenerStatus is the state of a Listener's connection, e.g. for a health check.
+type ListenerStatus struct {
+	Connected bool
+	Since     time.Time // when the connection was last established or lost
+	LastEvent time.Time // when the last event, nops included, was received; zero before the first
+}
+
+//NewListener returns a Listener for the client's stream.
+func (c *Client) NewListener(opts ...ListenOption) *Listener {
+	return &Listener{Client: c, cfg: newListenConfig(opts), queue: newStreamQueue()}
+}
+
+//Pause holds the events received from now on until Resume, keeping the connection open. Past a backlog of 1000
+//events those held are replaced by a StreamReconnected event, as after a lost connection.
+func (l *Listener) Pause() {
+	l.queue.pause(true)
+}
+
+//Resume delivers the events held since Pause, then new events as they arrive.
+func (l *Listener) Resume() {
+	l.queue.pause(false)
+}
+
+//Status returns the state of the connection.
+func (l *Listener) Status() ListenerStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.status
+}
+
+func (l *Listener) setConnected(connected bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.status.Connected = connected
+	l.status.Since = time.Now()
+}
+
+//received records the arrival of an event and queues it for delivery
+func (l *Listener) received(e StreamEvent) {
+	if e.Type != StreamReconnected {
+		l.mu.Lock()
+		l.status.LastEvent = time.Now()
+		l.mu.Unlock()
+	}
+	l.queue.push(e)
+}
+
+//Run calls handler with every event until the context is done. Events are delivered in order from a single
+//goroutine; a slow handler delays the ones after it, which are held as while paused.
+//
+//Lost connections, including ones which stay silent for longer than the ListenTimeout, are re-established with a
+//backoff, after which a StreamReconnected event is delivered. Device tickles invalidate the client's device cache
+//before they are delivered. Run returns the context's error once it is done, or the error of a connection refused
+//for a bad API key, after delivering the events received before it unless paused.
+func (l *Listener) Run(ctx context.Context, handler func(StreamEvent)) error {
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		l.queue.deliver(ctx, handler, stop)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	c := l.Client
+	var prev time.Duration
+	everConnected := false
+	for retry := 1; ; retry++ {
+		connected, err := l.listenOnce(ctx, everConnected)
+		everConnected = everConnected || connected
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var status *StatusError
+		if errors.As(err, &status) && (status.StatusCode == http.StatusUnauthorized || status.StatusCode == http.StatusForbidden) {
+			return err
+		}
+		if connected {
+			retry, prev = 1, 0
+		}
+		c.warn("Stream connection lost:", err)
+		delay, _ := l.cfg.backoff.Delay(retry, prev)
+		prev = delay
+		if err = sleepContext(ctx, delay); err != nil {
+			return err
+		}
+		c.count(CounterStreamReconnects, 1)
+	}
+}
+
+//listenOnce reads one connection until it fails, reporting whether it was established
+func (l *Listener) listenOnce(ctx context.Context, reconnected bool) (bool, error) {
+	c, cfg, handler := l.Client, l.cfg, l.received
+	ws, err := c.dialStream(ctx, cfg.compress)
+	if err != nil {
+		return false, err
+	}
+	defer ws.Close()
+	l.setConnected(true)
+	defer l.setConnected(false)
+	ws.idle = cfg.timeout
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-stop:
+		}
+	}()
+	if cfg.ping > 0 {
+		go func() {
+			t := time.NewTicker(cfg.ping)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+					ws.writeFrame(opPing, nil) // a failure shows up as a read error
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	if reconnected {
+		handler(StreamEvent{Type: StreamReconnected})
+	}
+	for {
+		message, err := ws.ReadMessage()
+		if ctx.Err() != nil {
+			return true, ctx.Err()
+		}
+		if err == io.EOF {
+			return true, errors.New("Stream closed by the server")
+		}
+		if err != nil {
+			return true, c.redactError(err)
+		}
+		c.debugf("<-- stream %s", message)
+		var e StreamEvent
+		if err = json.Unmarshal(message, &e); err != nil {
+			c.warn("Ignoring malformed stream message:", err)
+			continue
+		}
+		if e.Type == StreamTickle && e.Subtype == "device" {
+			c.InvalidateDevices()
+		}
+		handler(e)
+	}
+}
+
+//dialStream connects to the event stream, offering compression when compress is set
+func (c *Client) dialStream(ctx context.Context, compress bool) (*wsConn, error) {
+	key, err := c.apiKey()
+	if err != nil {
+		return nil, err
+	}
+	if fetchTLS && c.TLSConfig != nil {
+		return nil, ErrTLSUnenforceable
+	}
+	streamURL := c.StreamURL
+	if len(streamURL) == 0 {
+		streamURL = DefaultStreamURL
+	}
+	if !strings.HasSuffix(streamURL, "/") {
+		streamURL += "/"
+	}
+	c.debugf("--> stream %s", streamURL+key)
+	ws, err := dialWebsocket(ctx, streamURL+key, c.TLSConfig, compress)
+	return ws, c.redactError(err)
+}
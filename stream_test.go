@@ -0,0 +1,288 @@
+package pushbullet
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"expvar"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamServer upgrades requests for /websocket/<key> and hands the connection to serve, accepting compression
+// with context takeover when it is offered
+type streamServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	conns int
+}
+
+func newStreamServer(serve func(n int, conn net.Conn, rw *bufio.ReadWriter)) *streamServer {
+	s := &streamServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/websocket/apikey" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + websocketGUID))
+		conn, rw, _ := w.(http.Hijacker).Hijack()
+		defer conn.Close()
+		extensions := ""
+		if r.Header.Get("Sec-WebSocket-Extensions") == deflateExtension {
+			extensions = "Sec-WebSocket-Extensions: " + deflateExtension + "\r\n"
+		}
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" + extensions +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		rw.Flush()
+		s.mu.Lock()
+		s.conns++
+		n := s.conns
+		s.mu.Unlock()
+		serve(n, conn, rw)
+	}))
+	return s
+}
+
+func (s *streamServer) client() *Client {
+	return ClientWithKey("apikey", WithAPI(s.URL, ""), WithStreamURL("ws"+strings.TrimPrefix(s.URL, "http")+"/websocket"))
+}
+
+// serverFrame writes an unmasked frame, as servers send them
+func serverFrame(rw *bufio.ReadWriter, fin bool, opcode byte, payload string) {
+	b := opcode
+	if fin {
+		b |= 0x80
+	}
+	rw.WriteByte(b)
+	if len(payload) < 126 {
+		rw.WriteByte(byte(len(payload)))
+	} else {
+		rw.Write([]byte{126, byte(len(payload) >> 8), byte(len(payload))})
+	}
+	rw.WriteString(payload)
+	rw.Flush()
+}
+
+func TestListen(t *testing.T) {
+	pong := make(chan string, 1)
+	server := newStreamServer(func(n int, conn net.Conn, rw *bufio.ReadWriter) {
+		if n > 1 {
+			serverFrame(rw, true, opText, `{"type": "nop"}`)
+			time.Sleep(time.Second)
+			return
+		}
+		serverFrame(rw, true, opText, `{"type": "nop"}`)
+		serverFrame(rw, true, opPing, "are you there")
+		ws := &wsConn{conn: conn, r: rw.Reader}
+		if _, _, opcode, payload, err := ws.readFrame(); err == nil && opcode == opPong {
+			pong <- string(payload)
+		}
+		serverFrame(rw, false, opText, `{"type": "tickle", `)
+		serverFrame(rw, true, opContinuation, `"subtype": "device"}`)
+		serverFrame(rw, true, opText, `{"type": "push", "push": {"type": "mirror", "title": "Call", "application_name": "Phone"}}`)
+		serverFrame(rw, true, opClose, "")
+	})
+	defer server.Close()
+	c := server.client()
+	c.counters = new(expvar.Map).Init()
+	c.devices.valid = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var events []StreamEvent
+	err := c.Listen(ctx, func(e StreamEvent) {
+		events = append(events, e)
+		if e.Type == StreamReconnected {
+			cancel()
+		}
+	}, ListenBackoff(ConstantBackoff{Interval: 10 * time.Millisecond}))
+	if err != context.Canceled {
+		t.Error("Expected Listen to end with the context, got:", err)
+	}
+
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type+"/"+e.Subtype)
+	}
+	if got := strings.Join(types, " "); got != "nop/ tickle/device push/ reconnected/" {
+		t.Fatalf("events = %q", got)
+	}
+	if n, err := events[2].Mirror(); err != nil || n.Title != "Call" || n.ApplicationName != "Phone" {
+		t.Errorf("Unexpected mirror: %+v %v", n, err)
+	}
+	if c.devices.valid {
+		t.Error("Device tickle did not invalidate the device cache")
+	}
+	if got := <-pong; got != "are you there" {
+		t.Errorf("pong = %q", got)
+	}
+	if v := c.counters.Get(CounterStreamReconnects); v == nil || v.String() != "1" {
+		t.Errorf("reconnects = %v, want 1", v)
+	}
+}
+
+// compressedFrame writes a text message compressed with the connection's deflate writer
+func compressedFrame(rw *bufio.ReadWriter, buf *bytes.Buffer, fw *flate.Writer, payload string) {
+	buf.Reset()
+	fw.Write([]byte(payload))
+	fw.Flush()
+	data := bytes.TrimSuffix(buf.Bytes(), []byte{0x00, 0x00, 0xff, 0xff})
+	rw.Write([]byte{0x80 | 0x40 | opText, byte(len(data))})
+	rw.Write(data)
+	rw.Flush()
+}
+
+func TestListenCompressionAndPings(t *testing.T) {
+	pinged := make(chan bool, 1)
+	server := newStreamServer(func(n int, conn net.Conn, rw *bufio.ReadWriter) {
+		ws := &wsConn{conn: conn, r: rw.Reader}
+		_, _, opcode, _, err := ws.readFrame()
+		pinged <- err == nil && opcode == opPing
+		var buf bytes.Buffer
+		fw, _ := flate.NewWriter(&buf, flate.BestCompression)
+		// the second message repeats the first, so it only compresses to a reference back into the history
+		compressedFrame(rw, &buf, fw, `{"type": "tickle", "subtype": "push"}`)
+		compressedFrame(rw, &buf, fw, `{"type": "tickle", "subtype": "push"}`)
+		time.Sleep(time.Second)
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	server.client().Listen(ctx, func(e StreamEvent) {
+		if got = append(got, e.Type+"/"+e.Subtype); len(got) == 2 {
+			cancel()
+		}
+	}, ListenCompression(), ListenPingInterval(10*time.Millisecond))
+	if strings.Join(got, " ") != "tickle/push tickle/push" {
+		t.Errorf("Unexpected events: %v", got)
+	}
+	if !<-pinged {
+		t.Error("Expected the client to ping")
+	}
+}
+
+func TestListenerPauseResume(t *testing.T) {
+	read := make(chan struct{})
+	server := newStreamServer(func(n int, conn net.Conn, rw *bufio.ReadWriter) {
+		serverFrame(rw, true, opText, `{"type": "tickle", "subtype": "push"}`)
+		// the pong is only sent once the client has read the tickle before the ping
+		serverFrame(rw, true, opPing, "")
+		ws := &wsConn{conn: conn, r: rw.Reader}
+		if _, _, opcode, _, err := ws.readFrame(); err == nil && opcode == opPong {
+			close(read)
+		}
+		time.Sleep(time.Second)
+	})
+	defer server.Close()
+	l := server.client().NewListener()
+	l.Pause()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan StreamEvent, 1)
+	done := make(chan error)
+	go func() { done <- l.Run(ctx, func(e StreamEvent) { events <- e }) }()
+	<-read
+	select {
+	case e := <-events:
+		t.Fatal("Event delivered while paused:", e)
+	default:
+	}
+	if status := l.Status(); !status.Connected || status.LastEvent.IsZero() {
+		t.Errorf("Unexpected status while connected: %+v", status)
+	}
+	l.Resume()
+	if e := <-events; e.Type != StreamTickle {
+		t.Error("Unexpected event after resuming:", e)
+	}
+	cancel()
+	<-done
+	if l.Status().Connected {
+		t.Error("Expected the connection reported lost after Run returned")
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.conns != 1 {
+		t.Errorf("Expected the connection kept while paused, made %d", server.conns)
+	}
+}
+
+func TestListenBadKey(t *testing.T) {
+	server := newStreamServer(func(int, net.Conn, *bufio.ReadWriter) {})
+	defer server.Close()
+	c := server.client()
+	c.APIKey = "wrong"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.Listen(ctx, func(StreamEvent) {})
+	if KindOf(err) != KindBadToken {
+		t.Errorf("Expected a bad token error, got: %v", err)
+	}
+}
+
+func TestDialCancelledDuringHandshake(t *testing.T) {
+	for _, scheme := range []string{"ws", "wss"} {
+		// accepts connections but never answers, holding the dial in its handshake
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					io.Copy(ioutil.Discard, conn)
+					conn.Close()
+				}()
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		_, err = dialWebsocket(ctx, scheme+"://"+l.Addr().String()+"/websocket/apikey", nil, false)
+		if err != context.Canceled {
+			t.Errorf("%s: expected the handshake to be cancelled, got: %v", scheme, err)
+		}
+		l.Close()
+	}
+}
+
+func TestListenSilentConnection(t *testing.T) {
+	server := newStreamServer(func(n int, conn net.Conn, rw *bufio.ReadWriter) {
+		if n == 1 {
+			time.Sleep(time.Second) // silent past the timeout
+			return
+		}
+		serverFrame(rw, true, opText, `{"type": "nop"}`)
+		time.Sleep(time.Second)
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	server.client().Listen(ctx, func(e StreamEvent) {
+		got = append(got, e.Type)
+		if e.Type == StreamNop {
+			cancel()
+		}
+	}, ListenTimeout(50*time.Millisecond), ListenBackoff(ConstantBackoff{Interval: 10 * time.Millisecond}))
+	if strings.Join(got, " ") != "reconnected nop" {
+		t.Errorf("Expected a reconnection after the silence, got: %v", got)
+	}
+}
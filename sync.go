@@ -0,0 +1,383 @@
+package pushbullet
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kariudo/gopushbullet/checkpoint"
+)
+
+//ChangeType describes how a synchronized record changed.
+type ChangeType int
+
+//Kinds of change reported by Sync
+const (
+	Added ChangeType = iota
+	Updated
+	Deleted
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+//MarshalText encodes the change type by name.
+func (t ChangeType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+//Resources kept up to date by Sync
+const (
+	ResourceDevices       = "devices"
+	ResourceChats         = "chats"
+	ResourceSubscriptions = "subscriptions"
+	ResourcePushes        = "pushes"
+)
+
+//ChangeEvent describes a change to one synchronized record. Exactly one of the record fields is set, matching Resource.
+type ChangeEvent struct {
+	Type     ChangeType
+	Resource string
+	// Initial is set for records loaded by the first refresh of a resource with no starting point (no PushesSince
+	// or checkpoint), which reports the existing history rather than new activity.
+	Initial bool
+	// Replayed is set for events re-dispatched from the local collections by Replay.
+	Replayed     bool
+	Device       *Device
+	Chat         *Chat
+	Subscription *Subscription
+	Push         *PushMessage
+}
+
+//Sync maintains up-to-date local collections of devices, chats, subscriptions, and pushes by repeatedly requesting
+//records modified after the newest one it has seen. Refresh should be called whenever the account changes (for
+//example when a stream tickle arrives); Run polls on an interval for callers without a stream.
+type Sync struct {
+	Client *Client
+	// Handler, when set, is called with every change once it has been applied to the local collections.
+	Handler func(ChangeEvent)
+	// PushesSince limits the initial push history fetch to pushes modified after this timestamp.
+	PushesSince float64
+	// Checkpoints, when set, persists the position reached in each resource so a restarted Sync only
+	// reports changes made since it last ran. On resuming, the active devices, chats, and subscriptions are
+	// reloaded without reporting them, and deletions made while stopped are reported even though the deleted
+	// records were never loaded. Pushes() only holds pushes modified since the checkpoint.
+	Checkpoints checkpoint.Store
+
+	refreshMu     sync.Mutex
+	mu            sync.RWMutex
+	cursors       map[string]float64
+	resuming      map[string]bool
+	devices       map[string]Device
+	chats         map[string]Chat
+	subscriptions map[string]Subscription
+	pushes        map[string]PushMessage
+	tracked       map[string]*pushReceipt
+}
+
+//NewSync returns a Sync for the client which reports changes to handler.
+func NewSync(c *Client, handler func(ChangeEvent)) *Sync {
+	return &Sync{Client: c, Handler: handler}
+}
+
+//syncPage decodes a page of any list endpoint
+type syncPage struct {
+	Devices       []Device       `json:"devices"`
+	Chats         []Chat         `json:"chats"`
+	Contacts      []Contact      `json:"contacts"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	Pushes        []PushMessage  `json:"pushes"`
+	Cursor        string         `json:"cursor"`
+}
+
+//Refresh fetches every record modified since the last refresh and applies the changes.
+func (s *Sync) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	if err := s.init(); err != nil {
+		return err
+	}
+
+	for _, resource := range []string{ResourceDevices, ResourceChats, ResourceSubscriptions, ResourcePushes} {
+		if err := s.refreshResource(ctx, resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Run refreshes immediately and then on every interval until the context is done.
+func (s *Sync) Run(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid sync interval")
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			s.Client.warn("Sync refresh failed:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+//Devices returns the active devices.
+func (s *Sync) Devices() []Device {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := make([]Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		l = append(l, d)
+	}
+	return l
+}
+
+//Chats returns the active chats.
+func (s *Sync) Chats() []Chat {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := make([]Chat, 0, len(s.chats))
+	for _, c := range s.chats {
+		l = append(l, c)
+	}
+	return l
+}
+
+//Subscriptions returns the active subscriptions.
+func (s *Sync) Subscriptions() []Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := make([]Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		l = append(l, sub)
+	}
+	return l
+}
+
+//Pushes returns the active pushes.
+func (s *Sync) Pushes() []PushMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l := make([]PushMessage, 0, len(s.pushes))
+	for _, p := range s.pushes {
+		l = append(l, p)
+	}
+	return l
+}
+
+func (s *Sync) init() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cursors != nil {
+		return nil
+	}
+	cursors := map[string]float64{ResourcePushes: s.PushesSince}
+	resuming := make(map[string]bool)
+	if s.Checkpoints != nil {
+		for _, resource := range []string{ResourceDevices, ResourceChats, ResourceSubscriptions, ResourcePushes} {
+			cp, ok, err := s.Checkpoints.Load(resource)
+			if err != nil {
+				return err
+			}
+			if ok {
+				cursors[resource] = cp.Modified
+				resuming[resource] = true
+			}
+		}
+	}
+	s.cursors = cursors
+	s.resuming = resuming
+	s.devices = make(map[string]Device)
+	s.chats = make(map[string]Chat)
+	s.subscriptions = make(map[string]Subscription)
+	s.pushes = make(map[string]PushMessage)
+	return nil
+}
+
+//refreshResource walks every page of records modified after the resource cursor. Pages arrive newest first, so
+//the cursor only advances once the last page has been applied; a failure part way through is retried in full.
+func (s *Sync) refreshResource(ctx context.Context, resource string) error {
+	s.mu.RLock()
+	modifiedAfter, resuming := s.cursors[resource], s.resuming[resource]
+	s.mu.RUnlock()
+	if resuming && resource != ResourcePushes {
+		if err := s.reload(ctx, resource); err != nil {
+			return err
+		}
+	}
+
+	newest := modifiedAfter
+	cursor := ""
+	for {
+		q := url.Values{}
+		q.Set("modified_after", strconv.FormatFloat(modifiedAfter, 'f', -1, 64))
+		if len(cursor) > 0 {
+			q.Set("cursor", cursor)
+		}
+		var p syncPage
+		if err := s.Client.getContext(ctx, query(resource, q), &p); err != nil {
+			return err
+		}
+		events, pageNewest := s.apply(resource, &p, resuming, modifiedAfter)
+		if resource == ResourceDevices && len(events) > 0 {
+			// keep nickname lookups from resolving to deleted or renamed devices
+			s.Client.InvalidateDevices()
+		}
+		for i := range events {
+			events[i].Initial = modifiedAfter == 0 && !resuming
+		}
+		s.dispatch(events)
+		for _, receipt := range s.receipts(p.Pushes) {
+			receipt()
+		}
+		if pageNewest > newest {
+			newest = pageNewest
+		}
+		if len(p.Cursor) == 0 {
+			s.mu.Lock()
+			s.cursors[resource] = newest
+			delete(s.resuming, resource)
+			s.mu.Unlock()
+			return s.checkpoint(resource)
+		}
+		cursor = p.Cursor
+	}
+}
+
+//reload loads every active record of a resource into the local collections without reporting them, restoring the
+//state a resumed Sync had before it stopped
+func (s *Sync) reload(ctx context.Context, resource string) error {
+	cursor := ""
+	for {
+		q := url.Values{}
+		if len(cursor) > 0 {
+			q.Set("cursor", cursor)
+		}
+		var p syncPage
+		if err := s.Client.getContext(ctx, listCall(resource, q, nil), &p); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		for _, d := range p.Devices {
+			s.devices[d.ID] = d
+		}
+		for _, c := range p.Chats {
+			s.chats[c.ID] = c
+		}
+		for _, sub := range p.Subscriptions {
+			s.subscriptions[sub.ID] = sub
+		}
+		s.mu.Unlock()
+		if len(p.Cursor) == 0 {
+			return nil
+		}
+		cursor = p.Cursor
+	}
+}
+
+//checkpoint persists the cursor reached in a resource once all of its pages have been applied
+func (s *Sync) checkpoint(resource string) error {
+	if s.Checkpoints == nil {
+		return nil
+	}
+	s.mu.RLock()
+	modified := s.cursors[resource]
+	s.mu.RUnlock()
+	return s.Checkpoints.Save(resource, checkpoint.Checkpoint{Modified: modified})
+}
+
+//apply merges a page into the local collections, returning the resulting changes and the newest modified time seen.
+//While resuming from a checkpoint the local collections may be incomplete, so changes are classified by the
+//checkpoint time instead: every tombstone is a deletion, and records created since then were added.
+func (s *Sync) apply(resource string, p *syncPage, resuming bool, since float64) (events []ChangeEvent, newest float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	track := func(active bool, created, modified float64, exists bool) (ChangeType, bool) {
+		if modified > newest {
+			newest = modified
+		}
+		switch {
+		case !active:
+			return Deleted, exists || resuming
+		case resuming && created > since:
+			return Added, true
+		case exists || resuming:
+			return Updated, true
+		}
+		return Added, true
+	}
+	for i := range p.Devices {
+		d := p.Devices[i]
+		_, exists := s.devices[d.ID]
+		if t, ok := track(d.Active, d.Created, d.Modified, exists); ok {
+			if t == Deleted {
+				delete(s.devices, d.ID)
+			} else {
+				s.devices[d.ID] = d
+			}
+			events = append(events, ChangeEvent{Type: t, Resource: resource, Device: &d})
+		}
+	}
+	for i := range p.Chats {
+		c := p.Chats[i]
+		_, exists := s.chats[c.ID]
+		if t, ok := track(c.Active, c.Created, c.Modified, exists); ok {
+			if t == Deleted {
+				delete(s.chats, c.ID)
+			} else {
+				s.chats[c.ID] = c
+			}
+			events = append(events, ChangeEvent{Type: t, Resource: resource, Chat: &c})
+		}
+	}
+	for i := range p.Subscriptions {
+		sub := p.Subscriptions[i]
+		_, exists := s.subscriptions[sub.ID]
+		if t, ok := track(sub.Active, sub.Created, sub.Modified, exists); ok {
+			if t == Deleted {
+				delete(s.subscriptions, sub.ID)
+			} else {
+				s.subscriptions[sub.ID] = sub
+			}
+			events = append(events, ChangeEvent{Type: t, Resource: resource, Subscription: &sub})
+		}
+	}
+	for i := range p.Pushes {
+		push := p.Pushes[i]
+		_, exists := s.pushes[push.ID]
+		if t, ok := track(push.Active, push.Created, push.Modified, exists); ok {
+			if t == Deleted {
+				delete(s.pushes, push.ID)
+			} else {
+				s.pushes[push.ID] = push
+			}
+			events = append(events, ChangeEvent{Type: t, Resource: resource, Push: &push})
+		}
+	}
+	return events, newest
+}
+
+func (s *Sync) dispatch(events []ChangeEvent) {
+	if s.Handler == nil {
+		return
+	}
+	for _, e := range events {
+		s.Handler(e)
+	}
+}
@@ -0,0 +1,171 @@
+package pushbullet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kariudo/gopushbullet/checkpoint"
+)
+
+func TestSyncRefresh(t *testing.T) {
+	deviceState := `{"devices": [{"iden": "d1", "nickname": "Phone", "active": true, "modified": 1430000000.5}]}`
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/devices":
+			fmt.Fprintln(w, deviceState)
+		case "/pushes":
+			if r.URL.Query().Get("cursor") == "" {
+				fmt.Fprintln(w, `{"pushes": [{"iden": "p1", "active": true, "modified": 1430000001}], "cursor": "next"}`)
+				return
+			}
+			fmt.Fprintln(w, `{"pushes": [{"iden": "p2", "active": true, "modified": 1430000002}]}`)
+		default:
+			fmt.Fprintln(w, `{}`)
+		}
+	})
+	defer server.Close()
+
+	var events []ChangeEvent
+	s := NewSync(c, func(e ChangeEvent) { events = append(events, e) })
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || len(s.Devices()) != 1 || len(s.Pushes()) != 2 {
+		t.Fatalf("Unexpected initial sync: %d events, %d devices, %d pushes", len(events), len(s.Devices()), len(s.Pushes()))
+	}
+	if s.cursors[ResourcePushes] != 1430000002 || s.cursors[ResourceDevices] != 1430000000.5 {
+		t.Error("Cursors not advanced:", s.cursors)
+	}
+
+	events = nil
+	deviceState = `{"devices": [{"iden": "d1", "active": false, "modified": 1430000010}, {"iden": "d2", "active": false, "modified": 1430000011}]}`
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Devices()) != 0 {
+		t.Error("Deleted device still present")
+	}
+	deleted := 0
+	for _, e := range events {
+		if e.Resource == ResourceDevices && e.Type == Deleted {
+			deleted++
+		}
+	}
+	if deleted != 1 {
+		t.Error("Expected one delete event for the known device, got:", deleted)
+	}
+}
+
+func TestSyncCheckpoints(t *testing.T) {
+	type record struct {
+		ID       string  `json:"iden"`
+		Active   bool    `json:"active"`
+		Created  float64 `json:"created"`
+		Modified float64 `json:"modified"`
+	}
+	account := map[string][]record{
+		"/devices": {{"d1", true, 100, 100}, {"d2", true, 100, 100}},
+		"/pushes":  {{"p1", true, 100, 100}},
+	}
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var page []record
+		for _, rec := range account[r.URL.Path] {
+			if q.Get("active") == "true" && !rec.Active {
+				continue
+			}
+			if since, err := strconv.ParseFloat(q.Get("modified_after"), 64); err == nil && rec.Modified <= since {
+				continue
+			}
+			page = append(page, rec)
+		}
+		json.NewEncoder(w).Encode(map[string][]record{strings.TrimPrefix(r.URL.Path, "/"): page})
+	})
+	defer server.Close()
+	store := checkpoint.NewMemory()
+
+	s := NewSync(c, nil)
+	s.Checkpoints = store
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// while stopped, d1 and p1 are deleted, d2 is renamed, and d3 is added
+	account["/devices"] = []record{{"d1", false, 100, 200}, {"d2", true, 100, 210}, {"d3", true, 220, 220}}
+	account["/pushes"] = []record{{"p1", false, 100, 205}}
+
+	events := make(map[string]ChangeType)
+	s = NewSync(c, func(e ChangeEvent) {
+		switch {
+		case e.Device != nil:
+			events[e.Device.ID] = e.Type
+		case e.Push != nil:
+			events[e.Push.ID] = e.Type
+		}
+	})
+	s.Checkpoints = store
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ChangeType{"d1": Deleted, "d2": Updated, "d3": Added, "p1": Deleted}
+	if !reflect.DeepEqual(events, want) {
+		t.Error("Unexpected events after resuming:", events)
+	}
+	if len(s.Devices()) != 2 {
+		t.Error("Resumed sync did not reload the active devices:", s.Devices())
+	}
+	if s.cursors[ResourceDevices] != 220 || s.cursors[ResourcePushes] != 205 {
+		t.Error("Cursors not advanced after resuming:", s.cursors)
+	}
+}
+
+func TestSyncPageFailureKeepsCursor(t *testing.T) {
+	var modifiedAfter []string
+	failed := false
+	server, c := mockHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pushes" {
+			fmt.Fprintln(w, `{}`)
+			return
+		}
+		if r.URL.Query().Get("cursor") == "" {
+			modifiedAfter = append(modifiedAfter, r.URL.Query().Get("modified_after"))
+			fmt.Fprintln(w, `{"pushes": [{"iden": "p2", "active": true, "modified": 1430000002}], "cursor": "next"}`)
+			return
+		}
+		if !failed {
+			failed = true
+			w.WriteHeader(500)
+			fmt.Fprintln(w, `{"error": {"type": "server", "message": "try again"}}`)
+			return
+		}
+		fmt.Fprintln(w, `{"pushes": [{"iden": "p1", "active": true, "modified": 1430000001}]}`)
+	})
+	defer server.Close()
+
+	s := NewSync(c, nil)
+	if err := s.Refresh(context.Background()); err == nil {
+		t.Fatal("Expected the failing second page to fail the refresh")
+	}
+	if err := s.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the retry starts over instead of skipping the older page
+	if len(modifiedAfter) != 2 || modifiedAfter[1] != "0" {
+		t.Error("Cursor advanced past an unapplied page:", modifiedAfter)
+	}
+	if len(s.Pushes()) != 2 || s.cursors[ResourcePushes] != 1430000002 {
+		t.Error("Unexpected state after retry:", len(s.Pushes()), s.cursors[ResourcePushes])
+	}
+}
+
+func TestSyncRunInvalidInterval(t *testing.T) {
+	if err := NewSync(&Client{}, nil).Run(context.Background(), 0); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
@@ -0,0 +1,33 @@
+package pushbullet
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+//SendTempFile uploads the contents of r as a file named name, pushes it to all of the user's devices, and deletes
+//the push once ttl has passed, returning the created push. The deletion is scheduled in this process and is lost if
+//it exits first; a periodic DeletePushes with an OlderThan filter can collect any missed.
+func (c *Client) SendTempFile(r io.Reader, name string, ttl time.Duration) (PushMessage, error) {
+	return c.SendTempFileContext(context.Background(), r, name, ttl)
+}
+
+//SendTempFileContext is SendTempFile bound to a context which may cancel its requests
+func (c *Client) SendTempFileContext(ctx context.Context, r io.Reader, name string, ttl time.Duration) (PushMessage, error) {
+	auth, err := c.Upload(ctx, r, name, "")
+	if err != nil {
+		c.warn("Failed to upload temporary file:", err)
+		return PushMessage{}, err
+	}
+	created, err := c.sendPush(ctx, PushMessage{Type: "file", FileName: auth.FileName, FileType: auth.FileType, FileURL: auth.FileURL})
+	if err != nil {
+		return created, err
+	}
+	time.AfterFunc(ttl, func() {
+		if err := c.DeletePushContext(context.Background(), created.ID); err != nil {
+			c.warn("Failed to delete expired temporary file push", created.ID+":", err)
+		}
+	})
+	return created, nil
+}
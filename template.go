@@ -0,0 +1,110 @@
+package pushbullet
+
+//Clone returns a deep copy of the push, so that a prototype can be kept and copies derived from it without sharing
+//Items, Extra or Raw.
+//
+//	proto := PushMessage{Type: "note", ChannelTag: "deploys", SourceDeviceID: "ujpah72o0"}
+//	c.SendPush(proto.WithTitle("Deploy done").WithBody(summary).Request())
+func (p PushMessage) Clone() PushMessage {
+	if p.Items != nil {
+		p.Items = append([]string{}, p.Items...)
+	}
+	if p.Extra != nil {
+		extra := make(map[string]interface{}, len(p.Extra))
+		for k, v := range p.Extra {
+			extra[k] = v
+		}
+		p.Extra = extra
+	}
+	if p.Raw != nil {
+		p.Raw = append([]byte{}, p.Raw...)
+	}
+	return p
+}
+
+//WithTitle returns a copy of the push with the title set.
+func (p PushMessage) WithTitle(title string) PushMessage {
+	p = p.Clone()
+	p.Title = title
+	return p
+}
+
+//WithBody returns a copy of the push with the body set.
+func (p PushMessage) WithBody(body string) PushMessage {
+	p = p.Clone()
+	p.Body = body
+	return p
+}
+
+//WithURL returns a copy of the push with the URL set.
+func (p PushMessage) WithURL(url string) PushMessage {
+	p = p.Clone()
+	p.URL = url
+	return p
+}
+
+//WithItems returns a copy of the push with a copy of the checklist items.
+func (p PushMessage) WithItems(items ...string) PushMessage {
+	p = p.Clone()
+	p.Items = append([]string{}, items...)
+	return p
+}
+
+//WithSourceDevice returns a copy of the push sent as coming from the device.
+func (p PushMessage) WithSourceDevice(deviceID string) PushMessage {
+	p = p.Clone()
+	p.SourceDeviceID = deviceID
+	return p
+}
+
+//WithGUID returns a copy of the push with the GUID set.
+func (p PushMessage) WithGUID(guid string) PushMessage {
+	p = p.Clone()
+	p.GUID = guid
+	return p
+}
+
+//WithExtra returns a copy of the push with an additional field to send.
+func (p PushMessage) WithExtra(key string, value interface{}) PushMessage {
+	p = p.Clone()
+	if p.Extra == nil {
+		p.Extra = make(map[string]interface{})
+	}
+	p.Extra[key] = value
+	return p
+}
+
+//WithDevice returns a copy of the push addressed to the device, replacing any other target.
+func (p PushMessage) WithDevice(deviceID string) PushMessage {
+	p = p.withoutTarget()
+	p.DeviceID = deviceID
+	return p
+}
+
+//WithEmail returns a copy of the push addressed to the email address, replacing any other target.
+func (p PushMessage) WithEmail(email string) PushMessage {
+	p = p.withoutTarget()
+	p.Email = email
+	return p
+}
+
+//WithChannel returns a copy of the push sent to the channel's subscribers, replacing any other target.
+func (p PushMessage) WithChannel(tag string) PushMessage {
+	p = p.withoutTarget()
+	p.ChannelTag = tag
+	return p
+}
+
+//WithClient returns a copy of the push addressed to the OAuth client's users, replacing any other target.
+func (p PushMessage) WithClient(clientID string) PushMessage {
+	p = p.withoutTarget()
+	p.ClientID = clientID
+	return p
+}
+
+//withoutTarget returns a copy of the push with no target set
+func (p PushMessage) withoutTarget() PushMessage {
+	p = p.Clone()
+	p.DeviceID, p.Email, p.ChannelTag, p.ClientID = "", "", "", ""
+	return p
+}
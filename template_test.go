@@ -0,0 +1,39 @@
+package pushbullet
+
+import "testing"
+
+func TestCloneDoesNotAlias(t *testing.T) {
+	proto := PushMessage{Type: "checklist", Items: []string{"a", "b"}, Extra: map[string]interface{}{"k": 1}, Raw: []byte(`{}`)}
+	c := proto.Clone()
+	c.Items[0] = "changed"
+	c.Extra["k"] = 2
+	c.Raw[0] = '['
+	if proto.Items[0] != "a" || proto.Extra["k"] != 1 || proto.Raw[0] != '{' {
+		t.Errorf("Clone shares state with the prototype: %+v", proto)
+	}
+
+	// appending to the derived items must not write into spare capacity of the prototype's
+	proto.Items = make([]string, 1, 4)
+	first := proto.WithItems(append(proto.Items, "x")...)
+	second := proto.WithItems(append(proto.Items, "y")...)
+	if first.Items[1] != "x" || second.Items[1] != "y" {
+		t.Error("Derived items alias each other:", first.Items, second.Items)
+	}
+}
+
+func TestWithSetters(t *testing.T) {
+	proto := PushMessage{Type: "note", ChannelTag: "deploys", SourceDeviceID: "d1"}
+	p := proto.WithTitle("Done").WithBody("v1.2").WithExtra("k", "v")
+	if p.Title != "Done" || p.Body != "v1.2" || p.ChannelTag != "deploys" || p.SourceDeviceID != "d1" || p.Extra["k"] != "v" {
+		t.Errorf("Unexpected derived push: %+v", p)
+	}
+	if len(proto.Title) > 0 || proto.Extra != nil {
+		t.Errorf("Prototype changed: %+v", proto)
+	}
+	if p = p.WithDevice("d2"); p.DeviceID != "d2" || len(p.ChannelTag) > 0 {
+		t.Errorf("Expected the device to replace the channel target: %+v", p)
+	}
+	if err := p.WithEmail("ann@example.com").Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,47 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+//maxNoteBody is the largest text SendText sends as a note, beyond which it is easier to read as a file
+const maxNoteBody = 4096
+
+//SendText sends text such as command output or a log to all of the user's devices: as a note titled name when it
+//is short, or uploaded as a .txt file when it is longer than a few kilobytes or not valid UTF-8. An empty name is
+//replaced by one made from the current time, such as "output-20240501-123100".
+func (c *Client) SendText(r io.Reader, name string) (PushMessage, error) {
+	return c.SendTextContext(context.Background(), r, name)
+}
+
+//SendTextContext is SendText bound to a context which may cancel its requests
+func (c *Client) SendTextContext(ctx context.Context, r io.Reader, name string) (PushMessage, error) {
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		return PushMessage{}, err
+	}
+	if len(name) == 0 {
+		name = "output-" + time.Now().Format("20060102-150405")
+	}
+	if len(text) <= maxNoteBody && utf8.Valid(text) {
+		return c.sendPush(ctx, PushMessage{Type: "note", Title: name, Body: string(text)})
+	}
+
+	fileName := name
+	if !strings.EqualFold(filepath.Ext(fileName), ".txt") {
+		fileName += ".txt"
+	}
+	auth, err := c.Upload(ctx, bytes.NewReader(text), fileName, "text/plain")
+	if err != nil {
+		c.warn("Failed to upload text:", err)
+		return PushMessage{}, err
+	}
+	return c.sendPush(ctx, PushMessage{Type: "file", Title: name, FileName: auth.FileName, FileType: auth.FileType, FileURL: auth.FileURL})
+}
@@ -0,0 +1,106 @@
+package pushbullet
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"runtime"
+)
+
+//ErrPinMismatch is returned when a server's certificate chain holds none of the pinned keys.
+var ErrPinMismatch = errors.New("No pinned key in the certificate chain")
+
+//ErrTLSUnenforceable is returned for every request of a client given TLS settings when built for GOOS=js, where
+//requests go through the browser's fetch API, which applies its own TLS policy and cannot pin keys.
+var ErrTLSUnenforceable = errors.New("TLS settings cannot be enforced through the browser's fetch API")
+
+//fetchTLS reports whether requests go through fetch, so TLS settings would be silently ignored
+var fetchTLS = runtime.GOOS == "js"
+
+//unenforceableTLS fails closed rather than sending requests without the TLS settings the client was given
+type unenforceableTLS struct{}
+
+func (unenforceableTLS) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrTLSUnenforceable
+}
+
+//WithTLSConfig sets the TLS configuration for API, upload and stream connections. Options applied after it, such
+//as WithMinTLSVersion, adjust a copy of it.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		c.setTLS(cfg.Clone())
+	}
+}
+
+//WithMinTLSVersion refuses connections negotiating a TLS version below v, such as tls.VersionTLS13.
+func WithMinTLSVersion(v uint16) Option {
+	return func(c *Client) {
+		cfg := c.tlsConfig()
+		cfg.MinVersion = v
+		c.setTLS(cfg)
+	}
+}
+
+//WithPinnedKeys refuses connections unless the server's verified certificate chain holds one of the pinned public
+//keys. Pins are the base64 SHA-256 digests of the certificates' SubjectPublicKeyInfo, as used by HPKP:
+//
+//	openssl x509 -pubkey -noout -in cert.pem | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+//Pinning an intermediate or a backup key avoids an outage when the leaf certificate is renewed. Certificates the
+//server sends that are not part of a verified chain are ignored, so every connection fails when verification is
+//skipped with InsecureSkipVerify.
+func WithPinnedKeys(pins ...string) Option {
+	return func(c *Client) {
+		pinned := make(map[string]bool, len(pins))
+		for _, pin := range pins {
+			pinned[pin] = true
+		}
+		cfg := c.tlsConfig()
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			//only chains verified against the roots count: the server may send any certificate alongside its own
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+					if pinned[base64.StdEncoding.EncodeToString(sum[:])] {
+						return nil
+					}
+				}
+			}
+			return ErrPinMismatch
+		}
+		c.setTLS(cfg)
+	}
+}
+
+//tlsConfig returns a copy of the client's TLS configuration, or a new one
+func (c *Client) tlsConfig() *tls.Config {
+	if c.TLSConfig != nil {
+		return c.TLSConfig.Clone()
+	}
+	return &tls.Config{}
+}
+
+//setTLS installs the TLS configuration in the client's HTTP transport, keeping the transport's other settings
+func (c *Client) setTLS(cfg *tls.Config) {
+	c.TLSConfig = cfg
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{}
+	}
+	if fetchTLS {
+		client := *c.HTTPClient
+		client.Transport = unenforceableTLS{}
+		c.HTTPClient = &client
+		return
+	}
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok || transport == nil {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = cfg
+	client := *c.HTTPClient
+	client.Transport = transport
+	c.HTTPClient = &client
+}
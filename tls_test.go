@@ -0,0 +1,98 @@
+package pushbullet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPinnedKeys(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"iden": "u1"}`))
+	}))
+	defer server.Close()
+	sum := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+	// trust the test server's certificate, as the system roots would trust the API's
+	trusted := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	c := ClientWithKey("apikey", WithTLSConfig(trusted), WithMinTLSVersion(tls.VersionTLS12), WithPinnedKeys(pin))
+	c.BaseURL = server.URL + "/"
+	if u, err := c.GetUser(); err != nil || u.ID != "u1" {
+		t.Fatal("Pinned connection failed:", err)
+	}
+	if c.TLSConfig.MinVersion != tls.VersionTLS12 || trusted.MinVersion != 0 {
+		t.Error("Unexpected TLS configuration:", c.TLSConfig.MinVersion, trusted.MinVersion)
+	}
+
+	c = ClientWithKey("apikey", WithTLSConfig(trusted), WithPinnedKeys("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="))
+	c.BaseURL = server.URL + "/"
+	if _, err := c.GetUser(); !errors.Is(err, ErrPinMismatch) {
+		t.Error("Expected a pin mismatch, got:", err)
+	}
+}
+
+func TestPinnedKeyOutsideVerifiedChain(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Pinned Intermediate"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	extra, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(extra)
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(sum[:])
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"iden": "u1"}`))
+	}))
+	defer server.Close()
+	// the server's own certificate is trusted, and it sends the pinned one alongside without chaining to it
+	server.TLS.Certificates[0].Certificate = append(server.TLS.Certificates[0].Certificate, extra)
+	trusted := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	c := ClientWithKey("apikey", WithTLSConfig(trusted), WithPinnedKeys(pin))
+	c.BaseURL = server.URL + "/"
+	if _, err := c.GetUser(); !errors.Is(err, ErrPinMismatch) {
+		t.Error("Expected an unchained pinned certificate to be rejected, got:", err)
+	}
+}
+
+func TestTLSFailsClosedOverFetch(t *testing.T) {
+	fetchTLS = true
+	defer func() { fetchTLS = false }()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"iden": "u1"}`))
+	}))
+	defer server.Close()
+
+	c := ClientWithKey("apikey", WithAPI(server.URL, ""), WithPinnedKeys("pin"))
+	if _, err := c.GetUser(); !errors.Is(err, ErrTLSUnenforceable) {
+		t.Error("Expected requests to be refused when the pins cannot be enforced:", err)
+	}
+	if _, err := ClientWithKey("apikey", WithAPI(server.URL, "")).GetUser(); err != nil {
+		t.Error("Clients without TLS settings should work over fetch:", err)
+	}
+}
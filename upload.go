@@ -0,0 +1,176 @@
+package pushbullet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//defaultMaxUploadSize is the upload limit assumed when the account does not report one
+const defaultMaxUploadSize = 25 << 20
+
+//ErrUploadTooLarge is returned when a file to upload exceeds the account's upload limit.
+var ErrUploadTooLarge = errors.New("File exceeds the maximum upload size")
+
+//Upload uploads the contents of r as a file, returning the authorization which holds the URL to push. An empty
+//fileType is guessed from the extension of fileName. The contents are streamed rather than buffered, and fail with
+//ErrUploadTooLarge once they exceed the account's upload limit; readers whose size is known, such as files and
+//bytes.Reader, are refused before the upload is authorized.
+func (c *Client) Upload(ctx context.Context, r io.Reader, fileName, fileType string) (Authorization, error) {
+	if len(fileType) == 0 {
+		fileType = fileTypeOf(fileName)
+	}
+	limit, err := c.uploadLimit(ctx)
+	if err != nil {
+		return Authorization{}, err
+	}
+	if readerSize(r) > limit {
+		return Authorization{}, ErrUploadTooLarge
+	}
+	auth, err := c.AuthorizeUploadContext(ctx, fileName, fileType)
+	if err != nil {
+		return auth, err
+	}
+	return auth, c.uploadFile(ctx, auth, fileName, r, limit)
+}
+
+//uploadLimit returns the largest file the account may upload
+func (c *Client) uploadLimit(ctx context.Context) (int64, error) {
+	u, err := c.CurrentUserContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if u.MaxUploadSize <= 0 {
+		return defaultMaxUploadSize, nil
+	}
+	return u.MaxUploadSize, nil
+}
+
+//readerSize returns the number of bytes left in r, or -1 when it cannot be told without reading
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+//uploadFile streams the file to the authorized upload URL, failing with ErrUploadTooLarge after limit bytes. The
+//policy fields come first, as the storage service ignores fields after the file.
+func (c *Client) uploadFile(ctx context.Context, authorization Authorization, fileName string, r io.Reader, limit int64) error {
+	// the form is written around the file rather than copying it in: head holds everything before its contents, and
+	// tail the closing boundary
+	var head, tail bytes.Buffer
+	sw := &switchWriter{&head}
+	w := multipart.NewWriter(sw)
+	d := authorization.Data
+	for _, field := range [][2]string{
+		{"awsaccesskeyid", d.Awsaccesskeyid},
+		{"acl", d.Acl},
+		{"key", d.Key},
+		{"signature", d.Signature},
+		{"policy", d.Policy},
+		{"content-type", d.ContentType},
+	} {
+		if len(field[1]) == 0 {
+			continue
+		}
+		if err := w.WriteField(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	if _, err := w.CreateFormFile("file", filepath.Base(fileName)); err != nil {
+		return err
+	}
+	sw.Writer = &tail
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	file := &limitReader{r: r, n: limit}
+	req, err := http.NewRequestWithContext(ctx, "POST", authorization.UploadURL, io.MultiReader(&head, file, &tail))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if size := readerSize(r); size >= 0 {
+		req.ContentLength = int64(head.Len()) + size + int64(tail.Len())
+	}
+
+	// Submit the request, over the client's transport so its TLS settings apply
+	headSize, tailSize := int64(head.Len()), int64(tail.Len())
+	res, err := c.HTTPClient.Do(req)
+	if errors.Is(err, ErrUploadTooLarge) {
+		return ErrUploadTooLarge
+	}
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	c.count(CounterBytesUploaded, headSize+file.size()+tailSize)
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("Bad Status Result: %s", res.Status)
+	}
+	return nil
+}
+
+//switchWriter writes to a writer which may be replaced between writes
+type switchWriter struct {
+	io.Writer
+}
+
+//limitReader fails with ErrUploadTooLarge once more than n bytes have been read. It is read by the transport's
+//goroutine, so the count is guarded.
+type limitReader struct {
+	r    io.Reader
+	n    int64
+	mu   sync.Mutex
+	read int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if left := l.n - l.read; int64(len(p)) > left+1 {
+		p = p[:left+1]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.n {
+		return 0, ErrUploadTooLarge
+	}
+	return n, err
+}
+
+//size returns the number of bytes read
+func (l *limitReader) size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.read
+}
+
+//fileTypeOf guesses the MIME type of a file from its extension
+func fileTypeOf(fileName string) string {
+	if t := mime.TypeByExtension(filepath.Ext(fileName)); len(t) > 0 {
+		return t
+	}
+	return "application/octet-stream"
+}
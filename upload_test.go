@@ -0,0 +1,113 @@
+package pushbullet_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+	"github.com/kariudo/gopushbullet/pushbullettest"
+)
+
+// uploaded reports whether the server holds a file with the given content
+func uploaded(server *pushbullettest.Server, name, content string) bool {
+	data, ok := server.File(name)
+	return ok && string(data) == content
+}
+
+func TestSendTempFile(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	deleted := make(chan string, 1)
+	server.Intercept(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "DELETE" {
+			deleted <- r.URL.Path
+		}
+		return false
+	})
+	c := server.Client()
+
+	created, err := c.SendTempFile(strings.NewReader("one time report"), "report.txt", 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := server.File("report.txt"); string(data) != "one time report" {
+		t.Error("File not uploaded:", server.Files())
+	}
+	p := server.Pushes()[0]
+	if created.ID != p.ID || p.Type != "file" || p.FileType != "text/plain; charset=utf-8" || p.FileURL != server.URL+"/files/report.txt" {
+		t.Errorf("Unexpected push: %+v", p)
+	}
+	if server.Called("DELETE /pushes/" + p.ID) {
+		t.Fatal("Push deleted before its ttl")
+	}
+	select {
+	case path := <-deleted:
+		if path != "/pushes/"+p.ID {
+			t.Error("Unexpected deletion:", path)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Push not deleted after its ttl")
+	}
+}
+
+func TestSendText(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+
+	if _, err := c.SendText(strings.NewReader("build ok\n"), "make"); err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("compiling...\n", 1000)
+	if _, err := c.SendText(strings.NewReader(long), "make"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SendText(strings.NewReader(long), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	pushes := server.Pushes()
+	note, file, unnamed := pushes[0], pushes[1], pushes[2]
+	if note.Type != "note" || note.Title != "make" || note.Body != "build ok\n" {
+		t.Errorf("Expected a short text to be sent as a note: %+v", note)
+	}
+	if file.Type != "file" || file.FileName != "make.txt" || file.FileType != "text/plain" || !uploaded(server, "make.txt", long) {
+		t.Errorf("Expected a long text to be uploaded: %+v", file)
+	}
+	if !strings.HasPrefix(unnamed.FileName, "output-") || !strings.HasSuffix(unnamed.FileName, ".txt") {
+		t.Error("Unexpected automatic name:", unnamed.FileName)
+	}
+}
+
+func TestUploadLimit(t *testing.T) {
+	server := pushbullettest.NewServer()
+	defer server.Close()
+	c := server.Client()
+	server.SetUser(pushbullet.User{MaxUploadSize: 1024})
+	big := strings.Repeat("x", 8192) // long enough for SendText to upload it
+
+	// a reader of known size is refused before the upload is authorized
+	if _, err := c.SendTempFile(strings.NewReader(big), "big.txt", time.Hour); err != pushbullet.ErrUploadTooLarge {
+		t.Error("Expected ErrUploadTooLarge, got:", err)
+	}
+	if server.Called("POST /upload-request") {
+		t.Error("Upload authorized for an oversized file")
+	}
+	if _, err := c.SendText(strings.NewReader(big), "big"); err != pushbullet.ErrUploadTooLarge {
+		t.Error("Expected ErrUploadTooLarge, got:", err)
+	}
+	// one of unknown size is cut off once it passes the limit
+	if _, err := c.Upload(context.Background(), ioutil.NopCloser(strings.NewReader(big)), "big.txt", ""); err != pushbullet.ErrUploadTooLarge {
+		t.Error("Expected ErrUploadTooLarge, got:", err)
+	}
+	if _, err := c.Upload(context.Background(), ioutil.NopCloser(strings.NewReader(big[:1024])), "fits.txt", ""); err != nil {
+		t.Error("Upload at the limit failed:", err)
+	}
+	if files := server.Files(); len(files) != 1 || !uploaded(server, "fits.txt", big[:1024]) {
+		t.Error("Unexpected uploads:", files)
+	}
+}
@@ -0,0 +1,172 @@
+package pushbullet
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+//urlScheme matches a URL which starts with a scheme, telling "mailto:x" apart from a host and port like "example.com:8080"
+var urlScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:(//|[^0-9/]|$)`)
+
+//URLOption adjusts NormalizeURL.
+type URLOption func(*urlConfig)
+
+type urlConfig struct {
+	httpOnly bool
+}
+
+//HTTPOnly rejects URLs with a scheme other than http or https, such as javascript: or file:.
+func HTTPOnly() URLOption {
+	return func(cfg *urlConfig) {
+		cfg.httpOnly = true
+	}
+}
+
+//NormalizeURL cleans up a user supplied link URL: surrounding whitespace is trimmed, a missing scheme defaults to
+//https, the host is lowercased with internationalized domain names converted to their ASCII (punycode) form, and
+//characters not allowed in URLs are percent-encoded.
+func NormalizeURL(raw string, opts ...URLOption) (string, error) {
+	var cfg urlConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	raw = strings.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "", errors.New("Empty URL")
+	}
+	if !urlScheme.MatchString(raw) {
+		raw = "https://" + strings.TrimPrefix(raw, "//")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if cfg.httpOnly && u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("URL scheme not allowed: " + u.Scheme)
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		if len(u.Hostname()) == 0 {
+			return "", errors.New("URL has no host: " + raw)
+		}
+		host := asciiHost(u.Hostname())
+		if port := u.Port(); len(port) > 0 {
+			host += ":" + port
+		}
+		u.Host = host
+	}
+	return u.String(), nil
+}
+
+//WithLinkNormalization normalizes the URL of every outgoing link push with NormalizeURL, failing the send with a
+//url ValidationError when the URL cannot be normalized.
+func WithLinkNormalization(opts ...URLOption) Option {
+	return func(c *Client) {
+		c.LinkNormalizer = func(raw string) (string, error) {
+			return NormalizeURL(raw, opts...)
+		}
+	}
+}
+
+//asciiHost lowercases a host name, converting internationalized labels to punycode
+func asciiHost(host string) string {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i, label := range labels {
+		if utf8.ValidString(label) && len(label) != utf8.RuneCountInString(label) {
+			labels[i] = "xn--" + punycode(label)
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+//Parameters of the punycode bootstring encoding (RFC 3492)
+const (
+	pcBase        = 36
+	pcTMin        = 1
+	pcTMax        = 26
+	pcSkew        = 38
+	pcDamp        = 700
+	pcInitialBias = 72
+	pcInitialN    = 128
+)
+
+//punycode encodes a label as described in RFC 3492
+func punycode(label string) string {
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	handled := basic
+	if basic > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := rune(pcInitialN), 0, pcInitialBias
+	for handled < len(runes) {
+		m := rune(utf8.MaxRune)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			q := delta
+			for k := pcBase; ; k += pcBase {
+				t := k - bias
+				if t < pcTMin {
+					t = pcTMin
+				} else if t > pcTMax {
+					t = pcTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punycodeDigit(t+(q-t)%(pcBase-t)))
+				q = (q - t) / (pcBase - t)
+			}
+			out = append(out, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+func punycodeAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= pcDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > ((pcBase-pcTMin)*pcTMax)/2 {
+		delta /= pcBase - pcTMin
+		k += pcBase
+	}
+	return k + (pcBase-pcTMin+1)*delta/(delta+pcSkew)
+}
+
+func punycodeDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
@@ -0,0 +1,46 @@
+package pushbullet
+
+import "testing"
+
+func TestNormalizeURL(t *testing.T) {
+	cases := map[string]string{
+		"  example.com/a b \n":              "https://example.com/a%20b",
+		"HTTP://Example.COM:8080/Path":      "http://example.com:8080/Path",
+		"//cdn.example.com/x.png":           "https://cdn.example.com/x.png",
+		"https://münchen.de/":               "https://xn--mnchen-3ya.de/",
+		"bücher.example":                    "https://xn--bcher-kva.example",
+		"http://例え.テスト/":                    "http://xn--r8jz45g.xn--zckzah/",
+		"mailto:elon@tesla.com":             "mailto:elon@tesla.com",
+		"localhost:8080/status":             "https://localhost:8080/status",
+		"https://example.com/?q=1#fragment": "https://example.com/?q=1#fragment",
+	}
+	for raw, want := range cases {
+		if got, err := NormalizeURL(raw); err != nil || got != want {
+			t.Errorf("NormalizeURL(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "   ", "https://"} {
+		if got, err := NormalizeURL(raw); err == nil {
+			t.Errorf("NormalizeURL(%q) = %q, expected an error", raw, got)
+		}
+	}
+	for _, raw := range []string{"javascript:alert(1)", "file:///etc/passwd", "mailto:elon@tesla.com"} {
+		if got, err := NormalizeURL(raw, HTTPOnly()); err == nil {
+			t.Errorf("NormalizeURL(%q, HTTPOnly()) = %q, expected an error", raw, got)
+		}
+	}
+}
+
+func TestLinkNormalization(t *testing.T) {
+	mockServer, c := mockHTTP(200, `{}`)
+	defer mockServer.Close()
+	WithLinkNormalization(HTTPOnly())(c)
+
+	if err := c.SendLink("Build Test", "", " example.com "); err != nil {
+		t.Error(err)
+	}
+	err := c.SendLink("Build Test", "", "file:///etc/passwd")
+	if errs, ok := err.(ValidationErrors); !ok || errs[0].Field != "url" {
+		t.Errorf("Expected url validation error, got %T: %v", err, err)
+	}
+}
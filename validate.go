@@ -0,0 +1,91 @@
+package pushbullet
+
+import (
+	"net/url"
+	"strings"
+)
+
+//ValidationError describes a push field which would be rejected by Pushbullet.
+type ValidationError struct {
+	Field   string // JSON name of the offending field
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return "Invalid " + e.Field + ": " + e.Message
+}
+
+//ValidationErrors collects every problem found with a push.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+//Validate checks the push against the rules for its type, returning ValidationErrors naming each offending field:
+//a note needs a title or body, a link a parseable absolute URL, and a file its file_url and file_type. An email
+//target must be a well formed address and a channel tag target a valid tag. At most one target field may be set;
+//with none the push goes to all of the user's devices. Sends validate every push before it is posted, so problems
+//surface as field errors rather than an opaque 400 from the API.
+func (p PushMessage) Validate() error {
+	var errs ValidationErrors
+	invalid := func(field, message string) {
+		errs = append(errs, &ValidationError{Field: field, Message: message})
+	}
+
+	switch p.Type {
+	case "note":
+		if len(p.Title) == 0 && len(p.Body) == 0 {
+			invalid("title", "a note needs a title or body")
+		}
+	case "link":
+		if len(p.URL) == 0 {
+			invalid("url", "a link needs a URL")
+		} else if u, err := url.Parse(p.URL); err != nil || len(u.Scheme) == 0 {
+			invalid("url", "not an absolute URL: "+p.URL)
+		}
+	case "file":
+		if len(p.FileURL) == 0 {
+			invalid("file_url", "a file needs the URL of its upload")
+		}
+		if len(p.FileType) == 0 {
+			invalid("file_type", "a file needs a MIME type")
+		}
+	case "address", "checklist":
+		// deprecated types, coerced to notes by Pushbullet
+	case "":
+		invalid("type", "missing push type")
+	default:
+		invalid("type", "unknown push type "+p.Type)
+	}
+
+	if len(p.Email) > 0 {
+		if _, err := NormalizeEmail(p.Email); err != nil {
+			errs = append(errs, err.(*ValidationError))
+		}
+	}
+	if len(p.ChannelTag) > 0 {
+		if err := ValidateChannelTag(p.ChannelTag); err != nil {
+			errs = append(errs, err.(*ValidationError))
+		}
+	}
+
+	var targets []string
+	for _, t := range [][2]string{{"device_iden", p.DeviceID}, {"email", p.Email}, {"channel_tag", p.ChannelTag}, {"client_iden", p.ClientID}} {
+		if len(t[1]) > 0 {
+			targets = append(targets, t[0])
+		}
+	}
+	if len(targets) > 1 {
+		invalid(targets[1], "only one target may be set, already have "+targets[0])
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package pushbullet
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		p      PushMessage
+		fields []string
+	}{
+		{PushMessage{Type: "note", Body: "hi"}, nil},
+		{PushMessage{Type: "note"}, []string{"title"}},
+		{PushMessage{Type: "link", URL: "https://example.com", DeviceID: "d1"}, nil},
+		{PushMessage{Type: "link", URL: "example.com"}, []string{"url"}},
+		{PushMessage{Type: "link"}, []string{"url"}},
+		{PushMessage{Type: "file", FileURL: "https://dl.pushbulletusercontent.com/cat.jpg"}, []string{"file_type"}},
+		{PushMessage{Type: "file"}, []string{"file_url", "file_type"}},
+		{PushMessage{Type: "note", Title: "hi", DeviceID: "d1", Email: "a@b.c"}, []string{"email"}},
+		{PushMessage{Type: "note", Title: "hi", Email: "friend at example.com"}, []string{"email"}},
+		{PushMessage{Type: "note", Title: "hi", ChannelTag: "my channel"}, []string{"channel_tag"}},
+		{PushMessage{Type: "sms", Body: "hi"}, []string{"type"}},
+		{PushMessage{Body: "hi"}, []string{"type"}},
+	}
+	for _, tc := range cases {
+		err := tc.p.Validate()
+		if tc.fields == nil {
+			if err != nil {
+				t.Errorf("%+v: unexpected error %v", tc.p, err)
+			}
+			continue
+		}
+		errs, ok := err.(ValidationErrors)
+		if !ok || len(errs) != len(tc.fields) {
+			t.Errorf("%+v: expected errors for %v, got %v", tc.p, tc.fields, err)
+			continue
+		}
+		for i, field := range tc.fields {
+			if errs[i].Field != field {
+				t.Errorf("%+v: expected error for %s, got %v", tc.p, field, errs[i])
+			}
+		}
+	}
+}
+
+func TestSendValidatesBeforePosting(t *testing.T) {
+	mockServer, c := mockHTTP(400, `{"error": {"type": "invalid_request", "message": "bad push"}}`)
+	defer mockServer.Close()
+
+	_, err := c.SendPush(PushRequest{Type: "link", URL: "not a url"})
+	if errs, ok := err.(ValidationErrors); !ok || errs[0].Field != "url" {
+		t.Errorf("Expected a url validation error instead of the API error, got %T: %v", err, err)
+	}
+}
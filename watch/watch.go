@@ -0,0 +1,191 @@
+//Package watch monitors URLs and TCP endpoints and sends a notification whenever one goes down or recovers.
+//
+//A state change is only reported once it has been observed on Threshold consecutive checks, suppressing
+//notifications for endpoints which flap between up and down.
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+//Check probes an endpoint, returning nil when it is healthy.
+type Check interface {
+	Check(ctx context.Context) error
+}
+
+//CheckFunc adapts a function into a Check.
+type CheckFunc func(ctx context.Context) error
+
+//Check calls f(ctx).
+func (f CheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+//HTTP returns a Check which requires a GET of url to answer with a status below 400.
+func HTTP(url string, client *http.Client) Check {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return CheckFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode >= 400 {
+			return fmt.Errorf("Bad Status Result: %s", res.Status)
+		}
+		return nil
+	})
+}
+
+//TCP returns a Check which requires a TCP connection to addr (host:port) to succeed.
+func TCP(addr string) Check {
+	return CheckFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+//Watcher runs registered checks and notifies on state transitions.
+type Watcher struct {
+	Notifier pushbullet.Notifier
+	Options  []pushbullet.NotifyOption // applied to every notification, e.g. a target device
+	// Threshold is the number of consecutive results required to change state, defaults to 2.
+	Threshold int
+	// Timeout bounds each check, defaults to the check interval.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	entries []*entry
+}
+
+type entry struct {
+	name     string
+	check    Check
+	interval time.Duration
+
+	up          bool
+	known       bool
+	streak      int
+	streakStart time.Time // first check of the current streak
+	since       time.Time // first check observed in the current state
+	lastErr     error
+}
+
+//New returns a Watcher which reports transitions through n.
+func New(n pushbullet.Notifier, opts ...pushbullet.NotifyOption) *Watcher {
+	return &Watcher{Notifier: n, Options: opts}
+}
+
+//Add registers a check to run every interval once Run is called.
+func (w *Watcher) Add(name string, check Check, interval time.Duration) error {
+	if interval <= 0 {
+		return errors.New("Invalid check interval for " + name)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.entries = append(w.entries, &entry{name: name, check: check, interval: interval})
+	return nil
+}
+
+//Run checks every registered endpoint on its interval until the context is done.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mu.Lock()
+	entries := append([]*entry(nil), w.entries...)
+	w.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, e := range entries {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			ticker := time.NewTicker(e.interval)
+			defer ticker.Stop()
+			for {
+				w.run(ctx, e)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(e)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (w *Watcher) run(ctx context.Context, e *entry) {
+	timeout := w.Timeout
+	if timeout == 0 {
+		timeout = e.interval
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := e.check.Check(checkCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+	w.observe(ctx, e, err, time.Now())
+}
+
+//observe records a check result and notifies once a new state has persisted for Threshold checks. Outages are
+//measured from the first failed check to the first successful one, not from when each state was confirmed.
+func (w *Watcher) observe(ctx context.Context, e *entry, err error, now time.Time) {
+	threshold := w.Threshold
+	if threshold == 0 {
+		threshold = 2
+	}
+	up := err == nil
+	if err != nil {
+		e.lastErr = err
+	}
+	if e.known && up == e.up {
+		e.streak = 0
+		return
+	}
+	if e.streak == 0 {
+		e.streakStart = now
+	}
+	e.streak++
+	if e.streak < threshold {
+		return
+	}
+
+	wasKnown, downSince := e.known, e.since
+	e.known, e.up, e.streak, e.since = true, up, 0, e.streakStart
+	if !wasKnown && up {
+		// the first settled state is only worth reporting when it is a failure
+		return
+	}
+	var title, body string
+	if up {
+		title = e.name + " is UP"
+		body = "Recovered after " + e.since.Sub(downSince).Round(time.Second).String() + " down."
+	} else {
+		title = e.name + " is DOWN"
+		body = e.lastErr.Error()
+	}
+	if nerr := w.Notifier.Notify(ctx, title, body, w.Options...); nerr != nil {
+		// retry the notification on the next check rather than lose the transition
+		e.known, e.up, e.since = wasKnown, !up, downSince
+		e.streak = threshold - 1
+	}
+}
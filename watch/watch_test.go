@@ -0,0 +1,104 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pushbullet "github.com/kariudo/gopushbullet"
+)
+
+func TestTransitionsWithFlapSuppression(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	w := New(rec)
+	e := &entry{name: "api"}
+	down := errors.New("connection refused")
+	now := time.Now()
+
+	results := []error{nil, nil, down, nil, down, down, down, nil, nil}
+	for i, err := range results {
+		w.observe(context.Background(), e, err, now.Add(time.Duration(i)*time.Minute))
+	}
+	if len(rec.Pushes()) != 2 {
+		t.Fatalf("Expected a down and an up notification, got: %+v", rec.Pushes())
+	}
+	if rec.Pushes()[0].Title != "api is DOWN" || rec.Pushes()[0].Body != "connection refused" {
+		t.Error("Unexpected down notification:", rec.Pushes()[0])
+	}
+	if rec.Pushes()[1].Title != "api is UP" || rec.Pushes()[1].Body != "Recovered after 3m0s down." {
+		t.Error("Unexpected up notification:", rec.Pushes()[1])
+	}
+}
+
+func TestOutageMeasuredFromFirstFailure(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	w := New(rec)
+	w.Threshold = 3
+	e := &entry{name: "api"}
+	start := time.Now()
+	down := errors.New("connection refused")
+
+	at := func(minute int, err error) {
+		w.observe(context.Background(), e, err, start.Add(time.Duration(minute)*time.Minute))
+	}
+	at(0, nil)
+	at(1, nil)
+	at(2, nil)
+	at(10, down)
+	at(20, down)
+	at(21, down)
+	at(30, nil)
+	at(31, nil)
+	at(40, nil)
+	if len(rec.Pushes()) != 2 || rec.Pushes()[1].Body != "Recovered after 20m0s down." {
+		t.Errorf("Expected the outage to run from the first failure to the first success: %+v", rec.Pushes())
+	}
+}
+
+func TestAddRejectsInvalidInterval(t *testing.T) {
+	w := New(&pushbullet.RecordingNotifier{})
+	if err := w.Add("api", TCP("127.0.0.1:1"), 0); err == nil {
+		t.Error("Expected a zero interval to be rejected")
+	}
+}
+
+func TestInitialDownIsReported(t *testing.T) {
+	rec := &pushbullet.RecordingNotifier{}
+	w := New(rec)
+	w.Threshold = 1
+	e := &entry{name: "db"}
+	w.observe(context.Background(), e, errors.New("timeout"), time.Now())
+	if len(rec.Pushes()) != 1 {
+		t.Error("Expected initial failure to be reported")
+	}
+}
+
+func TestChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(500)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if err := HTTP(server.URL, nil).Check(ctx); err != nil {
+		t.Error(err)
+	}
+	if err := HTTP(server.URL+"/broken", nil).Check(ctx); err == nil {
+		t.Error("Expected 500 to fail the check")
+	}
+	if err := TCP(server.Listener.Addr().String()).Check(ctx); err != nil {
+		t.Error(err)
+	}
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := l.Addr().String()
+	l.Close()
+	if err := TCP(addr).Check(ctx); err == nil {
+		t.Error("Expected closed port to fail the check")
+	}
+}
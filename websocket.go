@@ -0,0 +1,341 @@
+package pushbullet
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//websocketGUID is appended to the handshake key to form the accept header, per RFC 6455
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+//maxStreamMessage bounds a message read from the stream, which only carries small JSON documents
+const maxStreamMessage = 1 << 20
+
+//Websocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+//deflateExtension is the compression extension offered by a client dialing with compress set, per RFC 7692
+const deflateExtension = "permessage-deflate"
+
+//deflateWindow is the most history a compressed message may refer back to, the default server_max_window_bits of 15
+const deflateWindow = 1 << 15
+
+//deflateTail completes a compressed message: the empty block the sender stripped, then a final empty block to end
+//the stream
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+//wsConn is a minimal client side websocket connection: enough to read the messages of the event stream and
+//answer pings, with permessage-deflate as its only extension and no subprotocols
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	idle time.Duration // bounds the wait for each frame when set
+
+	// deflate is set when the server accepted compression. Unless it takes no context over, each message may refer
+	// back to the ones before it, so the last deflateWindow bytes are kept in history.
+	deflate   bool
+	noContext bool
+	history   []byte
+
+	wmu sync.Mutex
+}
+
+//dialWebsocket opens a websocket connection to a ws:// or wss:// URL, using tlsConfig for wss and offering
+//compression when compress is set
+func dialWebsocket(ctx context.Context, rawURL string, tlsConfig *tls.Config, compress bool) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	switch u.Scheme {
+	case "ws":
+		if len(u.Port()) == 0 {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	case "wss":
+		if len(u.Port()) == 0 {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+	default:
+		return nil, errors.New("Unsupported stream URL scheme: " + u.Scheme)
+	}
+
+	var d net.Dialer
+	raw, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	// the handshake is bounded by the context. The watcher only touches the TCP connection, which stays the same
+	// when TLS wraps it, and has exited before the deadline is cleared.
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			raw.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+	fail := func(err error) (*wsConn, error) {
+		close(stop)
+		<-done
+		raw.Close()
+		return nil, contextError(ctx, err)
+	}
+
+	conn := raw
+
+	if u.Scheme == "wss" {
+		cfg := tlsConfig
+		if cfg == nil {
+			cfg = &tls.Config{}
+		}
+		if len(cfg.ServerName) == 0 {
+			cfg = cfg.Clone()
+			cfg.ServerName = u.Hostname()
+		}
+		tlsConn := tls.Client(raw, cfg)
+		if err = tlsConn.Handshake(); err != nil {
+			return fail(err)
+		}
+		conn = tlsConn
+	}
+
+	ws, err := handshake(conn, u, compress)
+	if err != nil {
+		return fail(err)
+	}
+	close(stop)
+	<-done
+	raw.SetDeadline(time.Time{})
+	return ws, nil
+}
+
+//handshake upgrades the connection to a websocket
+func handshake(conn net.Conn, u *url.URL, compress bool) (*wsConn, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{
+		"Upgrade":               {"websocket"},
+		"Connection":            {"Upgrade"},
+		"Sec-WebSocket-Key":     {key},
+		"Sec-WebSocket-Version": {"13"},
+	}}
+	if compress {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateExtension)
+	}
+	// Request.Write only understands http URLs
+	wire := *u
+	wire.Scheme = "http"
+	req.URL = &wire
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return nil, &StatusError{StatusCode: res.StatusCode}
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	if !strings.EqualFold(res.Header.Get("Upgrade"), "websocket") ||
+		res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		return nil, errors.New("Invalid websocket handshake response")
+	}
+	ws := &wsConn{conn: conn, r: r}
+	if ext := res.Header.Get("Sec-WebSocket-Extensions"); len(ext) > 0 {
+		params := strings.Split(ext, ";")
+		if !compress || strings.TrimSpace(params[0]) != deflateExtension {
+			return nil, errors.New("Unrequested websocket extension: " + ext)
+		}
+		ws.deflate = true
+		for _, param := range params[1:] {
+			ws.noContext = ws.noContext || strings.TrimSpace(param) == "server_no_context_takeover"
+		}
+	}
+	return ws, nil
+}
+
+//contextError prefers the context's error over the one caused by its cancellation
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+//ReadMessage returns the next text or binary message, answering pings, reassembling fragments and decompressing on
+//the way. A close frame is returned as io.EOF.
+func (ws *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	compressed := false
+	for {
+		fin, rsv1, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if rsv1 && (!ws.deflate || opcode >= opClose || opcode == opContinuation) {
+			return nil, errors.New("Unexpected compressed websocket frame")
+		}
+		compressed = compressed || rsv1
+		switch opcode {
+		case opPing:
+			if err = ws.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			ws.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			if len(message)+len(payload) > maxStreamMessage {
+				return nil, errors.New("Stream message too large")
+			}
+			message = append(message, payload...)
+			if fin && compressed {
+				return ws.inflate(message)
+			}
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("Unknown websocket opcode %#x", opcode)
+		}
+	}
+}
+
+//inflate decompresses a message, keeping the history later messages may refer to
+func (ws *wsConn) inflate(message []byte) ([]byte, error) {
+	r := flate.NewReaderDict(io.MultiReader(bytes.NewReader(message), bytes.NewReader(deflateTail)), ws.history)
+	defer r.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(r, maxStreamMessage+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > maxStreamMessage {
+		return nil, errors.New("Stream message too large")
+	}
+	if !ws.noContext {
+		ws.history = append(ws.history, out...)
+		if len(ws.history) > deflateWindow {
+			ws.history = append([]byte(nil), ws.history[len(ws.history)-deflateWindow:]...)
+		}
+	}
+	return out, nil
+}
+
+//readFrame reads a frame, reporting whether it is the last of its message and whether RSV1, the compression bit
+//of permessage-deflate, is set
+func (ws *wsConn) readFrame() (fin, rsv1 bool, opcode byte, payload []byte, err error) {
+	if ws.idle > 0 {
+		ws.conn.SetReadDeadline(time.Now().Add(ws.idle))
+	}
+	var head [2]byte
+	if _, err = io.ReadFull(ws.r, head[:]); err != nil {
+		return
+	}
+	fin, rsv1, opcode = head[0]&0x80 != 0, head[0]&0x40 != 0, head[0]&0x0F
+	masked, n := head[1]&0x80 != 0, uint64(head[1]&0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(ws.r, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(ws.r, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxStreamMessage {
+		err = errors.New("Stream message too large")
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(ws.r, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(ws.r, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+//writeFrame sends a single masked frame, as clients must
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+	frame := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, 0x80|127), ext[:]...)
+	}
+	var mask [4]byte
+	rand.Read(mask[:])
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+//SetReadDeadline bounds the wait for the next frame.
+func (ws *wsConn) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+//Close closes the connection without a closing handshake.
+func (ws *wsConn) Close() error {
+	return ws.conn.Close()
+}